@@ -7,15 +7,14 @@ import (
 )
 
 // GetAppInfo 获取应用基本信息
-func (c *Client) GetAppInfo() (*AppInfo, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+EndpointInfo, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
+func (c *Client) GetAppInfo(opts ...RequestOption) (*AppInfo, error) {
+	resp, err := c.send(&apiRequest{
+		name:   "GetAppInfo",
+		opts:   opts,
+		method: http.MethodGet,
+		path:   EndpointInfo,
+		family: FamilyApp,
+	})
 	if err != nil {
 		return nil, err
 	}
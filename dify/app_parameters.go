@@ -7,15 +7,14 @@ import (
 )
 
 // GetAppParameters 获取应用参数
-func (c *Client) GetAppParameters() (*AppParameters, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+EndpointParameters, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
+func (c *Client) GetAppParameters(opts ...RequestOption) (*AppParameters, error) {
+	resp, err := c.send(&apiRequest{
+		name:   "GetAppParameters",
+		opts:   opts,
+		method: http.MethodGet,
+		path:   EndpointParameters,
+		family: FamilyApp,
+	})
 	if err != nil {
 		return nil, err
 	}
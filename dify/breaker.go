@@ -0,0 +1,335 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EndpointFamily 端点分组，熔断器按分组分别统计
+type EndpointFamily string
+
+// 端点分组
+const (
+	FamilyChat       EndpointFamily = "chat"       // 对话、消息反馈与会话
+	FamilyCompletion EndpointFamily = "completion" // 文本生成与停止响应
+	FamilyWorkflows  EndpointFamily = "workflows"  // 工作流
+	FamilyFiles      EndpointFamily = "files"      // 文件上传
+	FamilyApp        EndpointFamily = "app"        // 应用信息、参数与语音
+	FamilyKnowledge  EndpointFamily = "knowledge"  // 知识库
+)
+
+// ErrCircuitOpen 熔断器处于打开状态，请求被直接拒绝
+var ErrCircuitOpen = errors.New("dify circuit breaker is open")
+
+// CircuitOpenError 熔断拒绝错误，可以通过 errors.Is(err, ErrCircuitOpen) 判断
+type CircuitOpenError struct {
+	Family     EndpointFamily // 被熔断的端点分组
+	RetryAfter time.Duration  // 距离进入半开状态的剩余时间
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s (retry after %s)", ErrCircuitOpen, e.Family, e.RetryAfter)
+}
+
+// Is 使 errors.Is(err, ErrCircuitOpen) 返回 true
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+// 熔断器状态
+const (
+	StateClosed   CircuitState = iota // 关闭，请求正常通过
+	StateOpen                         // 打开，请求直接失败
+	StateHalfOpen                     // 半开，只放行少量探测请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// BreakerConfig 熔断器配置
+type BreakerConfig struct {
+	ConsecutiveFailures int           // 连续失败多少次后打开，默认 5，小于 0 表示不按连续失败判断
+	FailureRate         float64       // 统计窗口内失败率达到该值后打开，0 表示不按失败率判断
+	MinRequests         int           // 按失败率判断前窗口内的最少请求数，默认 20
+	Window              time.Duration // 失败率统计窗口，默认 1 分钟
+	OpenTimeout         time.Duration // 打开后多久进入半开状态，默认 30 秒
+	HalfOpenProbes      int           // 半开状态下允许的探测请求数，全部成功后关闭，默认 1
+
+	// OnStateChange 状态变化回调，可用于告警，在持有锁之外调用
+	OnStateChange func(family EndpointFamily, from, to CircuitState)
+}
+
+// breakerBuckets 失败率统计窗口的分桶数量
+const breakerBuckets = 10
+
+type breakerBucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// circuit 单个端点分组的熔断状态
+type circuit struct {
+	state       CircuitState
+	openedAt    time.Time
+	consecutive int
+	probes      int
+	successes   int
+	buckets     [breakerBuckets]breakerBucket
+}
+
+// CircuitBreaker 按端点分组熔断
+//
+// 连续失败次数或窗口内失败率达到阈值时打开，打开期间请求直接返回 *CircuitOpenError；
+// 超过 OpenTimeout 后进入半开状态放行探测请求，探测全部成功则关闭，任一失败则重新打开。
+// 同一个熔断器可以同时用于 dify.Client 和 knowledge.Client。
+type CircuitBreaker struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	circuits map[EndpointFamily]*circuit
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.ConsecutiveFailures == 0 {
+		cfg.ConsecutiveFailures = 5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+
+	return &CircuitBreaker{
+		cfg:      cfg,
+		circuits: make(map[EndpointFamily]*circuit),
+	}
+}
+
+// WithCircuitBreaker 设置客户端使用的熔断器
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return clientOptionFunc(func(c *Client) {
+		c.breaker = breaker
+	})
+}
+
+// State 返回端点分组当前的熔断状态
+func (b *CircuitBreaker) State(family EndpointFamily) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb, ok := b.circuits[family]
+	if !ok {
+		return StateClosed
+	}
+	if cb.state == StateOpen && time.Since(cb.openedAt) >= b.cfg.OpenTimeout {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Allow 判断请求是否可以通过，通过时返回的 done 需要在请求结束后以请求上下文和请求结果调用
+//
+// 连接错误、超时（包括 http.Client.Timeout 和 WithRequestTimeout）以及 5xx 响应计为失败；
+// 调用方主动取消请求上下文，以及请求未发出就失败（如 ErrNoPoolMember）时不计入统计。
+func (b *CircuitBreaker) Allow(family EndpointFamily) (done func(ctx context.Context, resp *http.Response, err error), err error) {
+	b.mu.Lock()
+
+	cb := b.circuitLocked(family)
+	now := time.Now()
+	from := cb.state
+
+	if cb.state == StateOpen {
+		if wait := b.cfg.OpenTimeout - now.Sub(cb.openedAt); wait > 0 {
+			b.mu.Unlock()
+			return nil, &CircuitOpenError{Family: family, RetryAfter: wait}
+		}
+		cb.state = StateHalfOpen
+		cb.probes = 0
+		cb.successes = 0
+	}
+
+	probe := cb.state == StateHalfOpen
+	if probe {
+		if cb.probes >= b.cfg.HalfOpenProbes {
+			b.mu.Unlock()
+			return nil, &CircuitOpenError{Family: family}
+		}
+		cb.probes++
+	}
+	to := cb.state
+	b.mu.Unlock()
+	b.notify(family, from, to)
+
+	var once sync.Once
+	return func(ctx context.Context, resp *http.Response, err error) {
+		once.Do(func() {
+			if err != nil && (errors.Is(err, ErrNoPoolMember) || (ctx != nil && ctx.Err() == context.Canceled)) {
+				b.cancel(family, probe)
+				return
+			}
+			b.record(family, probe, !breakerFailure(resp, err))
+		})
+	}, nil
+}
+
+// Reset 将端点分组恢复为关闭状态
+func (b *CircuitBreaker) Reset(family EndpointFamily) {
+	b.mu.Lock()
+	cb := b.circuitLocked(family)
+	from := cb.state
+	*cb = circuit{}
+	b.mu.Unlock()
+
+	b.notify(family, from, StateClosed)
+}
+
+func (b *CircuitBreaker) circuitLocked(family EndpointFamily) *circuit {
+	cb, ok := b.circuits[family]
+	if !ok {
+		cb = &circuit{}
+		b.circuits[family] = cb
+	}
+	return cb
+}
+
+// cancel 请求被取消时归还探测名额
+func (b *CircuitBreaker) cancel(family EndpointFamily, probe bool) {
+	if !probe {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cb := b.circuitLocked(family); cb.state == StateHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// record 记录请求结果并更新状态
+func (b *CircuitBreaker) record(family EndpointFamily, probe bool, success bool) {
+	b.mu.Lock()
+
+	cb := b.circuitLocked(family)
+	now := time.Now()
+	from := cb.state
+
+	if probe {
+		if cb.state != StateHalfOpen {
+			// 其他探测已经改变了状态
+			b.mu.Unlock()
+			return
+		}
+		if success {
+			cb.successes++
+			if cb.successes >= b.cfg.HalfOpenProbes {
+				*cb = circuit{}
+			}
+		} else {
+			cb.state = StateOpen
+			cb.openedAt = now
+		}
+		to := cb.state
+		b.mu.Unlock()
+		b.notify(family, from, to)
+		return
+	}
+
+	if cb.state != StateClosed {
+		b.mu.Unlock()
+		return
+	}
+
+	bucket := cb.bucketLocked(now, b.cfg.Window)
+	bucket.total++
+	if success {
+		cb.consecutive = 0
+	} else {
+		bucket.failures++
+		cb.consecutive++
+	}
+
+	if !success && b.shouldOpenLocked(cb, now) {
+		cb.state = StateOpen
+		cb.openedAt = now
+		cb.consecutive = 0
+		cb.buckets = [breakerBuckets]breakerBucket{}
+	}
+	to := cb.state
+	b.mu.Unlock()
+
+	b.notify(family, from, to)
+}
+
+func (b *CircuitBreaker) shouldOpenLocked(cb *circuit, now time.Time) bool {
+	if b.cfg.ConsecutiveFailures > 0 && cb.consecutive >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+	if b.cfg.FailureRate <= 0 {
+		return false
+	}
+
+	total, failures := 0, 0
+	for _, bucket := range cb.buckets {
+		if now.Sub(bucket.start) < b.cfg.Window {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+	return total >= b.cfg.MinRequests && float64(failures)/float64(total) >= b.cfg.FailureRate
+}
+
+// bucketLocked 返回当前时间所在的统计分桶，过期的分桶会被重置
+func (cb *circuit) bucketLocked(now time.Time, window time.Duration) *breakerBucket {
+	width := window / breakerBuckets
+	if width <= 0 {
+		width = 1
+	}
+	slot := now.UnixNano() / int64(width)
+	bucket := &cb.buckets[slot%breakerBuckets]
+	start := time.Unix(0, slot*int64(width))
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+func (b *CircuitBreaker) notify(family EndpointFamily, from, to CircuitState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(family, from, to)
+	}
+}
+
+// breakerFailure 判断请求结果是否应计为熔断失败：连接错误或 5xx 响应
+func breakerFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp != nil && resp.StatusCode >= http.StatusInternalServerError
+}
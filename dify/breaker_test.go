@@ -0,0 +1,164 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试连续失败后熔断、快速失败，以及半开探测成功后恢复
+func TestCircuitBreaker(t *testing.T) {
+	var healthy int32
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"name":"app"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	breaker := NewCircuitBreaker(BreakerConfig{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         50 * time.Millisecond,
+		OnStateChange: func(family EndpointFamily, from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, string(family)+":"+from.String()+"->"+to.String())
+		},
+	})
+	client := NewClient("test-key", WithBaseURL(server.URL), WithCircuitBreaker(breaker))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetAppInfo(); err == nil {
+			t.Fatal("expected error from failing server")
+		}
+	}
+	if state := breaker.State(FamilyApp); state != StateOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	_, err := client.GetAppInfo()
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Family != FamilyApp {
+		t.Fatalf("error = %v, want circuit open for app", err)
+	}
+	if hits != 2 {
+		t.Fatalf("server hits = %d, want 2", hits)
+	}
+	if breaker.State(FamilyChat) != StateClosed {
+		t.Fatal("other families should not be affected")
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.GetAppInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if state := breaker.State(FamilyApp); state != StateClosed {
+		t.Fatalf("state = %s, want closed", state)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"app:closed->open", "app:open->half-open", "app:half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+// 测试上游变慢导致的超时计为失败并触发熔断，调用方主动取消的请求不计入统计
+func TestCircuitBreakerTripsOnTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(200 * time.Millisecond):
+		}
+		w.Write([]byte(`{"name":"app"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 3, OpenTimeout: time.Minute})
+
+	// 调用方取消的请求不计为失败
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := NewClient("test-key", WithBaseURL(server.URL), WithCircuitBreaker(breaker)).WithContext(ctx)
+	for i := 0; i < 3; i++ {
+		if _, err := canceled.GetAppInfo(); err == nil {
+			t.Fatal("expected canceled request to fail")
+		}
+	}
+	if state := breaker.State(FamilyApp); state != StateClosed {
+		t.Fatalf("state = %s after canceled requests, want closed", state)
+	}
+
+	// http.Client.Timeout 超时计为失败
+	client := NewClient("test-key", WithBaseURL(server.URL), WithCircuitBreaker(breaker),
+		WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetAppInfo(); err == nil {
+			t.Fatal("expected timeout")
+		}
+	}
+	if state := breaker.State(FamilyApp); state != StateClosed {
+		t.Fatalf("state = %s after 2 timeouts, want closed", state)
+	}
+
+	// WithRequestTimeout 超时同样计为失败
+	if _, err := client.GetAppInfo(WithRequestTimeout(20 * time.Millisecond)); err == nil {
+		t.Fatal("expected timeout")
+	}
+	if state := breaker.State(FamilyApp); state != StateOpen {
+		t.Fatalf("state = %s after 3 timeouts, want open", state)
+	}
+	if _, err := client.GetAppInfo(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+// 测试限流器排队超时不计入熔断统计
+func TestCircuitBreakerIgnoresLimiterErrors(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(`{"name":"app"}`))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 3, OpenTimeout: time.Minute})
+	limiter := NewLimiter(LimiterConfig{AppRate: 0.01, AppBurst: 1})
+	client := NewClient("test-key", WithBaseURL(server.URL), WithCircuitBreaker(breaker), WithLimiter(limiter))
+
+	if _, err := client.GetAppInfo(); err != nil {
+		t.Fatal(err)
+	}
+	// 令牌已用完，后续请求在排队时超时
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetAppInfo(WithRequestTimeout(10 * time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("error = %v, want deadline exceeded", err)
+		}
+	}
+	if state := breaker.State(FamilyApp); state != StateClosed {
+		t.Fatalf("state = %s after limiter timeouts, want closed", state)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("server hits = %d, want 1", n)
+	}
+}
@@ -2,7 +2,6 @@ package dify
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,80 +10,91 @@ import (
 )
 
 // CreateChat 发送阻塞模式的完成请求
-func (c *Client) CreateChat(req *ChatRequest) (*ChatResponse, error) {
+func (c *Client) CreateChat(req *ChatRequest, opts ...RequestOption) (*ChatResponse, error) {
 
-	endpoint := fmt.Sprintf("%s%s", c.BaseURL, EndpointChat)
+	// 设置响应模式为阻塞模式，复制请求避免修改调用方的结构体
+	payload := *req
+	payload.ResponseMode = ResponseModeBlocking
 
-	// 设置响应模式为阻塞模式
-	req.ResponseMode = ResponseModeBlocking
-
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(&payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	r := &apiRequest{
+		name:           "CreateChat",
+		opts:           opts,
+		method:         http.MethodPost,
+		path:           EndpointChat,
+		family:         FamilyChat,
+		body:           body,
+		contentType:    "application/json",
+		fileIDs:        uploadFileIDs(req.Files),
+		conversationID: req.ConversationId,
+		user:           req.User,
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.send(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("request failed with status %d: %w", resp.StatusCode, DecodeError(resp))
 	}
 
 	var result ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	c.pin(r, result.ConversationId, result.MessageID)
 
 	return &result, nil
 }
 
 // CreateStreamingChat 发送流式模式的完成请求
-func (c *Client) CreateStreamingChat(req *ChatRequest, handler StreamHandler) error {
+func (c *Client) CreateStreamingChat(req *ChatRequest, handler StreamHandler, opts ...RequestOption) (err error) {
 
-	endpoint := fmt.Sprintf("%s%s", c.BaseURL, EndpointChat)
+	// 设置响应模式为流式模式，复制请求避免修改调用方的结构体
+	payload := *req
+	payload.ResponseMode = ResponseModeStreaming
 
-	// 设置响应模式为流式模式
-	req.ResponseMode = ResponseModeStreaming
-
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(&payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(c.Ctx, "POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	r := &apiRequest{
+		name:        "CreateStreamingChat",
+		opts:        opts,
+		method:      http.MethodPost,
+		path:        EndpointChat,
+		family:      FamilyChat,
+		body:        body,
+		contentType: "application/json",
+		fileIDs:     uploadFileIDs(req.Files),
+		header: http.Header{
+			"Accept":            {"text/event-stream"},
+			"Connection":        {"keep-alive"},
+			"Cache-Control":     {"no-cache"},
+			"Transfer-Encoding": {"chunked"},
+			"Accept-Encoding":   {"identity"},
+		},
+		conversationID: req.ConversationId,
+		user:           req.User,
+		stream:         true,
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "text/event-stream")
-	httpReq.Header.Set("Connection", "keep-alive")
-	httpReq.Header.Set("Cache-Control", "no-cache")
-	httpReq.Header.Set("Transfer-Encoding", "chunked")
-	httpReq.Header.Set("Accept-Encoding", "identity")
-
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.send(r)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	defer func() {
+		r.op.EmitStreamEnd(err)
+	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("request failed with status %d: %w", resp.StatusCode, DecodeError(resp))
 	}
 
 	reader := bufio.NewReader(resp.Body)
@@ -122,6 +132,8 @@ func (c *Client) CreateStreamingChat(req *ChatRequest, handler StreamHandler) er
 			}
 			continue
 		}
+		r.op.EmitStreamEvent(StreamEvent{Event: baseResp.Event, Data: json.RawMessage(data)})
+		c.pin(r, baseResp.ConversationId, baseResp.TaskID, baseResp.MessageID)
 
 		// 根据事件类型处理不同的响应
 		switch baseResp.Event {
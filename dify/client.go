@@ -7,15 +7,29 @@ import (
 )
 
 // Client represents a client for the Dify API
+//
+// 客户端创建后配置不可修改，可以被多个 goroutine 并发使用。
+// 请求方法不会修改传入的请求结构体，同一个请求模板可以在多个 goroutine 中复用。
 type Client struct {
-	// BaseURL is the base URL for API requests
-	BaseURL string
-	// APIKey is the API key for authentication
-	APIKey string
-	// HTTPClient is the HTTP client used for making requests
-	HTTPClient *http.Client
-	// Ctx is the context for API requests
-	Ctx context.Context
+	// baseURL is the base URL for API requests
+	baseURL string
+	// apiKey is the API key for authentication
+	apiKey string
+	// httpClient is the HTTP client used for making requests
+	httpClient *http.Client
+	// ctx is the context for API requests
+	ctx context.Context
+
+	// pool 多 API Key / 多基础 URL 节点池
+	pool *Pool
+	// limiter 限流器
+	limiter *Limiter
+	// breaker 熔断器
+	breaker *CircuitBreaker
+	// middlewares 请求中间件，先添加的位于外层
+	middlewares []Middleware
+	// threadLocks 按外部线程键串行化会话创建，WithContext 派生的客户端共享
+	threadLocks *keyedMutex
 }
 
 // ClientOption 定义客户端选项接口
@@ -33,14 +47,14 @@ func (f clientOptionFunc) apply(c *Client) {
 // WithBaseURL 设置自定义的基础 URL
 func WithBaseURL(baseURL string) ClientOption {
 	return clientOptionFunc(func(c *Client) {
-		c.BaseURL = baseURL
+		c.baseURL = baseURL
 	})
 }
 
 // WithHTTPClient 设置自定义的 HTTP 客户端
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return clientOptionFunc(func(c *Client) {
-		c.HTTPClient = httpClient
+		c.httpClient = httpClient
 	})
 }
 
@@ -56,9 +70,10 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 	}
 
 	c := &Client{
-		BaseURL:    DefaultBaseURL,
-		APIKey:     apiKey,
-		HTTPClient: httpClient,
+		baseURL:     DefaultBaseURL,
+		apiKey:      apiKey,
+		httpClient:  httpClient,
+		threadLocks: &keyedMutex{},
 	}
 
 	// 应用选项
@@ -68,3 +83,36 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 
 	return c
 }
+
+// BaseURL 返回 API 基础 URL
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// APIKey 返回 API Key
+func (c *Client) APIKey() string {
+	return c.apiKey
+}
+
+// HTTPClient 返回发送请求使用的 HTTP 客户端
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// WithContext 返回使用 ctx 发送请求的客户端副本，原客户端不受影响
+//
+// 副本与原客户端共享节点池、限流器、熔断器和 HTTP 客户端。
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	clone.ctx = ctx
+	clone.middlewares = append([]Middleware(nil), c.middlewares...)
+	return &clone
+}
+
+// context 返回请求使用的上下文，未设置时使用 context.Background()
+func (c *Client) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
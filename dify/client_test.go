@@ -0,0 +1,106 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试同一客户端和同一请求模板被多个 goroutine 并发使用，需要配合 -race 运行
+func TestClientConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.ResponseMode == ResponseModeBlocking {
+			json.NewEncoder(w).Encode(ChatResponse{ConversationId: "conv-1", MessageID: "msg-1", Answer: req.Query})
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: {\"event\":\"message\",\"conversation_id\":\"conv-1\",\"answer\":\"%d\"}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"event\":\"message_end\",\"conversation_id\":\"conv-1\"}\n\n")
+	}))
+	defer srv.Close()
+
+	pool := NewPool([]PoolMember{
+		{APIKey: "key-a", BaseURL: srv.URL},
+		{APIKey: "key-b", BaseURL: srv.URL},
+	})
+	client := NewClient("", WithPool(pool),
+		WithLimiter(NewLimiter(LimiterConfig{MaxConcurrentStreams: 4})),
+		WithCircuitBreaker(NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 100})),
+		WithMetrics(&recordingMetrics{}),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+
+	template := &ChatRequest{Query: "shared", User: UserExample, Inputs: map[string]any{"k": "v"}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp, err := client.CreateChat(template)
+			if err == nil && resp.Answer != "shared" {
+				err = fmt.Errorf("answer = %q", resp.Answer)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			handler := &recordingHandler{}
+			if err := client.WithContext(context.Background()).CreateStreamingChat(template, handler); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if template.ResponseMode != "" {
+		t.Fatalf("request template modified: ResponseMode = %q", template.ResponseMode)
+	}
+}
+
+// 测试 WithContext 返回的副本不影响原客户端
+func TestClientWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		json.NewEncoder(w).Encode(AppInfo{Name: "bot"})
+	}))
+	defer srv.Close()
+
+	client := NewClient("key", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	derived := client.WithContext(ctx)
+	if derived.BaseURL() != srv.URL || derived.APIKey() != "key" || derived.HTTPClient() != client.HTTPClient() {
+		t.Fatal("derived client should share configuration")
+	}
+	if _, err := derived.GetAppInfo(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want deadline exceeded", err)
+	}
+	if _, err := client.GetAppInfo(); err != nil {
+		t.Fatalf("original client affected: %v", err)
+	}
+}
@@ -2,7 +2,6 @@ package dify
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,27 +10,30 @@ import (
 )
 
 // CreateCompletion 发送阻塞模式的完成请求
-func (c *Client) CreateCompletion(req *CompletionRequest) (*CompletionResponse, error) {
+func (c *Client) CreateCompletion(req *CompletionRequest, opts ...RequestOption) (*CompletionResponse, error) {
 
-	endpoint := fmt.Sprintf("%s%s", c.BaseURL, EndpointCompletion)
+	// 设置响应模式为阻塞模式，复制请求避免修改调用方的结构体
+	payload := *req
+	payload.ResponseMode = ResponseModeBlocking
 
-	// 设置响应模式为阻塞模式
-	req.ResponseMode = ResponseModeBlocking
-
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(&payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	r := &apiRequest{
+		name:           "CreateCompletion",
+		opts:           opts,
+		method:         http.MethodPost,
+		path:           EndpointCompletion,
+		family:         FamilyCompletion,
+		body:           body,
+		contentType:    "application/json",
+		fileIDs:        uploadFileIDs(req.Files),
+		conversationID: req.ConversationId,
+		user:           req.User,
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.send(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -46,6 +48,7 @@ func (c *Client) CreateCompletion(req *CompletionRequest) (*CompletionResponse,
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	c.pin(r, result.MessageID)
 
 	return &result, nil
 }
@@ -61,32 +64,39 @@ type StreamHandler interface {
 }
 
 // CreateStreamingCompletion 发送流式模式的完成请求
-func (c *Client) CreateStreamingCompletion(req *CompletionRequest, handler StreamHandler) error {
+func (c *Client) CreateStreamingCompletion(req *CompletionRequest, handler StreamHandler, opts ...RequestOption) (err error) {
 
-	endpoint := fmt.Sprintf("%s%s", c.BaseURL, EndpointCompletion)
+	// 设置响应模式为流式模式，复制请求避免修改调用方的结构体
+	payload := *req
+	payload.ResponseMode = ResponseModeStreaming
 
-	// 设置响应模式为流式模式
-	req.ResponseMode = ResponseModeStreaming
-
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(&payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	r := &apiRequest{
+		name:           "CreateStreamingCompletion",
+		opts:           opts,
+		method:         http.MethodPost,
+		path:           EndpointCompletion,
+		family:         FamilyCompletion,
+		body:           body,
+		contentType:    "application/json",
+		fileIDs:        uploadFileIDs(req.Files),
+		header:         http.Header{"Accept": {"text/event-stream"}},
+		conversationID: req.ConversationId,
+		user:           req.User,
+		stream:         true,
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "text/event-stream")
-
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.send(r)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	defer func() {
+		r.op.EmitStreamEnd(err)
+	}()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -129,6 +139,8 @@ func (c *Client) CreateStreamingCompletion(req *CompletionRequest, handler Strea
 			}
 			continue
 		}
+		r.op.EmitStreamEvent(StreamEvent{Event: baseResp.Event, Data: json.RawMessage(data)})
+		c.pin(r, baseResp.ConversationId, baseResp.TaskID, baseResp.MessageID)
 
 		// 根据事件类型处理不同的响应
 		switch baseResp.Event {
@@ -0,0 +1,230 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ConversationStore 会话存储接口，将外部线程ID（如聊天平台的 thread ID）映射到 Dify 的 conversation_id
+type ConversationStore interface {
+	// Get 获取外部键对应的会话ID，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (conversationID string, ok bool, err error)
+	// Set 保存外部键与会话ID的映射，并刷新过期时间
+	Set(ctx context.Context, key, conversationID string) error
+	// Delete 删除外部键的映射
+	Delete(ctx context.Context, key string) error
+}
+
+// conversationEntry 会话映射条目
+type conversationEntry struct {
+	ConversationID string    `json:"conversation_id"`
+	ExpiresAt      time.Time `json:"expires_at,omitempty"`
+}
+
+func (e conversationEntry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// MemoryConversationStore 基于内存的会话存储，进程重启后映射会丢失
+type MemoryConversationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]conversationEntry
+}
+
+// NewMemoryConversationStore 创建内存会话存储，ttl 为 0 表示永不过期
+func NewMemoryConversationStore(ttl time.Duration) *MemoryConversationStore {
+	return &MemoryConversationStore{
+		ttl:     ttl,
+		entries: make(map[string]conversationEntry),
+	}
+}
+
+// Get 获取外部键对应的会话ID
+func (s *MemoryConversationStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	if entry.expired(time.Now()) {
+		delete(s.entries, key)
+		return "", false, nil
+	}
+	return entry.ConversationID, true, nil
+}
+
+// Set 保存外部键与会话ID的映射
+func (s *MemoryConversationStore) Set(ctx context.Context, key, conversationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = s.newEntry(conversationID)
+	return nil
+}
+
+// Delete 删除外部键的映射
+func (s *MemoryConversationStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// Purge 清理所有已过期的映射，返回清理的条目数
+func (s *MemoryConversationStore) Purge() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.purgeLocked(time.Now())
+}
+
+// Len 返回当前存储的映射数量（包含尚未清理的过期条目）
+func (s *MemoryConversationStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+func (s *MemoryConversationStore) newEntry(conversationID string) conversationEntry {
+	entry := conversationEntry{ConversationID: conversationID}
+	if s.ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(s.ttl)
+	}
+	return entry
+}
+
+func (s *MemoryConversationStore) purgeLocked(now time.Time) int {
+	n := 0
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n
+}
+
+// FileConversationStore 基于本地 JSON 文件的会话存储，映射在进程重启后仍然有效
+type FileConversationStore struct {
+	MemoryConversationStore
+	path string
+}
+
+// NewFileConversationStore 创建文件会话存储，文件已存在时加载其中未过期的映射
+func NewFileConversationStore(path string, ttl time.Duration) (*FileConversationStore, error) {
+	s := &FileConversationStore{
+		MemoryConversationStore: MemoryConversationStore{
+			ttl:     ttl,
+			entries: make(map[string]conversationEntry),
+		},
+		path: path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read conversation store: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			return nil, fmt.Errorf("failed to decode conversation store: %w", err)
+		}
+	}
+	s.purgeLocked(time.Now())
+
+	return s, nil
+}
+
+// Set 保存外部键与会话ID的映射并写入文件
+func (s *FileConversationStore) Set(ctx context.Context, key, conversationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = s.newEntry(conversationID)
+	return s.saveLocked()
+}
+
+// Delete 删除外部键的映射并写入文件
+func (s *FileConversationStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		return nil
+	}
+	delete(s.entries, key)
+	return s.saveLocked()
+}
+
+// Get 获取外部键对应的会话ID，条目已过期时删除并写入文件
+func (s *FileConversationStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	if entry.expired(time.Now()) {
+		delete(s.entries, key)
+		return "", false, s.saveLocked()
+	}
+	return entry.ConversationID, true, nil
+}
+
+// Purge 清理所有已过期的映射并写入文件，返回清理的条目数；写入失败会被忽略，需要错误时使用 PurgeAndSave
+func (s *FileConversationStore) Purge() int {
+	n, _ := s.PurgeAndSave()
+	return n
+}
+
+// PurgeAndSave 清理所有已过期的映射并写入文件，返回清理的条目数
+func (s *FileConversationStore) PurgeAndSave() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.purgeLocked(time.Now())
+	if n == 0 {
+		return 0, nil
+	}
+	return n, s.saveLocked()
+}
+
+// saveLocked 先写入临时文件再重命名，避免进程中断时留下损坏的文件
+func (s *FileConversationStore) saveLocked() error {
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write conversation store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write conversation store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace conversation store: %w", err)
+	}
+	return nil
+}
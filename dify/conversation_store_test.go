@@ -0,0 +1,166 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试同一线程的并发消息只会创建一个会话
+func TestCreateChatForThreadConcurrent(t *testing.T) {
+	var created int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		conversationID := req.ConversationId
+		if conversationID == "" {
+			n := atomic.AddInt32(&created, 1)
+			conversationID = fmt.Sprintf("conv-%d", n)
+			time.Sleep(20 * time.Millisecond)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{ConversationId: conversationID, Answer: "ok"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	store := NewMemoryConversationStore(time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.CreateChatForThread(store, "thread-1", &ChatRequest{Query: "hi", User: UserExample})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if resp.ConversationId != "conv-1" {
+				t.Errorf("conversation = %q, want conv-1", resp.ConversationId)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Fatalf("created %d conversations, want 1", created)
+	}
+}
+
+// 测试保存的会话已不存在时清除映射并在新会话中重新发送
+func TestCreateChatForThreadStaleConversation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.ConversationId == "stale" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":"not_found","message":"Conversation Not Exists.","status":404}`)
+			return
+		}
+		if req.ResponseMode == ResponseModeBlocking {
+			json.NewEncoder(w).Encode(ChatResponse{ConversationId: "conv-new", Answer: "ok"})
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"event\":\"message\",\"conversation_id\":\"conv-stream\",\"answer\":\"ok\"}\n\n")
+		fmt.Fprint(w, "data: {\"event\":\"message_end\",\"conversation_id\":\"conv-stream\"}\n\n")
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	store := NewMemoryConversationStore(time.Minute)
+
+	if err := store.Set(ctx, "thread-1", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.CreateChatForThread(store, "thread-1", &ChatRequest{Query: "hi", User: UserExample})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ConversationId != "conv-new" {
+		t.Fatalf("conversation = %q, want conv-new", resp.ConversationId)
+	}
+	if id, _, _ := store.Get(ctx, "thread-1"); id != "conv-new" {
+		t.Fatalf("stored conversation = %q, want conv-new", id)
+	}
+
+	if err := store.Set(ctx, "thread-2", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateStreamingChatForThread(store, "thread-2", &ChatRequest{Query: "hi", User: UserExample}, &recordingHandler{}); err != nil {
+		t.Fatal(err)
+	}
+	if id, _, _ := store.Get(ctx, "thread-2"); id != "conv-stream" {
+		t.Fatalf("stored conversation = %q, want conv-stream", id)
+	}
+}
+
+// 测试文件存储在重新打开后仍保留映射，且过期条目不会被加载
+func TestFileConversationStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "conversations.json")
+
+	store, err := NewFileConversationStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "thread-1", "conv-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileConversationStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, ok, err := reopened.Get(ctx, "thread-1")
+	if err != nil || !ok || id != "conv-1" {
+		t.Fatalf("Get = %q, %v, %v; want conv-1", id, ok, err)
+	}
+
+	expiring, err := NewFileConversationStore(path, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expiring.Set(ctx, "thread-2", "conv-2"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := expiring.Get(ctx, "thread-2"); ok {
+		t.Fatal("expected thread-2 to be expired")
+	}
+
+	// Get 删除的过期条目需要写回文件
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "thread-2") {
+		t.Fatalf("expired entry still in file: %s", raw)
+	}
+
+	if err := expiring.Set(ctx, "thread-3", "conv-3"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	var purger interface{ Purge() int } = expiring
+	if n := purger.Purge(); n != 1 {
+		t.Fatalf("Purge = %d, want 1", n)
+	}
+	if raw, _ := os.ReadFile(path); strings.Contains(string(raw), "thread-3") {
+		t.Fatalf("purged entry still in file: %s", raw)
+	}
+}
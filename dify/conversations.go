@@ -1,14 +1,13 @@
 package dify
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
 // ConversationsDel 删除会话
-func (c *Client) ConversationsDel(conversationId string, user string) error {
+func (c *Client) ConversationsDel(conversationId string, user string, opts ...RequestOption) error {
 	data, err := json.Marshal(map[string]string{
 		"user": user,
 	})
@@ -16,15 +15,17 @@ func (c *Client) ConversationsDel(conversationId string, user string) error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s/%s", c.BaseURL, EndpointConversations, conversationId), bytes.NewBuffer(data))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(&apiRequest{
+		name:           "ConversationsDel",
+		opts:           opts,
+		method:         http.MethodPost,
+		path:           fmt.Sprintf("%s/%s", EndpointConversations, conversationId),
+		family:         FamilyChat,
+		body:           data,
+		contentType:    "application/json",
+		conversationID: conversationId,
+		user:           user,
+	})
 	if err != nil {
 		return err
 	}
@@ -1,7 +1,12 @@
 package dify
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
 )
 
 // DifyError represents an error returned by the Dify API
@@ -64,3 +69,20 @@ func NewDifyError(status int, code, message string) *DifyError {
 		Message: message,
 	}
 }
+
+// DecodeError 读取并关闭非 2xx 响应的响应体，转换为 *DifyError
+//
+// 响应体不是 Dify 错误格式时，Code 为 http_<状态码>，Message 为响应体内容。
+func DecodeError(resp *http.Response) *DifyError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var difyErr DifyError
+	if json.Unmarshal(body, &difyErr) == nil && difyErr.Code != "" {
+		if difyErr.Status == 0 {
+			difyErr.Status = resp.StatusCode
+		}
+		return &difyErr
+	}
+	return NewDifyError(resp.StatusCode, "http_"+strconv.Itoa(resp.StatusCode), strings.TrimSpace(string(body)))
+}
@@ -1,28 +1,29 @@
 package dify
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
 // SendFeedback 消息反馈（点赞）
-func (c *Client) SendFeedback(messageID string, feedback *FeedbackRequest) error {
+func (c *Client) SendFeedback(messageID string, feedback *FeedbackRequest, opts ...RequestOption) error {
 	data, err := json.Marshal(feedback)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s/%s%s", c.BaseURL, EndpointMessages, messageID, EndpointFeedbacks), bytes.NewBuffer(data))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(&apiRequest{
+		name:        "SendFeedback",
+		opts:        opts,
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("%s/%s%s", EndpointMessages, messageID, EndpointFeedbacks),
+		family:      FamilyChat,
+		body:        data,
+		contentType: "application/json",
+		messageID:   messageID,
+		user:        feedback.User,
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,56 @@
+package dify
+
+import "context"
+
+// Chatter 对话型应用
+type Chatter interface {
+	CreateChat(req *ChatRequest, opts ...RequestOption) (*ChatResponse, error)
+	CreateStreamingChat(req *ChatRequest, handler StreamHandler, opts ...RequestOption) error
+}
+
+// Completer 文本生成型应用
+type Completer interface {
+	CreateCompletion(req *CompletionRequest, opts ...RequestOption) (*CompletionResponse, error)
+	CreateStreamingCompletion(req *CompletionRequest, handler StreamHandler, opts ...RequestOption) error
+	StopResponse(taskID string, user string, opts ...RequestOption) error
+}
+
+// WorkflowRunner 工作流应用
+type WorkflowRunner interface {
+	WorkflowRun(request WorkflowRequest, opts ...RequestOption) (*WorkflowResponse, error)
+	WorkflowRunStreaming(request WorkflowRequest, handler StreamHandler, opts ...RequestOption) error
+}
+
+// FileUploader 文件上传
+type FileUploader interface {
+	UploadFile(filePath string, user string, opts ...RequestOption) (*FileUploadResponse, error)
+}
+
+// ConversationManager 会话和消息管理
+type ConversationManager interface {
+	ConversationsDel(conversationId string, user string, opts ...RequestOption) error
+	SendFeedback(messageID string, feedback *FeedbackRequest, opts ...RequestOption) error
+}
+
+// RawCaller 调用 SDK 尚未封装的端点
+type RawCaller interface {
+	Do(ctx context.Context, method, path string, body, out interface{}, opts ...RequestOption) error
+	DoStream(ctx context.Context, method, path string, body interface{}, opts ...RequestOption) (*RawStream, error)
+}
+
+// API 应用 API 的全部能力，由 *Client 实现
+//
+// 业务代码应尽量依赖用到的小接口，便于在测试中替换为 difymock 中的实现。
+type API interface {
+	Chatter
+	Completer
+	WorkflowRunner
+	FileUploader
+	ConversationManager
+	RawCaller
+	GetAppInfo(opts ...RequestOption) (*AppInfo, error)
+	GetAppParameters(opts ...RequestOption) (*AppParameters, error)
+	TextToSpeech(request *TTSRequest, opts ...RequestOption) ([]byte, error)
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,358 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrLimiterQueueFull 限流队列已满，请求被直接拒绝
+var ErrLimiterQueueFull = errors.New("dify limiter queue is full")
+
+// LimiterConfig 限流配置，各项为 0 表示不限制
+type LimiterConfig struct {
+	AppRate              float64 // 应用级每秒请求数
+	AppBurst             int     // 应用级突发容量，默认为 AppRate 向上取整
+	UserRate             float64 // 每个 User 每秒请求数
+	UserBurst            int     // 每个 User 的突发容量，默认为 UserRate 向上取整
+	MaxConcurrentStreams int     // 最大并发流式请求数
+	MaxQueue             int     // 排队请求总数上限
+	MaxQueuePerUser      int     // 单个 User 排队请求数上限
+}
+
+// LimiterStats 限流器运行状态
+type LimiterStats struct {
+	Queued        int            // 排队中的请求总数
+	QueuedByUser  map[string]int // 每个 User 排队中的请求数
+	ActiveStreams int            // 进行中的流式请求数
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	b := float64(burst)
+	if b <= 0 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait 返回获得一个令牌还需等待的时间
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// limiterWaiter 排队中的请求
+type limiterWaiter struct {
+	user    string
+	stream  bool
+	ready   chan struct{}
+	granted bool
+}
+
+// Limiter 客户端限流器
+//
+// 支持应用级和 User 级令牌桶限流以及流式请求并发上限。排队的请求按 User 轮转放行，
+// 单个 User 的突发请求不会饿死其他 User。
+type Limiter struct {
+	cfg LimiterConfig
+
+	mu      sync.Mutex
+	app     *tokenBucket
+	users   map[string]*tokenBucket
+	queues  map[string][]*limiterWaiter
+	order   []string
+	next    int
+	queued  int
+	streams int
+	timer   *time.Timer
+}
+
+// NewLimiter 创建限流器
+func NewLimiter(cfg LimiterConfig) *Limiter {
+	l := &Limiter{
+		cfg:    cfg,
+		users:  make(map[string]*tokenBucket),
+		queues: make(map[string][]*limiterWaiter),
+	}
+	if cfg.AppRate > 0 {
+		l.app = newTokenBucket(cfg.AppRate, cfg.AppBurst, time.Now())
+	}
+	return l
+}
+
+// WithLimiter 设置客户端使用的限流器
+func WithLimiter(limiter *Limiter) ClientOption {
+	return clientOptionFunc(func(c *Client) {
+		c.limiter = limiter
+	})
+}
+
+// Acquire 等待放行，stream 表示请求是否占用流式并发名额
+//
+// 返回的 release 需要在请求结束后调用以归还流式并发名额，ctx 结束时放弃排队并返回 ctx.Err()。
+func (l *Limiter) Acquire(ctx context.Context, user string, stream bool) (release func(), err error) {
+	l.mu.Lock()
+	if l.cfg.MaxQueue > 0 && l.queued >= l.cfg.MaxQueue {
+		l.mu.Unlock()
+		return nil, ErrLimiterQueueFull
+	}
+	if l.cfg.MaxQueuePerUser > 0 && len(l.queues[user]) >= l.cfg.MaxQueuePerUser {
+		l.mu.Unlock()
+		return nil, ErrLimiterQueueFull
+	}
+
+	w := &limiterWaiter{user: user, stream: stream, ready: make(chan struct{})}
+	if len(l.queues[user]) == 0 {
+		l.order = append(l.order, user)
+	}
+	l.queues[user] = append(l.queues[user], w)
+	l.queued++
+	l.dispatchLocked()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return l.releaser(w), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if w.granted {
+			// 放行与取消同时发生，归还名额
+			if w.stream {
+				l.streams--
+				l.dispatchLocked()
+			}
+		} else {
+			l.removeLocked(w)
+			l.dispatchLocked()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Stats 返回限流器当前状态
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byUser := make(map[string]int, len(l.queues))
+	for user, queue := range l.queues {
+		byUser[user] = len(queue)
+	}
+	return LimiterStats{
+		Queued:        l.queued,
+		QueuedByUser:  byUser,
+		ActiveStreams: l.streams,
+	}
+}
+
+// QueueDepth 返回排队中的请求总数
+func (l *Limiter) QueueDepth() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.queued
+}
+
+func (l *Limiter) releaser(w *limiterWaiter) func() {
+	if !w.stream {
+		return func() {}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.streams--
+			l.dispatchLocked()
+		})
+	}
+}
+
+// dispatchLocked 按 User 轮转放行满足条件的队首请求，并在需要等待令牌时安排下一次调度
+func (l *Limiter) dispatchLocked() {
+	now := time.Now()
+	if l.app != nil {
+		l.app.refill(now)
+	}
+
+	for l.grantNextLocked(now) {
+	}
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+
+	var wait time.Duration
+	for _, user := range l.order {
+		if d := l.waitLocked(l.queues[user][0], now); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		l.timer = time.AfterFunc(wait, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.dispatchLocked()
+		})
+	}
+
+	// 清理令牌已回满的空闲 User，避免长时间运行时无限增长
+	if len(l.users) > 1024 {
+		for user, b := range l.users {
+			b.refill(now)
+			if b.tokens >= b.burst && len(l.queues[user]) == 0 {
+				delete(l.users, user)
+			}
+		}
+	}
+}
+
+// grantNextLocked 从轮转游标开始找到第一个可以放行的 User，放行其队首请求
+func (l *Limiter) grantNextLocked(now time.Time) bool {
+	n := len(l.order)
+	for i := 0; i < n; i++ {
+		idx := (l.next + i) % n
+		user := l.order[idx]
+		w := l.queues[user][0]
+
+		if w.stream && l.cfg.MaxConcurrentStreams > 0 && l.streams >= l.cfg.MaxConcurrentStreams {
+			continue
+		}
+		if l.app != nil && l.app.tokens < 1 {
+			return false
+		}
+		ub := l.userBucketLocked(user, now)
+		if ub != nil && ub.tokens < 1 {
+			continue
+		}
+
+		if l.app != nil {
+			l.app.tokens--
+		}
+		if ub != nil {
+			ub.tokens--
+		}
+		if w.stream {
+			l.streams++
+		}
+		w.granted = true
+		close(w.ready)
+
+		l.queues[user] = l.queues[user][1:]
+		l.queued--
+		if len(l.queues[user]) == 0 {
+			delete(l.queues, user)
+			l.order = append(l.order[:idx], l.order[idx+1:]...)
+			l.next = idx
+		} else {
+			l.next = idx + 1
+		}
+		if len(l.order) > 0 {
+			l.next %= len(l.order)
+		} else {
+			l.next = 0
+		}
+		return true
+	}
+	return false
+}
+
+// waitLocked 返回队首请求因令牌不足需要等待的时间，受流式并发限制时返回 0 由 release 触发调度
+func (l *Limiter) waitLocked(w *limiterWaiter, now time.Time) time.Duration {
+	if w.stream && l.cfg.MaxConcurrentStreams > 0 && l.streams >= l.cfg.MaxConcurrentStreams {
+		return 0
+	}
+	var wait time.Duration
+	if l.app != nil {
+		wait = l.app.wait()
+	}
+	if ub := l.userBucketLocked(w.user, now); ub != nil {
+		if d := ub.wait(); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 && wait < time.Millisecond {
+		wait = time.Millisecond
+	}
+	return wait
+}
+
+func (l *Limiter) userBucketLocked(user string, now time.Time) *tokenBucket {
+	if l.cfg.UserRate <= 0 {
+		return nil
+	}
+	b, ok := l.users[user]
+	if !ok {
+		b = newTokenBucket(l.cfg.UserRate, l.cfg.UserBurst, now)
+		l.users[user] = b
+	}
+	b.refill(now)
+	return b
+}
+
+// removeLocked 将取消排队的请求移出队列
+func (l *Limiter) removeLocked(w *limiterWaiter) {
+	queue := l.queues[w.user]
+	for i, q := range queue {
+		if q != w {
+			continue
+		}
+		l.queues[w.user] = append(queue[:i:i], queue[i+1:]...)
+		l.queued--
+		break
+	}
+	if len(l.queues[w.user]) > 0 {
+		return
+	}
+
+	delete(l.queues, w.user)
+	for i, user := range l.order {
+		if user != w.user {
+			continue
+		}
+		l.order = append(l.order[:i], l.order[i+1:]...)
+		if l.next > i {
+			l.next--
+		}
+		break
+	}
+	if len(l.order) > 0 {
+		l.next %= len(l.order)
+	} else {
+		l.next = 0
+	}
+}
+
+// releaseBody 在响应体关闭时归还限流名额
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
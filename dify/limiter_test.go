@@ -0,0 +1,74 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 测试流式并发上限、排队取消以及按 User 轮转放行
+func TestLimiterFairStreams(t *testing.T) {
+	limiter := NewLimiter(LimiterConfig{MaxConcurrentStreams: 1})
+	ctx := context.Background()
+
+	hold, err := limiter.Acquire(ctx, "holder", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(timeout, "late", true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire error = %v, want deadline exceeded", err)
+	}
+	if depth := limiter.QueueDepth(); depth != 0 {
+		t.Fatalf("queue depth = %d after cancel, want 0", depth)
+	}
+
+	granted := make(chan string, 4)
+	start := func(user string) {
+		go func() {
+			release, err := limiter.Acquire(ctx, user, true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			granted <- user
+			time.Sleep(5 * time.Millisecond)
+			release()
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		start("noisy")
+		waitForQueue(t, limiter, i+1)
+	}
+	start("quiet")
+	waitForQueue(t, limiter, 4)
+
+	hold()
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		select {
+		case user := <-granted:
+			order = append(order, user)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, granted so far: %v", order)
+		}
+	}
+	if order[1] != "quiet" {
+		t.Fatalf("grant order = %v, want quiet second", order)
+	}
+}
+
+func waitForQueue(t *testing.T, limiter *Limiter, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for limiter.QueueDepth() != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth = %d, want %d", limiter.QueueDepth(), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
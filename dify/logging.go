@@ -0,0 +1,352 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted 替换 Authorization 等敏感请求头
+const redacted = "[REDACTED]"
+
+// maxLoggedBody 记录请求体和响应体的最大字节数
+const maxLoggedBody = 16 << 10
+
+// DefaultMaskedFields 默认脱敏的 JSON 字段
+var DefaultMaskedFields = []string{"inputs", "query", "answer", "text", "user"}
+
+// sensitiveHeaders 记录日志时始终隐藏的请求头
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+	"X-Api-Key":     true,
+}
+
+// logConfig 日志配置
+type logConfig struct {
+	level       slog.Level
+	errorLevel  slog.Level
+	streamLevel slog.Level
+	bodies      bool
+	masked      map[string]bool
+	sampleEvery int
+}
+
+// LogOption 定义日志选项接口
+type LogOption interface {
+	apply(*logConfig)
+}
+
+// logOptionFunc 是一个适配器，允许使用普通函数作为 LogOption
+type logOptionFunc func(*logConfig)
+
+func (f logOptionFunc) apply(c *logConfig) {
+	f(c)
+}
+
+// WithLogLevel 设置请求和响应日志的级别，默认为 Debug
+func WithLogLevel(level slog.Level) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.level = level
+	})
+}
+
+// WithLogErrorLevel 设置失败请求日志的级别，默认为 Warn
+func WithLogErrorLevel(level slog.Level) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.errorLevel = level
+	})
+}
+
+// WithLogStreamLevel 设置流式事件日志的级别，默认为 Debug
+func WithLogStreamLevel(level slog.Level) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.streamLevel = level
+	})
+}
+
+// WithLogBodies 是否记录请求头、请求体、响应体和流式事件数据，默认不记录
+func WithLogBodies(enabled bool) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.bodies = enabled
+	})
+}
+
+// WithLogMaskedFields 设置需要脱敏的 JSON 字段，替换 DefaultMaskedFields，不传参数时不脱敏
+func WithLogMaskedFields(fields ...string) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.masked = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			c.masked[field] = true
+		}
+	})
+}
+
+// WithLogStreamSampling 对 message、agent_message、text_chunk、tts_message 等高频流式事件每 n 个只记录 1 个，
+// 其他事件始终记录，默认全部记录
+func WithLogStreamSampling(n int) LogOption {
+	return logOptionFunc(func(c *logConfig) {
+		c.sampleEvery = n
+	})
+}
+
+// WithLogger 设置结构化日志，相当于添加 LoggingMiddleware(logger, opts...)
+func WithLogger(logger *slog.Logger, opts ...LogOption) ClientOption {
+	return WithMiddleware(LoggingMiddleware(logger, opts...))
+}
+
+// LoggingMiddleware 创建记录请求、响应和流式事件的中间件，knowledge.Client 也可以使用
+//
+// Authorization 等请求头始终隐藏，请求体和响应体中的 inputs、query、answer 等字段按配置脱敏。
+func LoggingMiddleware(logger *slog.Logger, opts ...LogOption) Middleware {
+	cfg := &logConfig{
+		level:       slog.LevelDebug,
+		errorLevel:  slog.LevelWarn,
+		streamLevel: slog.LevelDebug,
+		sampleEvery: 1,
+	}
+	WithLogMaskedFields(DefaultMaskedFields...).apply(cfg)
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+
+	return func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			attrs := operationLogAttrs(op)
+			if op.User != "" && !cfg.masked["user"] {
+				attrs = append(attrs, slog.String("user", op.User))
+				attrs = attrs[:len(attrs):len(attrs)]
+			}
+			start := time.Now()
+
+			reqAttrs := append(attrs, slog.String("method", req.Method), slog.String("path", req.URL.Path))
+			if cfg.bodies {
+				reqAttrs = append(reqAttrs, slog.Any("header", redactHeader(req.Header)))
+				if body := cfg.requestBody(req); body != "" {
+					reqAttrs = append(reqAttrs, slog.String("body", body))
+				}
+			}
+			logger.LogAttrs(ctx, cfg.level, "dify request", reqAttrs...)
+
+			resp, err := next(op, req)
+			if err != nil {
+				logger.LogAttrs(ctx, cfg.errorLevel, "dify request failed",
+					append(attrs, slog.Duration("duration", time.Since(start)), slog.String("error", err.Error()))...)
+				return nil, err
+			}
+
+			respAttrs := append(attrs, slog.Int("status", resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				body := peekBody(resp, maxLoggedBody)
+				logger.LogAttrs(ctx, cfg.errorLevel, "dify response",
+					append(respAttrs, slog.Duration("duration", time.Since(start)), slog.String("body", cfg.mask(body)))...)
+				return resp, nil
+			}
+
+			if op.Stream {
+				s := &streamLog{logger: logger, cfg: cfg, ctx: ctx, attrs: attrs, start: start}
+				logger.LogAttrs(ctx, cfg.level, "dify stream started",
+					append(respAttrs, slog.Duration("duration", time.Since(start)))...)
+				op.OnStreamEvent(s.event)
+				op.OnStreamEnd(s.end)
+				resp.Body = &closeHookBody{ReadCloser: resp.Body, close: func() { s.end(nil) }}
+				return resp, nil
+			}
+
+			var captured *bytes.Buffer
+			if cfg.bodies {
+				captured = &bytes.Buffer{}
+				resp.Body = &teeBody{ReadCloser: resp.Body, buf: captured, limit: maxLoggedBody}
+			}
+			resp.Body = &closeHookBody{ReadCloser: resp.Body, close: func() {
+				closeAttrs := append(respAttrs, slog.Duration("duration", time.Since(start)))
+				if captured != nil {
+					closeAttrs = append(closeAttrs, slog.String("body", cfg.mask(captured.Bytes())))
+				}
+				logger.LogAttrs(ctx, cfg.level, "dify response", closeAttrs...)
+			}}
+			return resp, nil
+		}
+	}
+}
+
+// operationLogAttrs 根据 Operation 生成日志属性
+func operationLogAttrs(op *Operation) []slog.Attr {
+	attrs := []slog.Attr{slog.String("op", op.Name), slog.String("family", string(op.Family))}
+	optional := []struct {
+		key   string
+		value string
+	}{
+		{"conversation_id", op.ConversationID},
+		{"task_id", op.TaskID},
+		{"message_id", op.MessageID},
+		{"dataset_id", op.DatasetID},
+		{"document_id", op.DocumentID},
+	}
+	for _, o := range optional {
+		if o.value != "" {
+			attrs = append(attrs, slog.String(o.key, o.value))
+		}
+	}
+	return attrs[:len(attrs):len(attrs)]
+}
+
+// redactHeader 复制请求头并隐藏敏感字段
+func redactHeader(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for key, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			out[key] = redacted
+			continue
+		}
+		out[key] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// requestBody 读取请求体副本，非 JSON 请求体只记录长度
+func (c *logConfig) requestBody(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil {
+		return ""
+	}
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return fmt.Sprintf("[%d bytes]", req.ContentLength)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody))
+	return c.mask(data)
+}
+
+// mask 对 JSON 中需要脱敏的字段进行替换，无法解析时原样返回
+func (c *logConfig) mask(data []byte) string {
+	if len(c.masked) == 0 {
+		return string(data)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(data)
+	}
+	out, err := json.Marshal(c.maskValue(v))
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+func (c *logConfig) maskValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, field := range val {
+			if c.masked[key] {
+				val[key] = maskedValue(field)
+				continue
+			}
+			val[key] = c.maskValue(field)
+		}
+	case []interface{}:
+		for i := range val {
+			val[i] = c.maskValue(val[i])
+		}
+	}
+	return v
+}
+
+// maskedValue 脱敏后的值，字符串保留长度便于排查
+func maskedValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if val == "" {
+			return ""
+		}
+		return fmt.Sprintf("[MASKED %d chars]", len([]rune(val)))
+	default:
+		return "[MASKED]"
+	}
+}
+
+// sampledEvents 需要采样的高频流式事件
+var sampledEvents = map[string]bool{
+	"message":       true,
+	"agent_message": true,
+	"text_chunk":    true,
+	"tts_message":   true,
+}
+
+// streamLog 一次流式响应的日志状态
+type streamLog struct {
+	logger *slog.Logger
+	cfg    *logConfig
+	ctx    context.Context
+	attrs  []slog.Attr
+	start  time.Time
+
+	events  int
+	sampled int
+	once    sync.Once
+}
+
+func (s *streamLog) event(ev StreamEvent) {
+	s.events++
+	if sampledEvents[ev.Event] && s.cfg.sampleEvery > 1 {
+		s.sampled++
+		if (s.sampled-1)%s.cfg.sampleEvery != 0 {
+			return
+		}
+	}
+
+	attrs := append(s.attrs, slog.String("event", ev.Event), slog.Int("seq", s.events))
+	if s.cfg.bodies {
+		attrs = append(attrs, slog.String("data", s.cfg.mask(ev.Data)))
+	}
+	level := s.cfg.streamLevel
+	if ev.Event == "error" {
+		level = s.cfg.errorLevel
+	}
+	s.logger.LogAttrs(s.ctx, level, "dify stream event", attrs...)
+}
+
+func (s *streamLog) end(err error) {
+	s.once.Do(func() {
+		attrs := append(s.attrs, slog.Int("events", s.events), slog.Duration("duration", time.Since(s.start)))
+		if err != nil {
+			s.logger.LogAttrs(s.ctx, s.cfg.errorLevel, "dify stream ended", append(attrs, slog.String("error", err.Error()))...)
+			return
+		}
+		s.logger.LogAttrs(s.ctx, s.cfg.level, "dify stream ended", attrs...)
+	})
+}
+
+// teeBody 读取响应体时复制最多 limit 字节到 buf
+type teeBody struct {
+	io.ReadCloser
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remain := b.limit - b.buf.Len(); n > 0 && remain > 0 {
+		if n < remain {
+			remain = n
+		}
+		b.buf.Write(p[:remain])
+	}
+	return n, err
+}
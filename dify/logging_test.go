@@ -0,0 +1,50 @@
+package dify
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试日志隐藏 Authorization、脱敏请求内容并对高频流式事件采样
+func TestLoggingMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "data: {\"event\":\"message\",\"task_id\":\"t\",\"answer\":\"secret answer %d\"}\n\n", i)
+		}
+		fmt.Fprint(w, "data: {\"event\":\"message_end\",\"task_id\":\"t\"}\n\n")
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("secret-key", WithBaseURL(server.URL),
+		WithLogger(logger, WithLogBodies(true), WithLogStreamSampling(2)))
+
+	err := client.CreateStreamingChat(&ChatRequest{Query: "private question", User: "alice"}, &recordingHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, leaked := range []string{"secret-key", "private question", "secret answer", "alice"} {
+		if strings.Contains(out, leaked) {
+			t.Fatalf("log contains %q:\n%s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, redacted) || !strings.Contains(out, "[MASKED 16 chars]") {
+		t.Fatalf("log missing redaction markers:\n%s", out)
+	}
+	// 5 个 message 事件按 2 采样记录 3 个，message_end 始终记录
+	if n := strings.Count(out, `"msg":"dify stream event"`); n != 4 {
+		t.Fatalf("stream event logs = %d, want 4:\n%s", n, out)
+	}
+	if !strings.Contains(out, `"msg":"dify stream ended"`) || !strings.Contains(out, `"events":6`) {
+		t.Fatalf("missing stream end log:\n%s", out)
+	}
+}
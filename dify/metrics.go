@@ -0,0 +1,226 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 非 Dify 返回的错误码
+const (
+	MetricsCodeNetwork     = "network"      // 连接或传输错误
+	MetricsCodeCanceled    = "canceled"     // 请求上下文被取消或超时
+	MetricsCodeCircuitOpen = "circuit_open" // 熔断器拒绝
+	MetricsCodeQueueFull   = "queue_full"   // 限流队列已满
+)
+
+// maxErrorBody 读取错误响应体的最大字节数
+const maxErrorBody = 64 << 10
+
+// Metrics 指标收集接口，客户端在请求和流式响应的各个阶段调用
+//
+// 实现需要支持并发调用。只需要部分指标时可以嵌入 NopMetrics。
+type Metrics interface {
+	// IncRequest 请求完成，statusCode 为 0 表示没有收到响应
+	IncRequest(op *Operation, statusCode int)
+	// IncError 请求失败，code 为 Dify 返回的错误码或 MetricsCode* 常量，非 Dify 格式的错误响应为 http_<状态码>
+	IncError(op *Operation, code string)
+	// ObserveLatency 阻塞请求从发出到响应体读取完毕的耗时
+	ObserveLatency(op *Operation, d time.Duration)
+	// ObserveTimeToFirstToken 流式请求从发出到收到第一个文本片段的耗时
+	ObserveTimeToFirstToken(op *Operation, d time.Duration)
+	// ObserveChunkGap 流式响应中相邻两个事件的间隔
+	ObserveChunkGap(op *Operation, d time.Duration)
+	// ObserveTokensPerSecond 流式响应的输出速度
+	ObserveTokensPerSecond(op *Operation, tokensPerSecond float64)
+	// AddBytesUploaded 上传文件请求发送的字节数
+	AddBytesUploaded(op *Operation, n int64)
+	// StreamStarted 流式响应开始
+	StreamStarted(op *Operation)
+	// StreamEnded 流式响应结束
+	StreamEnded(op *Operation)
+}
+
+// NopMetrics 不做任何处理的 Metrics 实现
+type NopMetrics struct{}
+
+func (NopMetrics) IncRequest(op *Operation, statusCode int)                      {}
+func (NopMetrics) IncError(op *Operation, code string)                           {}
+func (NopMetrics) ObserveLatency(op *Operation, d time.Duration)                 {}
+func (NopMetrics) ObserveTimeToFirstToken(op *Operation, d time.Duration)        {}
+func (NopMetrics) ObserveChunkGap(op *Operation, d time.Duration)                {}
+func (NopMetrics) ObserveTokensPerSecond(op *Operation, tokensPerSecond float64) {}
+func (NopMetrics) AddBytesUploaded(op *Operation, n int64)                       {}
+func (NopMetrics) StreamStarted(op *Operation)                                   {}
+func (NopMetrics) StreamEnded(op *Operation)                                     {}
+
+// WithMetrics 设置指标收集，相当于添加 MetricsMiddleware(metrics)
+func WithMetrics(metrics Metrics) ClientOption {
+	return WithMiddleware(MetricsMiddleware(metrics))
+}
+
+// MetricsMiddleware 创建向 metrics 报告指标的中间件，knowledge.Client 也可以使用
+func MetricsMiddleware(metrics Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			if req.ContentLength > 0 && strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+				metrics.AddBytesUploaded(op, req.ContentLength)
+			}
+
+			resp, err := next(op, req)
+			if err != nil {
+				metrics.IncRequest(op, 0)
+				metrics.IncError(op, transportErrorCode(err))
+				return nil, err
+			}
+
+			metrics.IncRequest(op, resp.StatusCode)
+			if resp.StatusCode >= http.StatusBadRequest {
+				metrics.IncError(op, responseErrorCode(resp))
+			}
+
+			if op.Stream && resp.StatusCode == http.StatusOK {
+				s := &streamMetrics{metrics: metrics, op: op, start: start, last: start}
+				metrics.StreamStarted(op)
+				op.OnStreamEvent(s.event)
+				op.OnStreamEnd(func(error) { s.end() })
+				resp.Body = &closeHookBody{ReadCloser: resp.Body, close: s.end}
+				return resp, nil
+			}
+
+			resp.Body = &closeHookBody{ReadCloser: resp.Body, close: func() {
+				metrics.ObserveLatency(op, time.Since(start))
+			}}
+			return resp, nil
+		}
+	}
+}
+
+// transportErrorCode 将未收到响应的错误归类为错误码
+func transportErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return MetricsCodeCircuitOpen
+	case errors.Is(err, ErrLimiterQueueFull):
+		return MetricsCodeQueueFull
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return MetricsCodeCanceled
+	default:
+		return MetricsCodeNetwork
+	}
+}
+
+// responseErrorCode 读取错误响应体中的错误码
+func responseErrorCode(resp *http.Response) string {
+	body := peekBody(resp, maxErrorBody)
+	var difyErr DifyError
+	if json.Unmarshal(body, &difyErr) == nil && difyErr.Code != "" {
+		return difyErr.Code
+	}
+	return "http_" + strconv.Itoa(resp.StatusCode)
+}
+
+// streamMetrics 一次流式响应的指标状态
+type streamMetrics struct {
+	metrics Metrics
+	op      *Operation
+	start   time.Time
+	last    time.Time
+	// firstToken 收到第一个文本片段的时间
+	firstToken time.Time
+	once       sync.Once
+}
+
+func (s *streamMetrics) event(ev StreamEvent) {
+	now := time.Now()
+	if s.last != s.start {
+		s.metrics.ObserveChunkGap(s.op, now.Sub(s.last))
+	}
+	s.last = now
+
+	switch ev.Event {
+	case "message", "agent_message", "text_chunk":
+		if s.firstToken.IsZero() {
+			s.firstToken = now
+			s.metrics.ObserveTimeToFirstToken(s.op, now.Sub(s.start))
+		}
+	case "message_end":
+		var resp struct {
+			Metadata struct {
+				Usage *Usage `json:"usage"`
+			} `json:"metadata"`
+		}
+		if json.Unmarshal(ev.Data, &resp) != nil || resp.Metadata.Usage == nil {
+			return
+		}
+		from := s.firstToken
+		if from.IsZero() {
+			from = s.start
+		}
+		s.tokensPerSecond(resp.Metadata.Usage.CompletionTokens, now.Sub(from))
+	case "workflow_finished":
+		var resp struct {
+			Data struct {
+				TotalTokens int     `json:"total_tokens"`
+				ElapsedTime float64 `json:"elapsed_time"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(ev.Data, &resp) != nil {
+			return
+		}
+		s.tokensPerSecond(resp.Data.TotalTokens, time.Duration(resp.Data.ElapsedTime*float64(time.Second)))
+	case "error":
+		var resp struct {
+			Code string `json:"code"`
+		}
+		json.Unmarshal(ev.Data, &resp)
+		if resp.Code == "" {
+			resp.Code = "stream_error"
+		}
+		s.metrics.IncError(s.op, resp.Code)
+	}
+}
+
+func (s *streamMetrics) tokensPerSecond(tokens int, d time.Duration) {
+	if tokens <= 0 || d <= 0 {
+		return
+	}
+	s.metrics.ObserveTokensPerSecond(s.op, float64(tokens)/d.Seconds())
+}
+
+func (s *streamMetrics) end() {
+	s.once.Do(func() {
+		s.metrics.StreamEnded(s.op)
+	})
+}
+
+// peekBody 读取响应体开头最多 limit 字节，并将响应体还原供调用方读取
+func peekBody(resp *http.Response, limit int64) []byte {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, limit))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	return body
+}
+
+// closeHookBody 在响应体关闭时调用 close
+type closeHookBody struct {
+	io.ReadCloser
+	close func()
+	once  sync.Once
+}
+
+func (b *closeHookBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.close)
+	return err
+}
@@ -0,0 +1,121 @@
+package dify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试流式请求的首 token、事件间隔、输出速度和活跃流，以及错误响应的错误码
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointInfo {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid_param","message":"bad","status":400}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"event\":\"message\",\"task_id\":\"t\",\"answer\":\"hi\"}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"event\":\"message_end\",\"task_id\":\"t\",\"metadata\":{\"usage\":{\"completion_tokens\":10}}}\n\n")
+	}))
+	defer server.Close()
+
+	metrics := &recordingMetrics{}
+	client := NewClient("test-key", WithBaseURL(server.URL), WithMetrics(metrics))
+
+	handler := &recordingHandler{}
+	if err := client.CreateStreamingChat(&ChatRequest{Query: "hi", User: UserExample}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAppInfo(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if strings.Join(metrics.requests, ",") != "CreateStreamingChat:200,GetAppInfo:400" {
+		t.Fatalf("requests = %v", metrics.requests)
+	}
+	if strings.Join(metrics.errors, ",") != "GetAppInfo:invalid_param" {
+		t.Fatalf("errors = %v", metrics.errors)
+	}
+	if metrics.firstTokens != 1 || metrics.gaps != 1 || metrics.tokensPerSecond <= 0 {
+		t.Fatalf("firstTokens = %d, gaps = %d, tokensPerSecond = %v", metrics.firstTokens, metrics.gaps, metrics.tokensPerSecond)
+	}
+	if metrics.activeStreams != 0 || metrics.streams != 1 {
+		t.Fatalf("activeStreams = %d, streams = %d", metrics.activeStreams, metrics.streams)
+	}
+	if metrics.latencies != 1 {
+		t.Fatalf("latencies = %d, want 1", metrics.latencies)
+	}
+}
+
+// recordingMetrics 记录收到的指标
+type recordingMetrics struct {
+	NopMetrics
+
+	mu              sync.Mutex
+	requests        []string
+	errors          []string
+	latencies       int
+	firstTokens     int
+	gaps            int
+	tokensPerSecond float64
+	streams         int
+	activeStreams   int
+}
+
+func (m *recordingMetrics) IncRequest(op *Operation, statusCode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s:%d", op.Name, statusCode))
+}
+
+func (m *recordingMetrics) IncError(op *Operation, code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = append(m.errors, op.Name+":"+code)
+}
+
+func (m *recordingMetrics) ObserveLatency(op *Operation, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies++
+}
+
+func (m *recordingMetrics) ObserveTimeToFirstToken(op *Operation, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.firstTokens++
+}
+
+func (m *recordingMetrics) ObserveChunkGap(op *Operation, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gaps++
+}
+
+func (m *recordingMetrics) ObserveTokensPerSecond(op *Operation, tokensPerSecond float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokensPerSecond = tokensPerSecond
+}
+
+func (m *recordingMetrics) StreamStarted(op *Operation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streams++
+	m.activeStreams++
+}
+
+func (m *recordingMetrics) StreamEnded(op *Operation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activeStreams--
+}
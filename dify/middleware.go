@@ -0,0 +1,75 @@
+package dify
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Operation 描述一次 SDK 调用，中间件可以据此获取 HTTP 请求之外的 SDK 层信息
+//
+// 同一次调用中 Operation 只在发起调用的 goroutine 中使用，中间件注册的观察函数也在该 goroutine 中被调用。
+type Operation struct {
+	Name           string         // SDK 方法名，如 CreateChat、ListKnowledge
+	Family         EndpointFamily // 端点分组
+	Stream         bool           // 是否为流式请求
+	User           string         // 终端用户标识
+	ConversationID string         // 会话ID
+	TaskID         string         // 任务ID
+	MessageID      string         // 消息ID
+	DatasetID      string         // 知识库ID
+	DocumentID     string         // 文档ID
+
+	eventObservers []func(StreamEvent)
+	endObservers   []func(error)
+}
+
+// StreamEvent 流式响应中的一个 SSE 事件
+type StreamEvent struct {
+	Event string          // 事件类型，如 message、message_end、node_started
+	Data  json.RawMessage // 事件原始 JSON 数据
+}
+
+// OnStreamEvent 注册流式事件观察函数，SDK 每解析出一个事件都会调用
+func (op *Operation) OnStreamEvent(fn func(event StreamEvent)) {
+	op.eventObservers = append(op.eventObservers, fn)
+}
+
+// OnStreamEnd 注册流式结束观察函数，err 为流式处理的最终结果
+func (op *Operation) OnStreamEnd(fn func(err error)) {
+	op.endObservers = append(op.endObservers, fn)
+}
+
+// EmitStreamEvent 通知所有观察函数收到了一个流式事件
+func (op *Operation) EmitStreamEvent(event StreamEvent) {
+	for _, fn := range op.eventObservers {
+		fn(event)
+	}
+}
+
+// EmitStreamEnd 通知所有观察函数流式处理已结束
+func (op *Operation) EmitStreamEnd(err error) {
+	for _, fn := range op.endObservers {
+		fn(err)
+	}
+}
+
+// Handler 处理一次 HTTP 请求
+type Handler func(op *Operation, req *http.Request) (*http.Response, error)
+
+// Middleware 请求中间件，可以修改请求、直接返回响应而不调用 next，或观察响应和流式事件
+type Middleware func(next Handler) Handler
+
+// Chain 将中间件按顺序包装到 handler 外层，第一个中间件位于最外层
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// WithMiddleware 为客户端添加中间件，可以多次调用，先添加的位于外层
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return clientOptionFunc(func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	})
+}
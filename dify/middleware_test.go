@@ -0,0 +1,88 @@
+package dify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试中间件可以修改请求、观察流式事件以及直接返回响应
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant"); got != "acme" {
+			t.Errorf("X-Tenant = %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"event\":\"message\",\"task_id\":\"task-1\",\"answer\":\"hi\"}\n\n")
+		io.WriteString(w, "data: {\"event\":\"message_end\",\"task_id\":\"task-1\"}\n\n")
+	}))
+	defer server.Close()
+
+	var names, events []string
+	var ended bool
+	tenant := func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			names = append(names, op.Name)
+			req.Header.Set("X-Tenant", "acme")
+			op.OnStreamEvent(func(event StreamEvent) {
+				events = append(events, event.Event)
+			})
+			op.OnStreamEnd(func(err error) {
+				ended = err == nil
+			})
+			return next(op, req)
+		}
+	}
+	client := NewClient("test-key", WithBaseURL(server.URL), WithMiddleware(tenant))
+
+	handler := &recordingHandler{}
+	if err := client.CreateStreamingChat(&ChatRequest{Query: "hi", User: UserExample}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "CreateStreamingChat" {
+		t.Fatalf("operation names = %v", names)
+	}
+	if strings.Join(events, ",") != "message,message_end" || !ended {
+		t.Fatalf("events = %v, ended = %v", events, ended)
+	}
+
+	cached := func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"name":"cached"}`)),
+			}, nil
+		}
+	}
+	offline := NewClient("test-key", WithBaseURL("http://127.0.0.1:0"), WithMiddleware(cached))
+	info, err := offline.GetAppInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "cached" {
+		t.Fatalf("name = %q, want cached", info.Name)
+	}
+}
+
+// recordingHandler 记录收到的回答片段
+type recordingHandler struct {
+	answers []string
+}
+
+func (h *recordingHandler) OnMessage(response *MessageStreamResponse) error {
+	h.answers = append(h.answers, response.Answer)
+	return nil
+}
+
+func (h *recordingHandler) OnMessageWorkflow(response *WorkflowStreamResponse) error { return nil }
+
+func (h *recordingHandler) OnMessageEnd(response *MessageEndStreamResponse) error { return nil }
+
+func (h *recordingHandler) OnTTS(response *TTSStreamResponse) error { return nil }
+
+func (h *recordingHandler) OnTTSEnd(response *TTSStreamResponse) error { return nil }
+
+func (h *recordingHandler) OnError(err error) error { return err }
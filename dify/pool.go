@@ -0,0 +1,213 @@
+package dify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoPoolMember 节点池中没有可以发送请求的节点
+var ErrNoPoolMember = errors.New("no available pool member")
+
+// PoolMember 节点池成员，由 API Key 和基础 URL 组成
+type PoolMember struct {
+	APIKey  string // API Key
+	BaseURL string // 基础 URL，为空时使用客户端的 BaseURL
+	Weight  int    // 轮询权重，小于等于 0 时视为 1
+}
+
+// PoolMemberStatus 节点健康状态快照
+type PoolMemberStatus struct {
+	PoolMember
+	Healthy        bool      // 当前是否健康
+	UnhealthyUntil time.Time // 冷却结束时间
+	Failures       int       // 连续失败次数
+}
+
+// poolMember 节点池成员的运行时状态
+type poolMember struct {
+	PoolMember
+	current        int
+	unhealthyUntil time.Time
+	failures       int
+}
+
+// pinnedMember 会话、任务或消息与创建它的节点的绑定关系
+type pinnedMember struct {
+	member    *poolMember
+	expiresAt time.Time
+}
+
+// Pool 多 API Key / 多基础 URL 节点池
+//
+// 普通请求按权重平滑轮询选择健康节点，遇到 429、5xx、连接错误，或 400 且错误码为 provider_quota_exceeded、
+// model_currently_not_support 时，将节点标记为不健康并在冷却期内跳过，同时换下一个节点重试。
+// 会话、任务、消息以及上传文件相关的请求固定发往创建它们的节点，不做故障转移。
+type Pool struct {
+	mu       sync.Mutex
+	members  []*poolMember
+	cooldown time.Duration
+	pinTTL   time.Duration
+	pins     map[string]pinnedMember
+	writes   int
+}
+
+// PoolOption 定义节点池选项接口
+type PoolOption interface {
+	apply(*Pool)
+}
+
+// poolOptionFunc 是一个适配器，允许使用普通函数作为 PoolOption
+type poolOptionFunc func(*Pool)
+
+func (f poolOptionFunc) apply(p *Pool) {
+	f(p)
+}
+
+// WithPoolCooldown 设置节点被标记为不健康后的冷却时间，默认 30 秒
+func WithPoolCooldown(cooldown time.Duration) PoolOption {
+	return poolOptionFunc(func(p *Pool) {
+		p.cooldown = cooldown
+	})
+}
+
+// WithPoolPinTTL 设置会话与节点绑定关系的保留时间，默认 24 小时
+func WithPoolPinTTL(ttl time.Duration) PoolOption {
+	return poolOptionFunc(func(p *Pool) {
+		p.pinTTL = ttl
+	})
+}
+
+// NewPool 创建节点池
+func NewPool(members []PoolMember, opts ...PoolOption) *Pool {
+	p := &Pool{
+		cooldown: 30 * time.Second,
+		pinTTL:   24 * time.Hour,
+		pins:     make(map[string]pinnedMember),
+	}
+	for _, m := range members {
+		if m.Weight <= 0 {
+			m.Weight = 1
+		}
+		p.members = append(p.members, &poolMember{PoolMember: m})
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	return p
+}
+
+// WithPool 设置客户端使用的节点池，设置后 APIKey 和 BaseURL 仅作为成员的默认值
+func WithPool(pool *Pool) ClientOption {
+	return clientOptionFunc(func(c *Client) {
+		c.pool = pool
+	})
+}
+
+// Status 返回所有节点的健康状态
+func (p *Pool) Status() []PoolMemberStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]PoolMemberStatus, 0, len(p.members))
+	for _, m := range p.members {
+		statuses = append(statuses, PoolMemberStatus{
+			PoolMember:     m.PoolMember,
+			Healthy:        !now.Before(m.unhealthyUntil),
+			UnhealthyUntil: m.unhealthyUntil,
+			Failures:       m.failures,
+		})
+	}
+	return statuses
+}
+
+// Size 返回节点数量
+func (p *Pool) Size() int {
+	return len(p.members)
+}
+
+// pick 选择一个节点，pinKeys 中第一个已绑定节点的键决定返回的节点，exclude 中的节点不参与选择
+func (p *Pool) pick(pinKeys []string, exclude map[*poolMember]bool) (member *poolMember, pinned bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range pinKeys {
+		if pin, ok := p.pins[key]; ok && now.Before(pin.expiresAt) {
+			return pin.member, true
+		}
+	}
+
+	// 平滑加权轮询，只在健康节点中选择
+	var best *poolMember
+	total := 0
+	for _, m := range p.members {
+		if exclude[m] || now.Before(m.unhealthyUntil) {
+			continue
+		}
+		m.current += m.Weight
+		total += m.Weight
+		if best == nil || m.current > best.current {
+			best = m
+		}
+	}
+	if best != nil {
+		best.current -= total
+		return best, false
+	}
+
+	// 没有健康节点时选择最早结束冷却的节点
+	for _, m := range p.members {
+		if exclude[m] {
+			continue
+		}
+		if best == nil || m.unhealthyUntil.Before(best.unhealthyUntil) {
+			best = m
+		}
+	}
+	return best, false
+}
+
+// markFailure 将节点标记为不健康
+func (p *Pool) markFailure(m *poolMember) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m.failures++
+	m.unhealthyUntil = time.Now().Add(p.cooldown)
+}
+
+// markSuccess 将节点恢复为健康
+func (p *Pool) markSuccess(m *poolMember) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m.failures = 0
+	m.unhealthyUntil = time.Time{}
+}
+
+// pin 将会话、任务或消息ID绑定到节点
+func (p *Pool) pin(key string, m *poolMember) {
+	if key == "" || m == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.pins[key] = pinnedMember{member: m, expiresAt: now.Add(p.pinTTL)}
+
+	// 定期清理过期的绑定关系，避免长时间运行时无限增长
+	p.writes++
+	if p.writes%1024 == 0 {
+		for k, pin := range p.pins {
+			if !now.Before(pin.expiresAt) {
+				delete(p.pins, k)
+			}
+		}
+	}
+}
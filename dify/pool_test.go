@@ -0,0 +1,156 @@
+package dify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// 测试节点故障时切换到健康节点，且会话相关请求固定到创建会话的节点
+func TestPoolFailoverAndPinning(t *testing.T) {
+	var downHits, upHits int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downHits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upHits, 1)
+		if got := r.Header.Get("Authorization"); got != "Bearer key-up" {
+			t.Errorf("Authorization = %q", got)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{ConversationId: "conv-1", MessageID: "msg-1"})
+	}))
+	defer up.Close()
+
+	pool := NewPool([]PoolMember{
+		{APIKey: "key-down", BaseURL: down.URL, Weight: 10},
+		{APIKey: "key-up", BaseURL: up.URL},
+	})
+	client := NewClient("", WithPool(pool))
+
+	resp, err := client.CreateChat(&ChatRequest{Query: "hi", User: UserExample})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ConversationId != "conv-1" {
+		t.Fatalf("conversation = %q", resp.ConversationId)
+	}
+	if downHits != 1 || upHits != 1 {
+		t.Fatalf("hits down=%d up=%d, want 1 and 1", downHits, upHits)
+	}
+
+	status := pool.Status()
+	if status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("unexpected pool status: %+v", status)
+	}
+
+	// 冷却期内的节点被跳过，会话请求发往创建会话的节点
+	if _, err := client.CreateChat(&ChatRequest{Query: "again", User: UserExample, ConversationId: "conv-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if downHits != 1 || upHits != 2 {
+		t.Fatalf("hits down=%d up=%d, want 1 and 2", downHits, upHits)
+	}
+}
+
+// 测试 API Key 额度用尽时换节点重试，并将该节点标记为不健康
+func TestPoolFailoverOnQuotaExceeded(t *testing.T) {
+	var exhaustedHits, healthyHits int32
+	exhausted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exhaustedHits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DifyError{Code: ErrCodeProviderQuotaExceeded, Message: "Your quota for Dify Hosted OpenAI has been exhausted.", Status: 400})
+	}))
+	defer exhausted.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&healthyHits, 1)
+		json.NewEncoder(w).Encode(ChatResponse{ConversationId: "conv-1", MessageID: "msg-1"})
+	}))
+	defer healthy.Close()
+
+	pool := NewPool([]PoolMember{
+		{APIKey: "key-exhausted", BaseURL: exhausted.URL, Weight: 10},
+		{APIKey: "key-healthy", BaseURL: healthy.URL},
+	})
+	client := NewClient("", WithPool(pool))
+
+	if _, err := client.CreateChat(&ChatRequest{Query: "hi", User: UserExample}); err != nil {
+		t.Fatal(err)
+	}
+	if exhaustedHits != 1 || healthyHits != 1 {
+		t.Fatalf("hits exhausted=%d healthy=%d, want 1 and 1", exhaustedHits, healthyHits)
+	}
+	if status := pool.Status(); status[0].Healthy || status[0].Failures != 1 {
+		t.Fatalf("unexpected pool status: %+v", status)
+	}
+
+	// 其他 400 错误与节点无关，不做故障转移，响应体保持完整
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DifyError{Code: ErrCodeInvalidParam, Message: "query is required", Status: 400})
+	}))
+	defer invalid.Close()
+	single := NewClient("", WithPool(NewPool([]PoolMember{{APIKey: "key", BaseURL: invalid.URL}, {APIKey: "key-healthy", BaseURL: healthy.URL}})))
+	_, err := single.CreateChat(&ChatRequest{User: UserExample})
+	if err == nil || !strings.Contains(err.Error(), "query is required") {
+		t.Fatalf("error = %v, want invalid_param body", err)
+	}
+	if healthyHits != 1 {
+		t.Fatalf("healthy hits = %d, want no failover", healthyHits)
+	}
+}
+
+// 测试引用上传文件的请求发往接收上传的节点
+func TestPoolPinsUploadedFiles(t *testing.T) {
+	var hits [2]int32
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits[i], 1)
+			if r.URL.Path == EndpointFiles+"/upload" {
+				json.NewEncoder(w).Encode(FileUploadResponse{ID: "file-1"})
+				return
+			}
+			json.NewEncoder(w).Encode(ChatResponse{ConversationId: "conv-1", MessageID: "msg-1"})
+		}))
+		defer servers[i].Close()
+	}
+
+	pool := NewPool([]PoolMember{{APIKey: "key-a", BaseURL: servers[0].URL}, {APIKey: "key-b", BaseURL: servers[1].URL}})
+	client := NewClient("", WithPool(pool))
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := client.UploadFile(path, UserExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded := 0
+	if hits[1] == 1 {
+		uploaded = 1
+	}
+
+	// 轮询本会轮到另一个节点，引用文件的请求仍然发往接收上传的节点
+	for i := 0; i < 3; i++ {
+		if _, err := client.CreateChat(&ChatRequest{Query: "describe", User: UserExample, Files: []FileInput{
+			{Type: "document", TransferMethod: "local_file", UploadFileID: file.ID},
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits[uploaded] != 4 || hits[1-uploaded] != 0 {
+		t.Fatalf("hits = %v, want all on member %d", hits, uploaded)
+	}
+}
@@ -0,0 +1,225 @@
+package dify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hb1707/dify-go-sdk/internal/transport"
+)
+
+// 供 knowledge 包通过 internal/transport 复用请求管线
+func init() {
+	transport.DecodeError = func(resp *http.Response) error { return DecodeError(resp) }
+	transport.RequestOptionsMiddleware = requestOptionsMiddleware
+	transport.OpenRawStream = openRawStream
+}
+
+// Do 调用 SDK 尚未封装的端点，复用鉴权、中间件、熔断、限流、节点池和错误解析
+//
+// path 为相对于基础 URL 的路径，可以包含查询参数，如 "/messages?user=abc"。
+// body 为 nil 时不发送请求体，[]byte、json.RawMessage 和 io.Reader 原样发送，其他值编码为 JSON。
+// 只有 json.RawMessage 和编码为 JSON 的请求体会带上 Content-Type: application/json，
+// []byte 和 io.Reader（如 multipart 表单）需要通过 WithRequestContentType 指定内容类型。
+// out 不为 nil 时将响应 JSON 解码到 out。非 2xx 响应返回 *DifyError。
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}, opts ...RequestOption) error {
+	data, contentType, err := transport.EncodeBody(body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(&apiRequest{
+		ctx:         ctx,
+		name:        "Do",
+		opts:        opts,
+		method:      method,
+		path:        path,
+		family:      familyForPath(path),
+		body:        data,
+		contentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	return transport.DecodeResponse(resp, out)
+}
+
+// DoStream 以流式模式调用 SDK 尚未封装的端点，返回原始 SSE 事件流，使用完毕后需要调用 Close
+//
+// 请求体和 Content-Type 的规则与 Do 相同。
+func (c *Client) DoStream(ctx context.Context, method, path string, body interface{}, opts ...RequestOption) (*RawStream, error) {
+	data, contentType, err := transport.EncodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	r := &apiRequest{
+		ctx:         ctx,
+		name:        "DoStream",
+		opts:        opts,
+		method:      method,
+		path:        path,
+		family:      familyForPath(path),
+		body:        data,
+		contentType: contentType,
+		header:      http.Header{"Accept": {"text/event-stream"}},
+		stream:      true,
+	}
+	resp, err := c.send(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return openRawStream(r.op, resp)
+}
+
+// openRawStream 检查流式响应的状态码并返回原始事件流，非 2xx 响应返回 *DifyError
+//
+// op 为本次调用的描述，解析出的事件会通过 op.EmitStreamEvent 通知中间件。
+func openRawStream(op *Operation, resp *http.Response) (*RawStream, error) {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := DecodeError(resp)
+		op.EmitStreamEnd(err)
+		return nil, err
+	}
+	return &RawStream{op: op, body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// RawStream 原始 SSE 事件流
+//
+//	for stream.Next() {
+//		ev := stream.Event()
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type RawStream struct {
+	op     *Operation
+	body   io.ReadCloser
+	reader *bufio.Reader
+	event  StreamEvent
+	err    error
+	done   bool
+	once   sync.Once
+}
+
+// Next 读取下一个事件，流结束或出错时返回 false
+//
+// 没有数据的事件（如 ping）会被跳过；error 事件会结束流，Err 返回对应的 *DifyError。
+func (s *RawStream) Next() bool {
+	if s.done {
+		return false
+	}
+	for {
+		name, data, err := s.readEvent()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.err = fmt.Errorf("failed to read stream: %w", err)
+			}
+			s.finish()
+			return false
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		var base StreamResponse
+		if json.Unmarshal(data, &base) == nil && base.Event != "" {
+			name = base.Event
+		}
+		s.event = StreamEvent{Event: name, Data: json.RawMessage(data)}
+		s.op.EmitStreamEvent(s.event)
+
+		if name == "error" {
+			difyErr := &DifyError{}
+			json.Unmarshal(data, difyErr)
+			s.err = difyErr
+			s.finish()
+			return false
+		}
+		return true
+	}
+}
+
+// Event 返回当前事件
+func (s *RawStream) Event() StreamEvent {
+	return s.event
+}
+
+// Err 返回流中遇到的错误，正常结束时为 nil
+func (s *RawStream) Err() error {
+	return s.err
+}
+
+// Close 关闭响应体，未读完的流会被丢弃
+func (s *RawStream) Close() error {
+	s.finish()
+	return s.body.Close()
+}
+
+// finish 标记流结束并通知中间件
+func (s *RawStream) finish() {
+	s.done = true
+	s.once.Do(func() {
+		s.op.EmitStreamEnd(s.err)
+	})
+}
+
+// readEvent 读取一个以空行结束的 SSE 事件，多行 data 以换行拼接
+func (s *RawStream) readEvent() (name string, data []byte, err error) {
+	var buf bytes.Buffer
+	lines := 0
+	for {
+		line, err := s.reader.ReadString('\n')
+		if line == "" && err != nil {
+			if lines > 0 && errors.Is(err, io.EOF) {
+				return name, buf.Bytes(), nil
+			}
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if lines > 0 {
+				return name, buf.Bytes(), nil
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		lines++
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(value)
+		}
+		if err != nil {
+			return name, buf.Bytes(), nil
+		}
+	}
+}
+
+// familyForPath 按路径推断端点分组，用于熔断统计
+func familyForPath(path string) EndpointFamily {
+	switch {
+	case strings.HasPrefix(path, EndpointChat), strings.HasPrefix(path, EndpointMessages),
+		strings.HasPrefix(path, EndpointConversations):
+		return FamilyChat
+	case strings.HasPrefix(path, EndpointCompletion):
+		return FamilyCompletion
+	case strings.HasPrefix(path, EndpointWorkflows):
+		return FamilyWorkflows
+	case strings.HasPrefix(path, EndpointFiles):
+		return FamilyFiles
+	default:
+		return FamilyApp
+	}
+}
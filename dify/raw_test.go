@@ -0,0 +1,148 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试 Do 的鉴权、请求体、响应解码和错误解析
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/messages/msg-1/suggested":
+			if r.URL.Query().Get("user") != "u1" {
+				t.Errorf("query = %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"result":"success","data":["a","b"]}`)
+		case "/conversations/conv-1/name":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":"invalid_param","message":"name is required","status":400}`)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	client := NewClient("key", WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	var out struct {
+		Data []string `json:"data"`
+	}
+	if err := client.Do(ctx, http.MethodGet, "/messages/msg-1/suggested?user=u1", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(out.Data, ",") != "a,b" {
+		t.Fatalf("data = %v", out.Data)
+	}
+
+	err := client.Do(ctx, http.MethodPost, "/conversations/conv-1/name", map[string]string{"user": "u1"}, nil)
+	var difyErr *DifyError
+	if !errors.As(err, &difyErr) || !IsInvalidParam(difyErr) || difyErr.Message != "name is required" {
+		t.Fatalf("error = %v", err)
+	}
+	if err := client.Do(ctx, http.MethodGet, "/missing", nil, nil); !errors.As(err, &difyErr) || difyErr.Code != "http_404" {
+		t.Fatalf("error = %v, want http_404", err)
+	}
+}
+
+// 测试 DoStream 的 SSE 分帧、ping 跳过、error 事件以及中间件观察
+func TestDoStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: ping\n\n")
+		fmt.Fprint(w, "data: {\"event\":\"message\",\"answer\":\"hi\"}\n\n")
+		fmt.Fprint(w, "event: custom\ndata: line one\ndata: line two\n\n")
+		fmt.Fprint(w, "data: {\"event\":\"error\",\"status\":500,\"code\":\"completion_request_error\",\"message\":\"boom\"}\n\n")
+		fmt.Fprint(w, "data: {\"event\":\"message\",\"answer\":\"never\"}\n\n")
+	}))
+	defer srv.Close()
+
+	var observed []string
+	var ended error
+	observe := func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			op.OnStreamEvent(func(ev StreamEvent) { observed = append(observed, ev.Event) })
+			op.OnStreamEnd(func(err error) { ended = err })
+			return next(op, req)
+		}
+	}
+	client := NewClient("key", WithBaseURL(srv.URL), WithMiddleware(observe))
+
+	stream, err := client.DoStream(context.Background(), http.MethodPost, "/chat-messages", map[string]string{"query": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var events []string
+	for stream.Next() {
+		ev := stream.Event()
+		events = append(events, ev.Event+"="+string(ev.Data))
+	}
+	want := `message={"event":"message","answer":"hi"}|custom=line one` + "\n" + `line two`
+	if strings.Join(events, "|") != want {
+		t.Fatalf("events = %q", events)
+	}
+	var difyErr *DifyError
+	if !errors.As(stream.Err(), &difyErr) || difyErr.Code != ErrCodeCompletionRequest {
+		t.Fatalf("stream error = %v", stream.Err())
+	}
+	if strings.Join(observed, ",") != "message,custom,error" || ended != stream.Err() {
+		t.Fatalf("observed = %v, ended = %v", observed, ended)
+	}
+}
+
+// 测试 Do 只在请求体为 JSON 时发送 application/json，原始请求体使用调用方指定的 Content-Type
+func TestDoContentType(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.Header.Get("Content-Type"))
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+	client := NewClient("key", WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	writer.WriteField("user", "u1")
+	writer.Close()
+
+	calls := []func() error{
+		func() error { return client.Do(ctx, http.MethodGet, "/parameters", nil, nil) },
+		func() error {
+			return client.Do(ctx, http.MethodPost, "/messages", map[string]string{"user": "u1"}, nil)
+		},
+		func() error { return client.Do(ctx, http.MethodPost, "/messages", json.RawMessage(`{}`), nil) },
+		func() error { return client.Do(ctx, http.MethodPost, "/messages", []byte("plain"), nil) },
+		func() error {
+			return client.Do(ctx, http.MethodPost, "/files/upload", &form, nil, WithRequestContentType(writer.FormDataContentType()))
+		},
+	}
+	for _, call := range calls {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"GET ",
+		"POST application/json",
+		"POST application/json",
+		"POST ",
+		"POST " + writer.FormDataContentType(),
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("content types = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,324 @@
+package dify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 注册表使用的环境变量
+const (
+	EnvAPIKey  = "DIFY_API_KEY"  // 默认 API Key
+	EnvBaseURL = "DIFY_BASE_URL" // 默认基础 URL
+)
+
+// ErrAppNotFound 注册表中不存在指定的应用
+var ErrAppNotFound = errors.New("dify app not found")
+
+// RegistryConfig 多应用配置文件，支持 YAML 和 JSON 格式
+type RegistryConfig struct {
+	BaseURL string               `yaml:"base_url" json:"base_url"` // 所有应用的默认基础 URL
+	APIKey  string               `yaml:"api_key" json:"api_key"`   // 所有应用的默认 API Key
+	Apps    map[string]AppConfig `yaml:"apps" json:"apps"`         // 应用名到应用配置的映射
+}
+
+// AppConfig 单个应用的配置，值中的 ${VAR} 会按环境变量展开
+type AppConfig struct {
+	APIKey  string `yaml:"api_key" json:"api_key"`
+	BaseURL string `yaml:"base_url" json:"base_url"`
+}
+
+// Registry 多应用客户端注册表，所有客户端共享同一个 http.Client
+type Registry struct {
+	path          string
+	httpClient    *http.Client
+	clientOptions []ClientOption
+	watchInterval time.Duration
+	onReload      func(err error)
+
+	mu      sync.RWMutex
+	apps    map[string]AppConfig
+	clients map[string]*Client
+	modTime time.Time
+	size    int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// RegistryOption 定义注册表选项接口
+type RegistryOption interface {
+	apply(*Registry)
+}
+
+// registryOptionFunc 是一个适配器，允许使用普通函数作为 RegistryOption
+type registryOptionFunc func(*Registry)
+
+func (f registryOptionFunc) apply(r *Registry) {
+	f(r)
+}
+
+// WithRegistryHTTPClient 设置所有应用共享的 HTTP 客户端
+func WithRegistryHTTPClient(httpClient *http.Client) RegistryOption {
+	return registryOptionFunc(func(r *Registry) {
+		r.httpClient = httpClient
+	})
+}
+
+// WithRegistryClientOptions 设置创建每个应用客户端时附加的选项
+func WithRegistryClientOptions(opts ...ClientOption) RegistryOption {
+	return registryOptionFunc(func(r *Registry) {
+		r.clientOptions = append(r.clientOptions, opts...)
+	})
+}
+
+// WithWatchInterval 设置配置文件变更检查间隔，0 表示关闭热加载
+func WithWatchInterval(interval time.Duration) RegistryOption {
+	return registryOptionFunc(func(r *Registry) {
+		r.watchInterval = interval
+	})
+}
+
+// WithReloadHook 设置热加载完成后的回调，err 不为空表示加载失败并继续使用旧配置
+//
+// 解析失败会在下一次检查时重试，同一份文件连续失败时只回调一次。
+func WithReloadHook(fn func(err error)) RegistryOption {
+	return registryOptionFunc(func(r *Registry) {
+		r.onReload = fn
+	})
+}
+
+// NewSharedHTTPClient 创建适合多应用共享的 HTTP 客户端
+func NewSharedHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Minute * 20,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        200,
+			MaxIdleConnsPerHost: 100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			ForceAttemptHTTP2:   true,
+		},
+	}
+}
+
+// NewRegistry 从配置文件加载应用注册表
+//
+// 配置文件为 YAML 或 JSON。环境变量 DIFY_<APP>_API_KEY/DIFY_<APP>_BASE_URL 优先于文件中的应用配置，
+// 未配置的值依次回退到文件顶层的默认值以及 DIFY_API_KEY/DIFY_BASE_URL，
+// 其中 <APP> 为应用名转大写并将非字母数字字符替换为下划线。
+func NewRegistry(path string, opts ...RegistryOption) (*Registry, error) {
+	r := &Registry{
+		path:          path,
+		watchInterval: 5 * time.Second,
+		clients:       make(map[string]*Client),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	if r.httpClient == nil {
+		r.httpClient = NewSharedHTTPClient()
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	if r.watchInterval > 0 {
+		go r.watch()
+	} else {
+		close(r.done)
+	}
+
+	return r, nil
+}
+
+// Get 获取指定应用的客户端
+func (r *Registry) Get(appName string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[appName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAppNotFound, appName)
+	}
+	return client, nil
+}
+
+// Apps 返回所有已注册的应用名，按字母顺序排列
+func (r *Registry) Apps() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload 重新加载配置文件，配置未变化的应用继续使用原有客户端
+func (r *Registry) Reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat registry config: %w", err)
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read registry config: %w", err)
+	}
+
+	var cfg RegistryConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse registry config: %w", err)
+	}
+
+	apps, err := resolveApps(&cfg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make(map[string]*Client, len(apps))
+	for name, app := range apps {
+		if old, ok := r.apps[name]; ok && old == app {
+			clients[name] = r.clients[name]
+			continue
+		}
+		opts := append([]ClientOption{
+			WithBaseURL(app.BaseURL),
+			WithHTTPClient(r.httpClient),
+		}, r.clientOptions...)
+		clients[name] = NewClient(app.APIKey, opts...)
+	}
+
+	r.apps = apps
+	r.clients = clients
+	r.modTime = info.ModTime()
+	r.size = info.Size()
+	return nil
+}
+
+// Close 停止配置文件监听
+func (r *Registry) Close() error {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+	return nil
+}
+
+// watch 定期检查配置文件的修改时间和大小，变化时重新加载
+//
+// 文件可能在写入过程中被读到，解析失败时先不通知，下一次检查时重试；
+// 文件保持不变且仍然失败时才通过回调报告一次错误。
+func (r *Registry) watch() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.watchInterval)
+	defer ticker.Stop()
+
+	var (
+		failedModTime time.Time
+		failedSize    int64 = -1
+		reported      bool
+	)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				r.notifyReload(fmt.Errorf("failed to stat registry config: %w", err))
+				continue
+			}
+
+			r.mu.RLock()
+			changed := !info.ModTime().Equal(r.modTime) || info.Size() != r.size
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			err = r.Reload()
+			if err == nil {
+				failedSize, reported = -1, false
+				r.notifyReload(nil)
+				continue
+			}
+			if !info.ModTime().Equal(failedModTime) || info.Size() != failedSize {
+				failedModTime, failedSize, reported = info.ModTime(), info.Size(), false
+				continue
+			}
+			if !reported {
+				reported = true
+				r.notifyReload(err)
+			}
+		}
+	}
+}
+
+func (r *Registry) notifyReload(err error) {
+	if r.onReload != nil {
+		r.onReload(err)
+	}
+}
+
+// resolveApps 合并配置文件与环境变量，得到每个应用的最终配置
+func resolveApps(cfg *RegistryConfig) (map[string]AppConfig, error) {
+	defaultKey := firstNonEmpty(os.ExpandEnv(cfg.APIKey), os.Getenv(EnvAPIKey))
+	defaultURL := firstNonEmpty(os.ExpandEnv(cfg.BaseURL), os.Getenv(EnvBaseURL), DefaultBaseURL)
+
+	apps := make(map[string]AppConfig, len(cfg.Apps))
+	for name, app := range cfg.Apps {
+		prefix := "DIFY_" + envName(name) + "_"
+		resolved := AppConfig{
+			APIKey:  firstNonEmpty(os.Getenv(prefix+"API_KEY"), os.ExpandEnv(app.APIKey), defaultKey),
+			BaseURL: firstNonEmpty(os.Getenv(prefix+"BASE_URL"), os.ExpandEnv(app.BaseURL), defaultURL),
+		}
+		if resolved.APIKey == "" {
+			return nil, fmt.Errorf("dify app %q has no api key", name)
+		}
+		resolved.BaseURL = strings.TrimRight(resolved.BaseURL, "/")
+		apps[name] = resolved
+	}
+	return apps, nil
+}
+
+// envName 将应用名转换为环境变量名片段，如 support-bot 转为 SUPPORT_BOT
+func envName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package dify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试注册表的环境变量覆盖与热加载
+func TestRegistryReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.yaml")
+	config := `
+base_url: https://dify.example.com/v1
+apps:
+  support-bot:
+    api_key: app-support
+  sales:
+    api_key: app-sales
+    base_url: https://sales.example.com/v1/
+`
+	writeConfig(t, path, config)
+	t.Setenv("DIFY_SUPPORT_BOT_API_KEY", "app-support-env")
+
+	reloaded := make(chan error, 16)
+	registry, err := NewRegistry(path,
+		WithWatchInterval(10*time.Millisecond),
+		WithReloadHook(func(err error) { reloaded <- err }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+
+	support, err := registry.Get("support-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if support.APIKey() != "app-support-env" || support.BaseURL() != "https://dify.example.com/v1" {
+		t.Fatalf("support-bot = %q %q", support.APIKey(), support.BaseURL())
+	}
+	sales, _ := registry.Get("sales")
+	if sales.BaseURL() != "https://sales.example.com/v1" {
+		t.Fatalf("sales base url = %q", sales.BaseURL())
+	}
+	if support.HTTPClient() != sales.HTTPClient() {
+		t.Fatal("expected apps to share one http client")
+	}
+
+	config = `
+apps:
+  support-bot:
+    api_key: app-support
+`
+	writeConfig(t, path, config)
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("config was not reloaded")
+	}
+
+	if _, err := registry.Get("sales"); !errors.Is(err, ErrAppNotFound) {
+		t.Fatalf("Get(sales) error = %v, want ErrAppNotFound", err)
+	}
+	if got := registry.Apps(); len(got) != 1 || got[0] != "support-bot" {
+		t.Fatalf("Apps() = %v", got)
+	}
+}
+
+// 测试写入过程中读到的不完整配置不会被报告，下一次检查时重新加载
+func TestRegistryReloadRetriesParseFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.yaml")
+	writeConfig(t, path, "apps:\n  support-bot:\n    api_key: app-support\n")
+
+	reloaded := make(chan error, 16)
+	registry, err := NewRegistry(path,
+		WithWatchInterval(100*time.Millisecond),
+		WithReloadHook(func(err error) { reloaded <- err }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+
+	// 模拟写入到一半的文件，停留时间短于检查间隔，最多被读到一次
+	writeConfig(t, path, "apps:\n  sales: [\n")
+	time.Sleep(20 * time.Millisecond)
+	writeConfig(t, path, "apps:\n  sales:\n    api_key: app-sales\n")
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case err := <-reloaded:
+			if err != nil {
+				t.Fatalf("reload error = %v, want retry without error", err)
+			}
+			if _, err := registry.Get("sales"); err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("config was not reloaded")
+		}
+	}
+}
+
+// 测试同一份损坏的配置持续失败时只报告一次错误，并继续使用旧配置
+func TestRegistryReloadReportsPersistentFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.yaml")
+	writeConfig(t, path, "apps:\n  support-bot:\n    api_key: app-support\n")
+
+	reloaded := make(chan error, 16)
+	registry, err := NewRegistry(path,
+		WithWatchInterval(10*time.Millisecond),
+		WithReloadHook(func(err error) { reloaded <- err }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+
+	writeConfig(t, path, "apps:\n  sales: [\n")
+	select {
+	case err := <-reloaded:
+		if err == nil {
+			t.Fatal("expected parse error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("parse error was not reported")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(reloaded); n != 0 {
+		t.Fatalf("reported %d more times, want once", n)
+	}
+	if _, err := registry.Get("support-bot"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeConfig 先写入临时文件再重命名，避免监听方读到写入一半的文件
+func writeConfig(t *testing.T, path, config string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,261 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// apiRequest 描述一次 API 调用，所有请求方法都通过 send 统一发送
+type apiRequest struct {
+	// ctx 请求上下文，为空时使用客户端的上下文
+	ctx         context.Context
+	name        string
+	method      string
+	path        string
+	body        []byte
+	contentType string
+	header      http.Header
+	// family 端点分组，用于熔断统计
+	family EndpointFamily
+	// user 请求所属的终端用户，用于按用户限流
+	user string
+	// stream 是否为流式请求
+	stream bool
+	// conversationID、taskID、messageID 配置节点池时请求会发往创建它们的节点
+	conversationID string
+	taskID         string
+	messageID      string
+	// fileIDs 请求引用的上传文件，配置节点池时请求会发往接收上传的节点
+	fileIDs []string
+
+	// opts 单次请求选项
+	opts []RequestOption
+	// direct 单次请求覆盖了 API Key 或基础 URL，不经过节点池
+	direct bool
+
+	// op 本次调用的描述，由 send 创建并传给中间件
+	op *Operation
+	// member 实际处理请求的节点，未配置节点池时为空
+	member *poolMember
+}
+
+// pinKeys 返回用于节点绑定的会话、任务、消息和文件ID，按优先级排列
+func (r *apiRequest) pinKeys() []string {
+	var keys []string
+	for _, key := range append([]string{r.conversationID, r.taskID, r.messageID}, r.fileIDs...) {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// send 构造请求并依次经过中间件、限流器、熔断器和节点池发送
+//
+// 返回的响应状态码可能不是 200，由调用方按各自的方式处理。
+func (c *Client) send(r *apiRequest) (*http.Response, error) {
+	r.op = &Operation{
+		Name:           r.name,
+		Family:         r.family,
+		Stream:         r.stream,
+		User:           r.user,
+		ConversationID: r.conversationID,
+		TaskID:         r.taskID,
+		MessageID:      r.messageID,
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = c.context()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range r.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if r.contentType != "" {
+		httpReq.Header.Set("Content-Type", r.contentType)
+	}
+
+	middlewares := c.middlewares
+	if len(r.opts) > 0 {
+		r.direct = newRequestConfig(r.opts).overridesTarget()
+		middlewares = append([]Middleware{requestOptionsMiddleware(c.baseURL, r.opts...)}, c.middlewares...)
+	}
+
+	handler := Chain(func(op *Operation, req *http.Request) (*http.Response, error) {
+		return c.limit(r, req)
+	}, middlewares...)
+	return handler(r.op, httpReq)
+}
+
+// limit 配置限流器时先排队等待放行，再发送请求
+//
+// 限流器位于熔断器之外，排队超时和队列已满不会计入熔断统计。
+func (c *Client) limit(r *apiRequest, req *http.Request) (*http.Response, error) {
+	if c.limiter == nil {
+		return c.guard(r, req)
+	}
+
+	release, err := c.limiter.Acquire(req.Context(), r.user, r.stream)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.guard(r, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// guard 配置熔断器时先检查熔断状态
+func (c *Client) guard(r *apiRequest, req *http.Request) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.roundTrip(r, req)
+	}
+
+	done, err := c.breaker.Allow(r.family)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.roundTrip(r, req)
+	done(req.Context(), resp, err)
+	return resp, err
+}
+
+// roundTrip 发送请求，配置节点池时在节点间故障转移
+func (c *Client) roundTrip(r *apiRequest, req *http.Request) (*http.Response, error) {
+	if c.pool == nil || c.pool.Size() == 0 || r.direct {
+		return c.httpClient.Do(req)
+	}
+
+	tried := make(map[*poolMember]bool)
+	for {
+		member, pinned := c.pool.pick(r.pinKeys(), tried)
+		if member == nil {
+			return nil, ErrNoPoolMember
+		}
+		r.member = member
+
+		attempt, err := memberRequest(req, member, c.baseURL, len(tried) > 0)
+		if err != nil {
+			return nil, err
+		}
+		tried[member] = true
+
+		// 绑定的请求不做故障转移，所有节点都尝试过后也不再重试，请求体无法重新生成时同样不重试
+		last := pinned || len(tried) >= c.pool.Size() || (req.Body != nil && req.GetBody == nil)
+
+		resp, err := c.httpClient.Do(attempt)
+		if err != nil {
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			c.pool.markFailure(member)
+			if last {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError || quotaExhausted(resp) {
+			c.pool.markFailure(member)
+			if last {
+				return resp, nil
+			}
+			resp.Body.Close()
+			continue
+		}
+
+		c.pool.markSuccess(member)
+		return resp, nil
+	}
+}
+
+// quotaExhausted 判断 400 响应是否为模型额度用尽或模型不可用，这类错误只与节点的 API Key 有关，可以换节点重试
+//
+// 读取的响应体会放回 resp.Body，调用方仍可完整读取。
+func quotaExhausted(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return false
+	}
+	return body.Code == ErrCodeProviderQuotaExceeded || body.Code == ErrCodeModelNotSupport
+}
+
+// memberRequest 复制请求并替换为节点的基础 URL 和 API Key，retry 为 true 时重新生成请求体
+func memberRequest(req *http.Request, member *poolMember, baseURL string, retry bool) (*http.Request, error) {
+	attempt := req.Clone(req.Context())
+	if retry && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		attempt.Body = body
+	}
+
+	if member.BaseURL != "" {
+		target := member.BaseURL + strings.TrimPrefix(req.URL.String(), baseURL)
+		u, err := req.URL.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		attempt.URL = u
+		attempt.Host = u.Host
+	}
+	if member.APIKey != "" {
+		attempt.Header.Set("Authorization", "Bearer "+member.APIKey)
+	}
+	return attempt, nil
+}
+
+// uploadFileIDs 返回以 local_file 方式引用的上传文件ID
+func uploadFileIDs(files []FileInput) []string {
+	var ids []string
+	for _, f := range files {
+		if f.UploadFileID != "" {
+			ids = append(ids, f.UploadFileID)
+		}
+	}
+	return ids
+}
+
+// pin 将会话、任务、消息或文件ID绑定到处理该请求的节点
+func (c *Client) pin(r *apiRequest, keys ...string) {
+	if c.pool == nil || r.member == nil {
+		return
+	}
+	for _, key := range keys {
+		c.pool.pin(key, r.member)
+	}
+}
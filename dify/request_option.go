@@ -0,0 +1,148 @@
+package dify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderIdempotencyKey 幂等键请求头
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// RequestOption 单次请求选项，只对当前调用生效
+type RequestOption interface {
+	apply(*requestConfig)
+}
+
+// requestOptionFunc 是一个适配器，允许使用普通函数作为 RequestOption
+type requestOptionFunc func(*requestConfig)
+
+func (f requestOptionFunc) apply(c *requestConfig) {
+	f(c)
+}
+
+// requestConfig 单次请求配置
+type requestConfig struct {
+	header     http.Header
+	timeout    time.Duration
+	apiKey     string
+	baseURL    string
+	onResponse []func(*http.Response)
+}
+
+func newRequestConfig(opts []RequestOption) *requestConfig {
+	cfg := &requestConfig{header: make(http.Header)}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+// overridesTarget 是否覆盖了 API Key 或基础 URL，此时请求不经过节点池
+func (c *requestConfig) overridesTarget() bool {
+	return c.apiKey != "" || c.baseURL != ""
+}
+
+// WithRequestHeader 添加请求头，可以多次调用
+func WithRequestHeader(key, value string) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.header.Add(key, value)
+	})
+}
+
+// WithRequestContentType 设置本次请求的 Content-Type，用于 Do/DoStream 发送 []byte 或 io.Reader 请求体，
+// 如 multipart 表单的 writer.FormDataContentType()
+func WithRequestContentType(contentType string) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.header.Set("Content-Type", contentType)
+	})
+}
+
+// WithRequestTimeout 设置本次调用的超时时间，流式请求的超时覆盖整个流
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.timeout = timeout
+	})
+}
+
+// WithRequestAPIKey 使用指定的 API Key 发送本次请求，配置了节点池时不再经过节点池
+func WithRequestAPIKey(apiKey string) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.apiKey = apiKey
+	})
+}
+
+// WithRequestBaseURL 将本次请求发往指定的基础 URL，配置了节点池时不再经过节点池
+func WithRequestBaseURL(baseURL string) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	})
+}
+
+// WithIdempotencyKey 设置 Idempotency-Key 请求头，便于服务端或网关对重试去重
+func WithIdempotencyKey(key string) RequestOption {
+	return WithRequestHeader(HeaderIdempotencyKey, key)
+}
+
+// WithResponseHook 收到响应后调用 fn，可用于读取状态码和 X-Request-Id 等响应头
+//
+// fn 在 SDK 读取响应体之前调用，不应读取或关闭响应体；请求失败没有响应时不会调用。
+func WithResponseHook(fn func(resp *http.Response)) RequestOption {
+	return requestOptionFunc(func(c *requestConfig) {
+		c.onResponse = append(c.onResponse, fn)
+	})
+}
+
+// requestOptionsMiddleware 返回应用单次请求选项的中间件，baseURL 为客户端配置的基础 URL
+//
+// 中间件放在中间件链的最外层，knowledge.Client 通过 internal/transport 复用该中间件。
+func requestOptionsMiddleware(baseURL string, opts ...RequestOption) Middleware {
+	cfg := newRequestConfig(opts)
+	return func(next Handler) Handler {
+		return func(op *Operation, req *http.Request) (*http.Response, error) {
+			if cfg.baseURL != "" && baseURL != "" {
+				target := cfg.baseURL + strings.TrimPrefix(req.URL.String(), baseURL)
+				attempt, err := http.NewRequestWithContext(req.Context(), req.Method, target, req.Body)
+				if err != nil {
+					return nil, err
+				}
+				attempt.Header = req.Header
+				attempt.ContentLength = req.ContentLength
+				attempt.GetBody = req.GetBody
+				req = attempt
+			}
+			for key, values := range cfg.header {
+				req.Header[key] = append([]string(nil), values...)
+			}
+			if cfg.apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+strings.TrimPrefix(cfg.apiKey, "Bearer "))
+			}
+
+			if cfg.timeout <= 0 {
+				return cfg.respond(next(op, req))
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), cfg.timeout)
+			resp, err := cfg.respond(next(op, req.WithContext(ctx)))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// 超时在响应体关闭时释放，流式请求的读取同样受超时约束
+			resp.Body = &closeHookBody{ReadCloser: resp.Body, close: cancel}
+			return resp, nil
+		}
+	}
+}
+
+// respond 收到响应后调用 WithResponseHook 设置的函数
+func (c *requestConfig) respond(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range c.onResponse {
+		fn(resp)
+	}
+	return resp, nil
+}
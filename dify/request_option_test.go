@@ -0,0 +1,76 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 测试单次请求的请求头、幂等键、API Key 和基础 URL 覆盖以及响应钩子
+func TestRequestOptions(t *testing.T) {
+	var got http.Header
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Clone()
+			w.Header().Set("X-Request-Id", name)
+			json.NewEncoder(w).Encode(AppInfo{Name: name})
+		}
+	}
+	primary := httptest.NewServer(handler("primary"))
+	defer primary.Close()
+	other := httptest.NewServer(handler("other"))
+	defer other.Close()
+
+	client := NewClient("key", WithPool(NewPool([]PoolMember{{APIKey: "pool-key", BaseURL: primary.URL}})))
+
+	var requestID string
+	info, err := client.GetAppInfo(
+		WithRequestHeader("X-Trace", "abc"),
+		WithIdempotencyKey("idem-1"),
+		WithResponseHook(func(resp *http.Response) { requestID = resp.Header.Get("X-Request-Id") }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-Trace") != "abc" || got.Get(HeaderIdempotencyKey) != "idem-1" || got.Get("Authorization") != "Bearer pool-key" {
+		t.Fatalf("headers = %v", got)
+	}
+	if info.Name != "primary" || requestID != "primary" {
+		t.Fatalf("info = %q, request id = %q", info.Name, requestID)
+	}
+
+	info, err = client.GetAppInfo(WithRequestBaseURL(other.URL+"/"), WithRequestAPIKey("other-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "other" || got.Get("Authorization") != "Bearer other-key" {
+		t.Fatalf("info = %q, Authorization = %q", info.Name, got.Get("Authorization"))
+	}
+	if got.Get("X-Trace") != "" {
+		t.Fatal("request options leaked into the next call")
+	}
+}
+
+// 测试单次请求超时
+func TestRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		json.NewEncoder(w).Encode(AppParameters{})
+	}))
+	defer srv.Close()
+
+	client := NewClient("key", WithBaseURL(srv.URL))
+	if _, err := client.GetAppParameters(WithRequestTimeout(20 * time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want deadline exceeded", err)
+	}
+	if _, err := client.GetAppParameters(); err != nil {
+		t.Fatal(err)
+	}
+}
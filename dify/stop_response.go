@@ -1,14 +1,13 @@
 package dify
 
 import (
-    "bytes"
     "encoding/json"
     "fmt"
     "net/http"
 )
 
 // StopResponse 停止响应
-func (c *Client) StopResponse(taskID string, user string) error {
+func (c *Client) StopResponse(taskID string, user string, opts ...RequestOption) error {
     data, err := json.Marshal(map[string]string{
         "user": user,
     })
@@ -16,15 +15,17 @@ func (c *Client) StopResponse(taskID string, user string) error {
         return err
     }
 
-    req, err := http.NewRequest("POST", fmt.Sprintf("%s%s/%s/stop", c.BaseURL, EndpointCompletion, taskID), bytes.NewBuffer(data))
-    if err != nil {
-        return err
-    }
-
-    req.Header.Set("Content-Type", "application/json")
-    req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.send(&apiRequest{
+        name:        "StopResponse",
+        opts:        opts,
+        method:      http.MethodPost,
+        path:        fmt.Sprintf("%s/%s/stop", EndpointCompletion, taskID),
+        family:      FamilyCompletion,
+        body:        data,
+        contentType: "application/json",
+        taskID:      taskID,
+        user:        user,
+    })
     if err != nil {
         return err
     }
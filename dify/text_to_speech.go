@@ -1,7 +1,6 @@
 package dify
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,21 +8,23 @@ import (
 )
 
 // TextToSpeech 文字转语音
-func (c *Client) TextToSpeech(request *TTSRequest) ([]byte, error) {
+func (c *Client) TextToSpeech(request *TTSRequest, opts ...RequestOption) ([]byte, error) {
 	data, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.BaseURL+EndpointAudio, bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(&apiRequest{
+		name:        "TextToSpeech",
+		opts:        opts,
+		method:      http.MethodPost,
+		path:        EndpointAudio,
+		family:      FamilyApp,
+		body:        data,
+		contentType: "application/json",
+		messageID:   request.MessageID,
+		user:        request.User,
+	})
 	if err != nil {
 		return nil, err
 	}
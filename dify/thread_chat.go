@@ -0,0 +1,217 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// keyedMutex 按键加锁，同一个键的调用串行执行，不同键之间互不影响
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock 锁定指定键，返回解锁函数
+func (m *keyedMutex) Lock(key string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Unlock()
+
+			m.mu.Lock()
+			l.refs--
+			if l.refs == 0 {
+				delete(m.locks, key)
+			}
+			m.mu.Unlock()
+		})
+	}
+}
+
+// CreateChatForThread 根据外部线程键查找或创建会话，然后发送阻塞模式的聊天请求
+//
+// 同一线程键的并发调用会在会话创建完成前串行等待，保证一个线程只对应一个 Dify 会话。
+// 请求成功后映射会被保存并刷新过期时间，传入的 req 不会被修改。
+//
+// 保存的会话在 Dify 中已不存在时（会话被删除，或配置节点池时进程重启，请求发往了其他节点），
+// 映射会被清除，并在新会话中重新发送本条消息，之前的对话上下文不会保留。
+func (c *Client) CreateChatForThread(store ConversationStore, threadKey string, req *ChatRequest, opts ...RequestOption) (*ChatResponse, error) {
+	ctx := c.context()
+	resp, conversationID, err := c.chatForThread(ctx, store, threadKey, req, opts)
+	if conversationID != "" && conversationNotFound(err) {
+		if err := c.forgetThread(ctx, store, threadKey, conversationID); err != nil {
+			return nil, err
+		}
+		resp, _, err = c.chatForThread(ctx, store, threadKey, req, opts)
+	}
+	return resp, err
+}
+
+// chatForThread 发送一次阻塞模式的聊天请求，返回使用的已有会话ID，新建会话时为空
+func (c *Client) chatForThread(ctx context.Context, store ConversationStore, threadKey string, req *ChatRequest, opts []RequestOption) (*ChatResponse, string, error) {
+	unlock := c.threadLocks.Lock(threadKey)
+	defer unlock()
+
+	conversationID, ok, err := store.Get(ctx, threadKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if ok {
+		// 会话已存在，无需阻塞同一线程的其他消息
+		unlock()
+	}
+
+	r := *req
+	r.ConversationId = conversationID
+
+	resp, err := c.CreateChat(&r, opts...)
+	if err != nil {
+		return nil, conversationID, err
+	}
+
+	if resp.ConversationId != "" {
+		if err := store.Set(ctx, threadKey, resp.ConversationId); err != nil {
+			return resp, conversationID, fmt.Errorf("failed to save conversation: %w", err)
+		}
+	}
+
+	return resp, conversationID, nil
+}
+
+// CreateStreamingChatForThread 根据外部线程键查找或创建会话，然后发送流式模式的聊天请求
+//
+// 新会话的 conversation_id 会在收到第一个携带它的事件时保存，同一线程的后续消息随即可以继续发送。
+// 保存的会话已不存在时与 CreateChatForThread 一样清除映射，并在新会话中重新发送本条消息。
+func (c *Client) CreateStreamingChatForThread(store ConversationStore, threadKey string, req *ChatRequest, handler StreamHandler, opts ...RequestOption) error {
+	ctx := c.context()
+	conversationID, err := c.streamingChatForThread(ctx, store, threadKey, req, handler, opts)
+	if conversationID != "" && conversationNotFound(err) {
+		if err := c.forgetThread(ctx, store, threadKey, conversationID); err != nil {
+			return err
+		}
+		_, err = c.streamingChatForThread(ctx, store, threadKey, req, handler, opts)
+	}
+	return err
+}
+
+// streamingChatForThread 发送一次流式模式的聊天请求，返回使用的已有会话ID，新建会话时为空
+func (c *Client) streamingChatForThread(ctx context.Context, store ConversationStore, threadKey string, req *ChatRequest, handler StreamHandler, opts []RequestOption) (string, error) {
+	unlock := c.threadLocks.Lock(threadKey)
+	defer unlock()
+
+	conversationID, ok, err := store.Get(ctx, threadKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if ok {
+		unlock()
+	}
+
+	r := *req
+	r.ConversationId = conversationID
+
+	h := &threadStreamHandler{
+		StreamHandler: handler,
+		save: func(id string) error {
+			defer unlock()
+			if err := store.Set(ctx, threadKey, id); err != nil {
+				return fmt.Errorf("failed to save conversation: %w", err)
+			}
+			return nil
+		},
+	}
+
+	return conversationID, c.CreateStreamingChat(&r, h, opts...)
+}
+
+// forgetThread 清除指向已失效会话的映射，映射已被其他调用更新时保留新的映射
+func (c *Client) forgetThread(ctx context.Context, store ConversationStore, threadKey, conversationID string) error {
+	unlock := c.threadLocks.Lock(threadKey)
+	defer unlock()
+
+	current, ok, err := store.Get(ctx, threadKey)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if !ok || current != conversationID {
+		return nil
+	}
+	if err := store.Delete(ctx, threadKey); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// conversationNotFound 判断请求是否因为会话不存在而失败
+func conversationNotFound(err error) bool {
+	var difyErr *DifyError
+	return errors.As(err, &difyErr) && difyErr.Status == http.StatusNotFound
+}
+
+// threadStreamHandler 包装 StreamHandler，在首次拿到 conversation_id 时保存映射
+type threadStreamHandler struct {
+	StreamHandler
+	once sync.Once
+	save func(conversationID string) error
+}
+
+func (h *threadStreamHandler) capture(conversationID string) error {
+	if conversationID == "" {
+		return nil
+	}
+	var err error
+	h.once.Do(func() {
+		err = h.save(conversationID)
+	})
+	return err
+}
+
+func (h *threadStreamHandler) OnMessage(response *MessageStreamResponse) error {
+	if err := h.capture(response.ConversationId); err != nil {
+		return err
+	}
+	return h.StreamHandler.OnMessage(response)
+}
+
+func (h *threadStreamHandler) OnMessageWorkflow(response *WorkflowStreamResponse) error {
+	if err := h.capture(response.ConversationId); err != nil {
+		return err
+	}
+	return h.StreamHandler.OnMessageWorkflow(response)
+}
+
+func (h *threadStreamHandler) OnMessageEnd(response *MessageEndStreamResponse) error {
+	if err := h.capture(response.ConversationId); err != nil {
+		return err
+	}
+	return h.StreamHandler.OnMessageEnd(response)
+}
+
+func (h *threadStreamHandler) OnTTS(response *TTSStreamResponse) error {
+	if err := h.capture(response.ConversationId); err != nil {
+		return err
+	}
+	return h.StreamHandler.OnTTS(response)
+}
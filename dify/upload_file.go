@@ -12,7 +12,7 @@ import (
 )
 
 // UploadFile 上传文件
-func (c *Client) UploadFile(filePath string, user string) (*FileUploadResponse, error) {
+func (c *Client) UploadFile(filePath string, user string, opts ...RequestOption) (*FileUploadResponse, error) {
     file, err := os.Open(filePath)
     if err != nil {
         return nil, err
@@ -43,15 +43,17 @@ func (c *Client) UploadFile(filePath string, user string) (*FileUploadResponse,
         return nil, err
     }
 
-    req, err := http.NewRequest("POST", c.BaseURL+EndpointFiles+"/upload", body)
-    if err != nil {
-        return nil, err
+    r := &apiRequest{
+        name:        "UploadFile",
+        opts:        opts,
+        method:      http.MethodPost,
+        path:        EndpointFiles + "/upload",
+        family:      FamilyFiles,
+        body:        body.Bytes(),
+        contentType: writer.FormDataContentType(),
+        user:        user,
     }
-
-    req.Header.Set("Content-Type", writer.FormDataContentType())
-    req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.send(r)
     if err != nil {
         return nil, err
     }
@@ -66,6 +68,8 @@ func (c *Client) UploadFile(filePath string, user string) (*FileUploadResponse,
     if err != nil {
         return nil, err
     }
+    // 配置节点池时，引用该文件的后续请求需要发往接收上传的节点
+    c.pin(r, result.ID)
 
     return &result, nil
 }
@@ -2,7 +2,6 @@ package dify
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,19 +38,23 @@ type WorkflowResponse struct {
 }
 
 // WorkflowRun 执行工作流的方法
-func (c *Client) WorkflowRun(request WorkflowRequest) (*WorkflowResponse, error) {
-	url := fmt.Sprintf("%s%s/run", c.BaseURL, EndpointWorkflows)
+func (c *Client) WorkflowRun(request WorkflowRequest, opts ...RequestOption) (*WorkflowResponse, error) {
 	jsonBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, err
+	r := &apiRequest{
+		name:        "WorkflowRun",
+		opts:        opts,
+		method:      http.MethodPost,
+		path:        EndpointWorkflows + "/run",
+		family:      FamilyWorkflows,
+		body:        jsonBody,
+		contentType: "application/json",
+		fileIDs:     uploadFileIDs(request.Files),
+		user:        request.User,
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(r)
 	if err != nil {
 		return nil, err
 	}
@@ -70,35 +73,44 @@ func (c *Client) WorkflowRun(request WorkflowRequest) (*WorkflowResponse, error)
 	if err != nil {
 		return nil, err
 	}
+	c.pin(r, workflowResp.TaskID, workflowResp.WorkflowRunId)
 
 	return &workflowResp, nil
 }
 
 // WorkflowRunStreaming 执行流式工作流的方法
-func (c *Client) WorkflowRunStreaming(request WorkflowRequest, handler StreamHandler) error {
-	url := fmt.Sprintf("%s%s/run", c.BaseURL, EndpointWorkflows)
+func (c *Client) WorkflowRunStreaming(request WorkflowRequest, handler StreamHandler, opts ...RequestOption) (err error) {
 	jsonBody, err := json.Marshal(request)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	r := &apiRequest{
+		name:        "WorkflowRunStreaming",
+		opts:        opts,
+		method:      http.MethodPost,
+		path:        EndpointWorkflows + "/run",
+		family:      FamilyWorkflows,
+		body:        jsonBody,
+		contentType: "application/json",
+		fileIDs:     uploadFileIDs(request.Files),
+		header: http.Header{
+			"Accept":            {"text/event-stream"},
+			"Connection":        {"keep-alive"},
+			"Cache-Control":     {"no-cache"},
+			"Transfer-Encoding": {"chunked"},
+		},
+		user:   request.User,
+		stream: true,
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "text/event-stream")
-	httpReq.Header.Set("Connection", "keep-alive")
-	httpReq.Header.Set("Cache-Control", "no-cache")
-	httpReq.Header.Set("Transfer-Encoding", "chunked")
-
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.send(r)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	defer func() {
+		r.op.EmitStreamEnd(err)
+	}()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -136,6 +148,8 @@ func (c *Client) WorkflowRunStreaming(request WorkflowRequest, handler StreamHan
 			}
 			continue
 		}
+		r.op.EmitStreamEvent(StreamEvent{Event: baseResp.Event, Data: json.RawMessage(data)})
+		c.pin(r, baseResp.TaskID, baseResp.WorkflowRunId)
 
 		// 根据事件类型处理不同的响应
 		switch baseResp.Event {
@@ -0,0 +1,361 @@
+// Package difycassette 录制真实的 Dify 请求并在测试中回放
+//
+// 录制：
+//
+//	rec := difycassette.NewRecorder("testdata/chat.json", difycassette.WithScrub(os.Getenv("DIFY_API_KEY"), "app-key"))
+//	client := dify.NewClient(apiKey, dify.WithHTTPClient(rec.HTTPClient()))
+//	// ... 调用 API
+//	rec.Save()
+//
+// 回放：
+//
+//	replayer, err := difycassette.Load("testdata/chat.json")
+//	client := dify.NewClient("app-key", dify.WithHTTPClient(replayer.HTTPClient()))
+//
+// 录制文件保存请求、响应以及流式响应中每个 SSE 事件的时间偏移。
+// Authorization 等敏感请求头在保存时隐藏，WithScrub 可以替换请求和响应中的其他敏感内容。
+// 回放时按请求方法、路径、查询参数和规范化后的请求体匹配，基础 URL 的主机部分不参与匹配。
+package difycassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Version 录制文件格式版本
+const Version = 1
+
+// ModeEnv 环境变量，Open 在其值为 record 时录制，否则回放
+const ModeEnv = "DIFY_CASSETTE"
+
+// redacted 敏感请求头的替换值
+const redacted = "[REDACTED]"
+
+// ErrNoInteraction 回放时没有匹配的录制记录
+var ErrNoInteraction = errors.New("difycassette: no matching interaction")
+
+// Cassette 录制文件
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction 一次请求和响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest 录制的请求
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Query      string      `json:"query,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// RecordedResponse 录制的响应，流式响应的内容保存在 Events 中
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+	Events     []Event     `json:"events,omitempty"`
+	// DurationMs 从发出请求到响应结束的毫秒数
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Event 流式响应中的一个 SSE 事件
+type Event struct {
+	// OffsetMs 相对于发出请求的毫秒数
+	OffsetMs int64 `json:"offset_ms"`
+	// Data 事件的原始内容，包含结尾的空行
+	Data string `json:"data"`
+}
+
+// body 返回请求体
+func (r *RecordedRequest) body() []byte {
+	return decodeBody(r.Body, r.BodyBase64)
+}
+
+// body 返回响应体
+func (r *RecordedResponse) body() []byte {
+	if len(r.Events) > 0 {
+		var buf bytes.Buffer
+		for _, ev := range r.Events {
+			buf.WriteString(ev.Data)
+		}
+		return buf.Bytes()
+	}
+	return decodeBody(r.Body, r.BodyBase64)
+}
+
+// ReadFile 读取录制文件
+func ReadFile(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析录制文件失败: %w", err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("不支持的录制文件版本: %d", c.Version)
+	}
+	return &c, nil
+}
+
+// WriteFile 写入录制文件，自动创建目录
+func (c *Cassette) WriteFile(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化录制文件失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建录制目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// Option 录制和回放选项
+type Option func(*config)
+
+type config struct {
+	transport     http.RoundTripper
+	scrubs        []string
+	headers       map[string]bool
+	ignoredFields map[string]bool
+	realtime      bool
+	hooks         []func(*Interaction)
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		transport: http.DefaultTransport,
+		headers: map[string]bool{
+			"Authorization": true,
+			"Cookie":        true,
+			"Set-Cookie":    true,
+			"X-Api-Key":     true,
+		},
+		ignoredFields: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithTransport 设置录制时实际发送请求的 Transport，默认为 http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithScrub 将请求和响应中出现的 secret 替换为 replacement，回放时对收到的请求做同样的替换后再匹配
+func WithScrub(secret, replacement string) Option {
+	return func(c *config) {
+		if secret != "" {
+			c.scrubs = append(c.scrubs, secret, replacement)
+		}
+	}
+}
+
+// WithRedactedHeaders 追加保存时需要隐藏的请求头和响应头，默认隐藏 Authorization、Cookie、Set-Cookie 和 X-Api-Key
+func WithRedactedHeaders(headers ...string) Option {
+	return func(c *config) {
+		for _, h := range headers {
+			c.headers[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// WithIgnoredFields 匹配请求时忽略 JSON 请求体中的指定顶层字段，例如每次运行都不同的 user
+func WithIgnoredFields(fields ...string) Option {
+	return func(c *config) {
+		for _, f := range fields {
+			c.ignoredFields[f] = true
+		}
+	}
+}
+
+// WithRealtime 回放时按录制的时间偏移返回响应和流式事件，默认立即返回
+func WithRealtime() Option {
+	return func(c *config) {
+		c.realtime = true
+	}
+}
+
+// WithHook 在保存每条录制记录前调用，可用于进一步脱敏
+func WithHook(hook func(*Interaction)) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// Transport 录制或回放的 Transport
+type Transport interface {
+	http.RoundTripper
+	// HTTPClient 返回使用该 Transport 的 HTTP 客户端
+	HTTPClient() *http.Client
+	// Close 录制时保存录制文件，回放时无操作
+	Close() error
+}
+
+// Open 环境变量 DIFY_CASSETTE 为 record 时返回录制到 path 的 Recorder，否则加载 path 并返回 Replayer
+func Open(path string, opts ...Option) (Transport, error) {
+	if os.Getenv(ModeEnv) == "record" {
+		return NewRecorder(path, opts...), nil
+	}
+	return Load(path, opts...)
+}
+
+// scrub 替换敏感内容
+func (c *config) scrub(s string) string {
+	for i := 0; i < len(c.scrubs); i += 2 {
+		s = strings.ReplaceAll(s, c.scrubs[i], c.scrubs[i+1])
+	}
+	return s
+}
+
+// scrubBytes 替换敏感内容
+func (c *config) scrubBytes(b []byte) []byte {
+	for i := 0; i < len(c.scrubs); i += 2 {
+		b = bytes.ReplaceAll(b, []byte(c.scrubs[i]), []byte(c.scrubs[i+1]))
+	}
+	return b
+}
+
+// header 复制请求头，隐藏敏感值并替换敏感内容
+func (c *config) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			if c.headers[http.CanonicalHeaderKey(k)] {
+				v = redacted
+			}
+			out.Add(k, c.scrub(v))
+		}
+	}
+	return out
+}
+
+// normalize 返回用于匹配的请求键
+func (c *config) normalize(method, path, query, contentType string, body []byte) string {
+	return method + " " + path + "?" + normalizeQuery(query) + "\n" + c.normalizeBody(contentType, body)
+}
+
+// normalizeBody JSON 请求体按键排序并去掉忽略的字段，multipart 请求体去掉随机的分隔符
+func (c *config) normalizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		if s, err := normalizeMultipart(body, params["boundary"]); err == nil {
+			return s
+		}
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		for f := range c.ignoredFields {
+			delete(m, f)
+		}
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// normalizeQuery 按键排序查询参数
+func normalizeQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
+
+// normalizeMultipart 将 multipart 请求体转换为与分隔符无关的形式
+func normalizeMultipart(body []byte, boundary string) (string, error) {
+	if boundary == "" {
+		return "", errors.New("missing boundary")
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+		if part.FileName() != "" {
+			sum := sha256.Sum256(data)
+			parts = append(parts, fmt.Sprintf("%s=@%s:%s", part.FormName(), part.FileName(), hex.EncodeToString(sum[:])))
+		} else {
+			parts = append(parts, part.FormName()+"="+string(data))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n"), nil
+}
+
+// encodeBody 文本内容原样保存，二进制内容使用 base64
+func encodeBody(b []byte) (text, b64 string) {
+	if len(b) == 0 {
+		return "", ""
+	}
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeBody(text, b64 string) []byte {
+	if b64 != "" {
+		b, err := base64.StdEncoding.DecodeString(b64)
+		if err == nil {
+			return b
+		}
+	}
+	return []byte(text)
+}
+
+// isEventStream 判断是否为 SSE 响应
+func isEventStream(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
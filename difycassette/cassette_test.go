@@ -0,0 +1,176 @@
+package difycassette
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/difytest"
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// 测试录制后在没有服务的情况下回放应用 API 和知识库 API
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cassette.json")
+	upload := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(upload, []byte("hello file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := difytest.NewServer(difytest.WithAPIKey("app-secret"), difytest.WithStreamInterval(5*time.Millisecond))
+	rec := NewRecorder(path, WithScrub("app-secret", "app-key"))
+	recorded := run(t, srv.DifyClient(dify.WithHTTPClient(rec.HTTPClient())),
+		srv.KnowledgeClient(knowledge.WithHTTPClient(rec.HTTPClient())), upload)
+	srv.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "app-secret") || !strings.Contains(string(data), redacted) {
+		t.Fatal("secrets not scrubbed")
+	}
+	c, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	for _, it := range c.Interactions {
+		events += len(it.Response.Events)
+	}
+	if len(c.Interactions) != 6 || events < 3 {
+		t.Fatalf("interactions = %d, events = %d", len(c.Interactions), events)
+	}
+
+	replayer, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const baseURL = "http://replay.invalid"
+	replayed := run(t, dify.NewClient("app-key", dify.WithBaseURL(baseURL), dify.WithHTTPClient(replayer.HTTPClient())),
+		knowledge.NewClient("app-key", knowledge.WithBaseURL(baseURL), knowledge.WithHTTPClient(replayer.HTTPClient())), upload)
+	if replayed != recorded {
+		t.Fatalf("replayed = %q, recorded = %q", replayed, recorded)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Fatalf("unused = %d", len(unused))
+	}
+
+	_, err = dify.NewClient("app-key", dify.WithBaseURL(baseURL), dify.WithHTTPClient(replayer.HTTPClient())).
+		CreateChat(&dify.ChatRequest{Query: "hello", User: "u1"})
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("error = %v, want ErrNoInteraction", err)
+	}
+}
+
+// 测试匹配时忽略字段以及按录制时间回放
+func TestReplayMatching(t *testing.T) {
+	c := &Cassette{Version: Version, Interactions: []Interaction{{
+		Request: RecordedRequest{
+			Method: "POST",
+			Path:   "/chat-messages",
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   `{"inputs":null,"query":"hi","user":"recorded-user","response_mode":"streaming"}`,
+		},
+		Response: RecordedResponse{
+			Status: 200,
+			Header: map[string][]string{"Content-Type": {"text/event-stream"}},
+			Events: []Event{
+				{OffsetMs: 0, Data: "data: {\"event\":\"message\",\"answer\":\"he\"}\n\n"},
+				{OffsetMs: 30, Data: "data: {\"event\":\"message\",\"answer\":\"llo\"}\n\n"},
+			},
+		},
+	}}}
+	replayer := NewReplayer(c, WithIgnoredFields("user"), WithRealtime())
+	client := dify.NewClient("key", dify.WithBaseURL("http://replay.invalid"), dify.WithHTTPClient(replayer.HTTPClient()))
+
+	handler := &answerHandler{}
+	start := time.Now()
+	if err := client.CreateStreamingChat(&dify.ChatRequest{Query: "hi", User: "another-user"}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if handler.answer != "hello" {
+		t.Fatalf("answer = %q", handler.answer)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("elapsed = %v, want >= 30ms", elapsed)
+	}
+}
+
+// run 依次调用各类 API 并返回结果摘要
+func run(t *testing.T, client *dify.Client, kb *knowledge.Client, upload string) string {
+	t.Helper()
+	ctx := context.Background()
+	var out []string
+
+	chat, err := client.CreateChat(&dify.ChatRequest{Query: "hello", User: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = append(out, chat.ConversationId, chat.Answer)
+
+	handler := &answerHandler{}
+	err = client.CreateStreamingChat(&dify.ChatRequest{Query: "tell me more", User: "u1", ConversationId: chat.ConversationId}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = append(out, handler.answer)
+
+	file, err := client.UploadFile(upload, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = append(out, file.ID)
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name:              "faq",
+		Text:              "Go SDK for Dify",
+		IndexingTechnique: "high_quality",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = append(out, ds.ID, result.Document.ID, result.Batch)
+
+	retrieved, err := kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "dify"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range retrieved.Records {
+		out = append(out, record.Segment.Content)
+	}
+	return strings.Join(out, "|")
+}
+
+// answerHandler 拼接流式回答
+type answerHandler struct {
+	answer string
+}
+
+func (h *answerHandler) OnMessage(response *dify.MessageStreamResponse) error {
+	h.answer += response.Answer
+	return nil
+}
+
+func (h *answerHandler) OnMessageWorkflow(response *dify.WorkflowStreamResponse) error { return nil }
+
+func (h *answerHandler) OnMessageEnd(response *dify.MessageEndStreamResponse) error { return nil }
+
+func (h *answerHandler) OnTTS(response *dify.TTSStreamResponse) error { return nil }
+
+func (h *answerHandler) OnTTSEnd(response *dify.TTSStreamResponse) error { return nil }
+
+func (h *answerHandler) OnError(err error) error { return err }
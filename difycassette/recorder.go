@@ -0,0 +1,189 @@
+package difycassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Recorder 通过实际的 Transport 发送请求并记录请求和响应
+type Recorder struct {
+	path string
+	cfg  *config
+
+	mu sync.Mutex
+	// slots 按发出请求的顺序保存录制记录，未完成或失败的请求为 nil
+	slots []*Interaction
+}
+
+// NewRecorder 创建录制到 path 的 Recorder，调用 Save 或 Close 后写入文件
+func NewRecorder(path string, opts ...Option) *Recorder {
+	return &Recorder{path: path, cfg: newConfig(opts)}
+}
+
+// HTTPClient 返回使用 Recorder 的 HTTP 客户端
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions 返回已完成的录制记录
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for _, it := range r.slots {
+		if it != nil {
+			out = append(out, *it)
+		}
+	}
+	return out
+}
+
+// Save 将已完成的录制记录写入文件
+func (r *Recorder) Save() error {
+	c := &Cassette{Version: Version, Interactions: r.Interactions()}
+	if c.Interactions == nil {
+		c.Interactions = []Interaction{}
+	}
+	return c.WriteFile(r.path)
+}
+
+// Close 保存录制文件
+func (r *Recorder) Close() error {
+	return r.Save()
+}
+
+// RoundTrip 发送请求并录制，流式响应在读取完毕或关闭时完成录制
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %w", err)
+		}
+	}
+	out := req.Clone(req.Context())
+	if req.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r.mu.Lock()
+	slot := len(r.slots)
+	r.slots = append(r.slots, nil)
+	r.mu.Unlock()
+
+	start := time.Now()
+	resp, err := r.cfg.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Interaction{Request: r.request(req, body)}
+	it.Response.Status = resp.StatusCode
+	it.Response.Header = r.cfg.header(resp.Header)
+
+	if isEventStream(resp.Header) {
+		resp.Body = &streamRecorder{
+			ReadCloser: resp.Body,
+			start:      start,
+			done: func(events []Event) {
+				for i := range events {
+					events[i].Data = r.cfg.scrub(events[i].Data)
+				}
+				it.Response.Events = events
+				it.Response.DurationMs = time.Since(start).Milliseconds()
+				r.finish(slot, it)
+			},
+		}
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	it.Response.Body, it.Response.BodyBase64 = encodeBody(r.cfg.scrubBytes(data))
+	it.Response.DurationMs = time.Since(start).Milliseconds()
+	r.finish(slot, it)
+	return resp, nil
+}
+
+// request 构造脱敏后的请求记录
+func (r *Recorder) request(req *http.Request, body []byte) RecordedRequest {
+	rec := RecordedRequest{
+		Method: req.Method,
+		Path:   r.cfg.scrub(req.URL.Path),
+		Query:  r.cfg.scrub(req.URL.RawQuery),
+		Header: r.cfg.header(req.Header),
+	}
+	rec.Body, rec.BodyBase64 = encodeBody(r.cfg.scrubBytes(body))
+	return rec
+}
+
+// finish 执行钩子并保存录制记录
+func (r *Recorder) finish(slot int, it *Interaction) {
+	for _, hook := range r.cfg.hooks {
+		hook(it)
+	}
+	r.mu.Lock()
+	r.slots[slot] = it
+	r.mu.Unlock()
+}
+
+// streamRecorder 在读取流式响应的同时按空行切分 SSE 事件并记录时间偏移
+type streamRecorder struct {
+	io.ReadCloser
+	start  time.Time
+	buf    []byte
+	events []Event
+	done   func([]Event)
+	once   sync.Once
+}
+
+func (s *streamRecorder) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if n > 0 {
+		s.buf = append(s.buf, p[:n]...)
+		for {
+			i := bytes.Index(s.buf, []byte("\n\n"))
+			if i < 0 {
+				break
+			}
+			s.add(s.buf[:i+2])
+			s.buf = s.buf[i+2:]
+		}
+	}
+	if err == io.EOF {
+		s.finish()
+	}
+	return n, err
+}
+
+func (s *streamRecorder) Close() error {
+	s.finish()
+	return s.ReadCloser.Close()
+}
+
+func (s *streamRecorder) add(data []byte) {
+	s.events = append(s.events, Event{
+		OffsetMs: time.Since(s.start).Milliseconds(),
+		Data:     string(data),
+	})
+}
+
+func (s *streamRecorder) finish() {
+	s.once.Do(func() {
+		if len(s.buf) > 0 {
+			s.add(s.buf)
+			s.buf = nil
+		}
+		s.done(s.events)
+	})
+}
@@ -0,0 +1,165 @@
+package difycassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Replayer 从录制文件返回响应，不发出任何网络请求
+//
+// 每条录制记录只使用一次，相同的请求依次匹配后续的记录，因此轮询类请求可以按录制顺序返回不同的结果。
+type Replayer struct {
+	cfg          *config
+	interactions []Interaction
+	keys         []string
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// Load 加载录制文件并创建 Replayer
+func Load(path string, opts ...Option) (*Replayer, error) {
+	c, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c, opts...), nil
+}
+
+// NewReplayer 使用录制内容创建 Replayer
+func NewReplayer(c *Cassette, opts ...Option) *Replayer {
+	r := &Replayer{
+		cfg:          newConfig(opts),
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+	for _, it := range c.Interactions {
+		req := it.Request
+		r.keys = append(r.keys, r.cfg.normalize(req.Method, req.Path, req.Query, req.Header.Get("Content-Type"), req.body()))
+	}
+	return r
+}
+
+// HTTPClient 返回使用 Replayer 的 HTTP 客户端
+func (r *Replayer) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Close 无操作，用于实现 Transport
+func (r *Replayer) Close() error {
+	return nil
+}
+
+// Unused 返回尚未被匹配的录制记录
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for i, used := range r.used {
+		if !used {
+			out = append(out, r.interactions[i])
+		}
+	}
+	return out
+}
+
+// RoundTrip 返回第一条匹配且未使用的录制记录的响应，没有匹配时返回 ErrNoInteraction
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %w", err)
+		}
+	}
+	key := r.cfg.normalize(req.Method, r.cfg.scrub(req.URL.Path), r.cfg.scrub(req.URL.RawQuery),
+		req.Header.Get("Content-Type"), r.cfg.scrubBytes(body))
+
+	it, ok := r.match(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.Path)
+	}
+
+	header := it.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	resp := &http.Response{
+		Status:     strconv.Itoa(it.Response.Status) + " " + http.StatusText(it.Response.Status),
+		StatusCode: it.Response.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Request:    req,
+	}
+
+	if !r.cfg.realtime {
+		data := it.Response.body()
+		resp.ContentLength = int64(len(data))
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return resp, nil
+	}
+
+	start := time.Now()
+	if len(it.Response.Events) == 0 {
+		if err := sleepUntil(req, start, it.Response.DurationMs); err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(it.Response.body()))
+		return resp, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		for _, ev := range it.Response.Events {
+			if err := sleepUntil(req, start, ev.OffsetMs); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.WriteString(pw, ev.Data); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	resp.ContentLength = -1
+	resp.Body = pr
+	return resp, nil
+}
+
+// match 查找并标记第一条匹配且未使用的录制记录
+func (r *Replayer) match(key string) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, k := range r.keys {
+		if !r.used[i] && k == key {
+			r.used[i] = true
+			return r.interactions[i], true
+		}
+	}
+	return Interaction{}, false
+}
+
+// sleepUntil 等待到相对于 start 的偏移，请求上下文结束时返回错误
+func sleepUntil(req *http.Request, start time.Time, offsetMs int64) error {
+	wait := time.Until(start.Add(time.Duration(offsetMs) * time.Millisecond))
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
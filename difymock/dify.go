@@ -0,0 +1,418 @@
+package difymock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// ChatterMock dify.Chatter 的模拟实现，未设置 Func 的方法被调用时会 panic
+type ChatterMock struct {
+	// CreateChatFunc 模拟 CreateChat 方法
+	CreateChatFunc func(req *dify.ChatRequest, opts ...dify.RequestOption) (*dify.ChatResponse, error)
+
+	// CreateStreamingChatFunc 模拟 CreateStreamingChat 方法
+	CreateStreamingChatFunc func(req *dify.ChatRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateChat          []ChatterMockCreateChatCall
+		CreateStreamingChat []ChatterMockCreateStreamingChatCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.Chatter = (*ChatterMock)(nil)
+
+// ChatterMockCreateChatCall CreateChat 的一次调用
+type ChatterMockCreateChatCall struct {
+	Req  *dify.ChatRequest
+	Opts []dify.RequestOption
+}
+
+// CreateChat 记录调用并执行 CreateChatFunc
+func (mock *ChatterMock) CreateChat(req *dify.ChatRequest, opts ...dify.RequestOption) (*dify.ChatResponse, error) {
+	if mock.CreateChatFunc == nil {
+		panic("ChatterMock.CreateChatFunc: method is nil but Chatter.CreateChat was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateChat = append(mock.calls.CreateChat, ChatterMockCreateChatCall{Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateChatFunc(req, opts...)
+}
+
+// CreateChatCalls 返回 CreateChat 的所有调用
+func (mock *ChatterMock) CreateChatCalls() []ChatterMockCreateChatCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChatterMockCreateChatCall(nil), mock.calls.CreateChat...)
+}
+
+// ChatterMockCreateStreamingChatCall CreateStreamingChat 的一次调用
+type ChatterMockCreateStreamingChatCall struct {
+	Req     *dify.ChatRequest
+	Handler dify.StreamHandler
+	Opts    []dify.RequestOption
+}
+
+// CreateStreamingChat 记录调用并执行 CreateStreamingChatFunc
+func (mock *ChatterMock) CreateStreamingChat(req *dify.ChatRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error {
+	if mock.CreateStreamingChatFunc == nil {
+		panic("ChatterMock.CreateStreamingChatFunc: method is nil but Chatter.CreateStreamingChat was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateStreamingChat = append(mock.calls.CreateStreamingChat, ChatterMockCreateStreamingChatCall{Req: req, Handler: handler, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateStreamingChatFunc(req, handler, opts...)
+}
+
+// CreateStreamingChatCalls 返回 CreateStreamingChat 的所有调用
+func (mock *ChatterMock) CreateStreamingChatCalls() []ChatterMockCreateStreamingChatCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChatterMockCreateStreamingChatCall(nil), mock.calls.CreateStreamingChat...)
+}
+
+// CompleterMock dify.Completer 的模拟实现，未设置 Func 的方法被调用时会 panic
+type CompleterMock struct {
+	// CreateCompletionFunc 模拟 CreateCompletion 方法
+	CreateCompletionFunc func(req *dify.CompletionRequest, opts ...dify.RequestOption) (*dify.CompletionResponse, error)
+
+	// CreateStreamingCompletionFunc 模拟 CreateStreamingCompletion 方法
+	CreateStreamingCompletionFunc func(req *dify.CompletionRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error
+
+	// StopResponseFunc 模拟 StopResponse 方法
+	StopResponseFunc func(taskID string, user string, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateCompletion          []CompleterMockCreateCompletionCall
+		CreateStreamingCompletion []CompleterMockCreateStreamingCompletionCall
+		StopResponse              []CompleterMockStopResponseCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.Completer = (*CompleterMock)(nil)
+
+// CompleterMockCreateCompletionCall CreateCompletion 的一次调用
+type CompleterMockCreateCompletionCall struct {
+	Req  *dify.CompletionRequest
+	Opts []dify.RequestOption
+}
+
+// CreateCompletion 记录调用并执行 CreateCompletionFunc
+func (mock *CompleterMock) CreateCompletion(req *dify.CompletionRequest, opts ...dify.RequestOption) (*dify.CompletionResponse, error) {
+	if mock.CreateCompletionFunc == nil {
+		panic("CompleterMock.CreateCompletionFunc: method is nil but Completer.CreateCompletion was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateCompletion = append(mock.calls.CreateCompletion, CompleterMockCreateCompletionCall{Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateCompletionFunc(req, opts...)
+}
+
+// CreateCompletionCalls 返回 CreateCompletion 的所有调用
+func (mock *CompleterMock) CreateCompletionCalls() []CompleterMockCreateCompletionCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]CompleterMockCreateCompletionCall(nil), mock.calls.CreateCompletion...)
+}
+
+// CompleterMockCreateStreamingCompletionCall CreateStreamingCompletion 的一次调用
+type CompleterMockCreateStreamingCompletionCall struct {
+	Req     *dify.CompletionRequest
+	Handler dify.StreamHandler
+	Opts    []dify.RequestOption
+}
+
+// CreateStreamingCompletion 记录调用并执行 CreateStreamingCompletionFunc
+func (mock *CompleterMock) CreateStreamingCompletion(req *dify.CompletionRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error {
+	if mock.CreateStreamingCompletionFunc == nil {
+		panic("CompleterMock.CreateStreamingCompletionFunc: method is nil but Completer.CreateStreamingCompletion was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateStreamingCompletion = append(mock.calls.CreateStreamingCompletion, CompleterMockCreateStreamingCompletionCall{Req: req, Handler: handler, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateStreamingCompletionFunc(req, handler, opts...)
+}
+
+// CreateStreamingCompletionCalls 返回 CreateStreamingCompletion 的所有调用
+func (mock *CompleterMock) CreateStreamingCompletionCalls() []CompleterMockCreateStreamingCompletionCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]CompleterMockCreateStreamingCompletionCall(nil), mock.calls.CreateStreamingCompletion...)
+}
+
+// CompleterMockStopResponseCall StopResponse 的一次调用
+type CompleterMockStopResponseCall struct {
+	TaskID string
+	User   string
+	Opts   []dify.RequestOption
+}
+
+// StopResponse 记录调用并执行 StopResponseFunc
+func (mock *CompleterMock) StopResponse(taskID string, user string, opts ...dify.RequestOption) error {
+	if mock.StopResponseFunc == nil {
+		panic("CompleterMock.StopResponseFunc: method is nil but Completer.StopResponse was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.StopResponse = append(mock.calls.StopResponse, CompleterMockStopResponseCall{TaskID: taskID, User: user, Opts: opts})
+	mock.mu.Unlock()
+	return mock.StopResponseFunc(taskID, user, opts...)
+}
+
+// StopResponseCalls 返回 StopResponse 的所有调用
+func (mock *CompleterMock) StopResponseCalls() []CompleterMockStopResponseCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]CompleterMockStopResponseCall(nil), mock.calls.StopResponse...)
+}
+
+// WorkflowRunnerMock dify.WorkflowRunner 的模拟实现，未设置 Func 的方法被调用时会 panic
+type WorkflowRunnerMock struct {
+	// WorkflowRunFunc 模拟 WorkflowRun 方法
+	WorkflowRunFunc func(request dify.WorkflowRequest, opts ...dify.RequestOption) (*dify.WorkflowResponse, error)
+
+	// WorkflowRunStreamingFunc 模拟 WorkflowRunStreaming 方法
+	WorkflowRunStreamingFunc func(request dify.WorkflowRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		WorkflowRun          []WorkflowRunnerMockWorkflowRunCall
+		WorkflowRunStreaming []WorkflowRunnerMockWorkflowRunStreamingCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.WorkflowRunner = (*WorkflowRunnerMock)(nil)
+
+// WorkflowRunnerMockWorkflowRunCall WorkflowRun 的一次调用
+type WorkflowRunnerMockWorkflowRunCall struct {
+	Request dify.WorkflowRequest
+	Opts    []dify.RequestOption
+}
+
+// WorkflowRun 记录调用并执行 WorkflowRunFunc
+func (mock *WorkflowRunnerMock) WorkflowRun(request dify.WorkflowRequest, opts ...dify.RequestOption) (*dify.WorkflowResponse, error) {
+	if mock.WorkflowRunFunc == nil {
+		panic("WorkflowRunnerMock.WorkflowRunFunc: method is nil but WorkflowRunner.WorkflowRun was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.WorkflowRun = append(mock.calls.WorkflowRun, WorkflowRunnerMockWorkflowRunCall{Request: request, Opts: opts})
+	mock.mu.Unlock()
+	return mock.WorkflowRunFunc(request, opts...)
+}
+
+// WorkflowRunCalls 返回 WorkflowRun 的所有调用
+func (mock *WorkflowRunnerMock) WorkflowRunCalls() []WorkflowRunnerMockWorkflowRunCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]WorkflowRunnerMockWorkflowRunCall(nil), mock.calls.WorkflowRun...)
+}
+
+// WorkflowRunnerMockWorkflowRunStreamingCall WorkflowRunStreaming 的一次调用
+type WorkflowRunnerMockWorkflowRunStreamingCall struct {
+	Request dify.WorkflowRequest
+	Handler dify.StreamHandler
+	Opts    []dify.RequestOption
+}
+
+// WorkflowRunStreaming 记录调用并执行 WorkflowRunStreamingFunc
+func (mock *WorkflowRunnerMock) WorkflowRunStreaming(request dify.WorkflowRequest, handler dify.StreamHandler, opts ...dify.RequestOption) error {
+	if mock.WorkflowRunStreamingFunc == nil {
+		panic("WorkflowRunnerMock.WorkflowRunStreamingFunc: method is nil but WorkflowRunner.WorkflowRunStreaming was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.WorkflowRunStreaming = append(mock.calls.WorkflowRunStreaming, WorkflowRunnerMockWorkflowRunStreamingCall{Request: request, Handler: handler, Opts: opts})
+	mock.mu.Unlock()
+	return mock.WorkflowRunStreamingFunc(request, handler, opts...)
+}
+
+// WorkflowRunStreamingCalls 返回 WorkflowRunStreaming 的所有调用
+func (mock *WorkflowRunnerMock) WorkflowRunStreamingCalls() []WorkflowRunnerMockWorkflowRunStreamingCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]WorkflowRunnerMockWorkflowRunStreamingCall(nil), mock.calls.WorkflowRunStreaming...)
+}
+
+// FileUploaderMock dify.FileUploader 的模拟实现，未设置 Func 的方法被调用时会 panic
+type FileUploaderMock struct {
+	// UploadFileFunc 模拟 UploadFile 方法
+	UploadFileFunc func(filePath string, user string, opts ...dify.RequestOption) (*dify.FileUploadResponse, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		UploadFile []FileUploaderMockUploadFileCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.FileUploader = (*FileUploaderMock)(nil)
+
+// FileUploaderMockUploadFileCall UploadFile 的一次调用
+type FileUploaderMockUploadFileCall struct {
+	FilePath string
+	User     string
+	Opts     []dify.RequestOption
+}
+
+// UploadFile 记录调用并执行 UploadFileFunc
+func (mock *FileUploaderMock) UploadFile(filePath string, user string, opts ...dify.RequestOption) (*dify.FileUploadResponse, error) {
+	if mock.UploadFileFunc == nil {
+		panic("FileUploaderMock.UploadFileFunc: method is nil but FileUploader.UploadFile was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UploadFile = append(mock.calls.UploadFile, FileUploaderMockUploadFileCall{FilePath: filePath, User: user, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UploadFileFunc(filePath, user, opts...)
+}
+
+// UploadFileCalls 返回 UploadFile 的所有调用
+func (mock *FileUploaderMock) UploadFileCalls() []FileUploaderMockUploadFileCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]FileUploaderMockUploadFileCall(nil), mock.calls.UploadFile...)
+}
+
+// ConversationManagerMock dify.ConversationManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type ConversationManagerMock struct {
+	// ConversationsDelFunc 模拟 ConversationsDel 方法
+	ConversationsDelFunc func(conversationID string, user string, opts ...dify.RequestOption) error
+
+	// SendFeedbackFunc 模拟 SendFeedback 方法
+	SendFeedbackFunc func(messageID string, feedback *dify.FeedbackRequest, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		ConversationsDel []ConversationManagerMockConversationsDelCall
+		SendFeedback     []ConversationManagerMockSendFeedbackCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.ConversationManager = (*ConversationManagerMock)(nil)
+
+// ConversationManagerMockConversationsDelCall ConversationsDel 的一次调用
+type ConversationManagerMockConversationsDelCall struct {
+	ConversationID string
+	User           string
+	Opts           []dify.RequestOption
+}
+
+// ConversationsDel 记录调用并执行 ConversationsDelFunc
+func (mock *ConversationManagerMock) ConversationsDel(conversationID string, user string, opts ...dify.RequestOption) error {
+	if mock.ConversationsDelFunc == nil {
+		panic("ConversationManagerMock.ConversationsDelFunc: method is nil but ConversationManager.ConversationsDel was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ConversationsDel = append(mock.calls.ConversationsDel, ConversationManagerMockConversationsDelCall{ConversationID: conversationID, User: user, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ConversationsDelFunc(conversationID, user, opts...)
+}
+
+// ConversationsDelCalls 返回 ConversationsDel 的所有调用
+func (mock *ConversationManagerMock) ConversationsDelCalls() []ConversationManagerMockConversationsDelCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ConversationManagerMockConversationsDelCall(nil), mock.calls.ConversationsDel...)
+}
+
+// ConversationManagerMockSendFeedbackCall SendFeedback 的一次调用
+type ConversationManagerMockSendFeedbackCall struct {
+	MessageID string
+	Feedback  *dify.FeedbackRequest
+	Opts      []dify.RequestOption
+}
+
+// SendFeedback 记录调用并执行 SendFeedbackFunc
+func (mock *ConversationManagerMock) SendFeedback(messageID string, feedback *dify.FeedbackRequest, opts ...dify.RequestOption) error {
+	if mock.SendFeedbackFunc == nil {
+		panic("ConversationManagerMock.SendFeedbackFunc: method is nil but ConversationManager.SendFeedback was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.SendFeedback = append(mock.calls.SendFeedback, ConversationManagerMockSendFeedbackCall{MessageID: messageID, Feedback: feedback, Opts: opts})
+	mock.mu.Unlock()
+	return mock.SendFeedbackFunc(messageID, feedback, opts...)
+}
+
+// SendFeedbackCalls 返回 SendFeedback 的所有调用
+func (mock *ConversationManagerMock) SendFeedbackCalls() []ConversationManagerMockSendFeedbackCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ConversationManagerMockSendFeedbackCall(nil), mock.calls.SendFeedback...)
+}
+
+// RawCallerMock dify.RawCaller 的模拟实现，未设置 Func 的方法被调用时会 panic
+type RawCallerMock struct {
+	// DoFunc 模拟 Do 方法
+	DoFunc func(ctx context.Context, method string, path string, body interface{}, out interface{}, opts ...dify.RequestOption) error
+
+	// DoStreamFunc 模拟 DoStream 方法
+	DoStreamFunc func(ctx context.Context, method string, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		Do       []RawCallerMockDoCall
+		DoStream []RawCallerMockDoStreamCall
+	}
+	mu sync.RWMutex
+}
+
+var _ dify.RawCaller = (*RawCallerMock)(nil)
+
+// RawCallerMockDoCall Do 的一次调用
+type RawCallerMockDoCall struct {
+	Ctx    context.Context
+	Method string
+	Path   string
+	Body   interface{}
+	Out    interface{}
+	Opts   []dify.RequestOption
+}
+
+// Do 记录调用并执行 DoFunc
+func (mock *RawCallerMock) Do(ctx context.Context, method string, path string, body interface{}, out interface{}, opts ...dify.RequestOption) error {
+	if mock.DoFunc == nil {
+		panic("RawCallerMock.DoFunc: method is nil but RawCaller.Do was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Do = append(mock.calls.Do, RawCallerMockDoCall{Ctx: ctx, Method: method, Path: path, Body: body, Out: out, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DoFunc(ctx, method, path, body, out, opts...)
+}
+
+// DoCalls 返回 Do 的所有调用
+func (mock *RawCallerMock) DoCalls() []RawCallerMockDoCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]RawCallerMockDoCall(nil), mock.calls.Do...)
+}
+
+// RawCallerMockDoStreamCall DoStream 的一次调用
+type RawCallerMockDoStreamCall struct {
+	Ctx    context.Context
+	Method string
+	Path   string
+	Body   interface{}
+	Opts   []dify.RequestOption
+}
+
+// DoStream 记录调用并执行 DoStreamFunc
+func (mock *RawCallerMock) DoStream(ctx context.Context, method string, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error) {
+	if mock.DoStreamFunc == nil {
+		panic("RawCallerMock.DoStreamFunc: method is nil but RawCaller.DoStream was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DoStream = append(mock.calls.DoStream, RawCallerMockDoStreamCall{Ctx: ctx, Method: method, Path: path, Body: body, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DoStreamFunc(ctx, method, path, body, opts...)
+}
+
+// DoStreamCalls 返回 DoStream 的所有调用
+func (mock *RawCallerMock) DoStreamCalls() []RawCallerMockDoStreamCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]RawCallerMockDoStreamCall(nil), mock.calls.DoStream...)
+}
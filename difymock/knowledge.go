@@ -0,0 +1,1378 @@
+package difymock
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// DatasetManagerMock knowledge.DatasetManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type DatasetManagerMock struct {
+	// CreateKnowledgeFunc 模拟 CreateKnowledge 方法
+	CreateKnowledgeFunc func(ctx context.Context, req *knowledge.CreateKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.Knowledge, error)
+
+	// ListKnowledgeFunc 模拟 ListKnowledge 方法
+	ListKnowledgeFunc func(ctx context.Context, req *knowledge.ListKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.ListKnowledgeResponse, error)
+
+	// DeleteKnowledgeFunc 模拟 DeleteKnowledge 方法
+	DeleteKnowledgeFunc func(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) error
+
+	// GetKnowledgeFunc 模拟 GetKnowledge 方法
+	GetKnowledgeFunc func(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) (*knowledge.Knowledge, error)
+
+	// UpdateKnowledgeFunc 模拟 UpdateKnowledge 方法
+	UpdateKnowledgeFunc func(ctx context.Context, knowledgeID string, req *knowledge.UpdateKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.Knowledge, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateKnowledge []DatasetManagerMockCreateKnowledgeCall
+		ListKnowledge   []DatasetManagerMockListKnowledgeCall
+		DeleteKnowledge []DatasetManagerMockDeleteKnowledgeCall
+		GetKnowledge    []DatasetManagerMockGetKnowledgeCall
+		UpdateKnowledge []DatasetManagerMockUpdateKnowledgeCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.DatasetManager = (*DatasetManagerMock)(nil)
+
+// DatasetManagerMockCreateKnowledgeCall CreateKnowledge 的一次调用
+type DatasetManagerMockCreateKnowledgeCall struct {
+	Ctx  context.Context
+	Req  *knowledge.CreateKnowledgeRequest
+	Opts []dify.RequestOption
+}
+
+// CreateKnowledge 记录调用并执行 CreateKnowledgeFunc
+func (mock *DatasetManagerMock) CreateKnowledge(ctx context.Context, req *knowledge.CreateKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.Knowledge, error) {
+	if mock.CreateKnowledgeFunc == nil {
+		panic("DatasetManagerMock.CreateKnowledgeFunc: method is nil but DatasetManager.CreateKnowledge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateKnowledge = append(mock.calls.CreateKnowledge, DatasetManagerMockCreateKnowledgeCall{Ctx: ctx, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateKnowledgeFunc(ctx, req, opts...)
+}
+
+// CreateKnowledgeCalls 返回 CreateKnowledge 的所有调用
+func (mock *DatasetManagerMock) CreateKnowledgeCalls() []DatasetManagerMockCreateKnowledgeCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DatasetManagerMockCreateKnowledgeCall(nil), mock.calls.CreateKnowledge...)
+}
+
+// DatasetManagerMockListKnowledgeCall ListKnowledge 的一次调用
+type DatasetManagerMockListKnowledgeCall struct {
+	Ctx  context.Context
+	Req  *knowledge.ListKnowledgeRequest
+	Opts []dify.RequestOption
+}
+
+// ListKnowledge 记录调用并执行 ListKnowledgeFunc
+func (mock *DatasetManagerMock) ListKnowledge(ctx context.Context, req *knowledge.ListKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.ListKnowledgeResponse, error) {
+	if mock.ListKnowledgeFunc == nil {
+		panic("DatasetManagerMock.ListKnowledgeFunc: method is nil but DatasetManager.ListKnowledge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListKnowledge = append(mock.calls.ListKnowledge, DatasetManagerMockListKnowledgeCall{Ctx: ctx, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListKnowledgeFunc(ctx, req, opts...)
+}
+
+// ListKnowledgeCalls 返回 ListKnowledge 的所有调用
+func (mock *DatasetManagerMock) ListKnowledgeCalls() []DatasetManagerMockListKnowledgeCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DatasetManagerMockListKnowledgeCall(nil), mock.calls.ListKnowledge...)
+}
+
+// DatasetManagerMockDeleteKnowledgeCall DeleteKnowledge 的一次调用
+type DatasetManagerMockDeleteKnowledgeCall struct {
+	Ctx         context.Context
+	KnowledgeID string
+	Opts        []dify.RequestOption
+}
+
+// DeleteKnowledge 记录调用并执行 DeleteKnowledgeFunc
+func (mock *DatasetManagerMock) DeleteKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) error {
+	if mock.DeleteKnowledgeFunc == nil {
+		panic("DatasetManagerMock.DeleteKnowledgeFunc: method is nil but DatasetManager.DeleteKnowledge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteKnowledge = append(mock.calls.DeleteKnowledge, DatasetManagerMockDeleteKnowledgeCall{Ctx: ctx, KnowledgeID: knowledgeID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteKnowledgeFunc(ctx, knowledgeID, opts...)
+}
+
+// DeleteKnowledgeCalls 返回 DeleteKnowledge 的所有调用
+func (mock *DatasetManagerMock) DeleteKnowledgeCalls() []DatasetManagerMockDeleteKnowledgeCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DatasetManagerMockDeleteKnowledgeCall(nil), mock.calls.DeleteKnowledge...)
+}
+
+// DatasetManagerMockGetKnowledgeCall GetKnowledge 的一次调用
+type DatasetManagerMockGetKnowledgeCall struct {
+	Ctx         context.Context
+	KnowledgeID string
+	Opts        []dify.RequestOption
+}
+
+// GetKnowledge 记录调用并执行 GetKnowledgeFunc
+func (mock *DatasetManagerMock) GetKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) (*knowledge.Knowledge, error) {
+	if mock.GetKnowledgeFunc == nil {
+		panic("DatasetManagerMock.GetKnowledgeFunc: method is nil but DatasetManager.GetKnowledge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetKnowledge = append(mock.calls.GetKnowledge, DatasetManagerMockGetKnowledgeCall{Ctx: ctx, KnowledgeID: knowledgeID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetKnowledgeFunc(ctx, knowledgeID, opts...)
+}
+
+// GetKnowledgeCalls 返回 GetKnowledge 的所有调用
+func (mock *DatasetManagerMock) GetKnowledgeCalls() []DatasetManagerMockGetKnowledgeCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DatasetManagerMockGetKnowledgeCall(nil), mock.calls.GetKnowledge...)
+}
+
+// DatasetManagerMockUpdateKnowledgeCall UpdateKnowledge 的一次调用
+type DatasetManagerMockUpdateKnowledgeCall struct {
+	Ctx         context.Context
+	KnowledgeID string
+	Req         *knowledge.UpdateKnowledgeRequest
+	Opts        []dify.RequestOption
+}
+
+// UpdateKnowledge 记录调用并执行 UpdateKnowledgeFunc
+func (mock *DatasetManagerMock) UpdateKnowledge(ctx context.Context, knowledgeID string, req *knowledge.UpdateKnowledgeRequest, opts ...dify.RequestOption) (*knowledge.Knowledge, error) {
+	if mock.UpdateKnowledgeFunc == nil {
+		panic("DatasetManagerMock.UpdateKnowledgeFunc: method is nil but DatasetManager.UpdateKnowledge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateKnowledge = append(mock.calls.UpdateKnowledge, DatasetManagerMockUpdateKnowledgeCall{Ctx: ctx, KnowledgeID: knowledgeID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateKnowledgeFunc(ctx, knowledgeID, req, opts...)
+}
+
+// UpdateKnowledgeCalls 返回 UpdateKnowledge 的所有调用
+func (mock *DatasetManagerMock) UpdateKnowledgeCalls() []DatasetManagerMockUpdateKnowledgeCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DatasetManagerMockUpdateKnowledgeCall(nil), mock.calls.UpdateKnowledge...)
+}
+
+// DocumentManagerMock knowledge.DocumentManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type DocumentManagerMock struct {
+	// CreateDocumentByTextFunc 模拟 CreateDocumentByText 方法
+	CreateDocumentByTextFunc func(ctx context.Context, datasetID string, req *knowledge.CreateDocumentByTextRequest, opts ...dify.RequestOption) (*knowledge.Result, error)
+
+	// CreateDocumentByFileFunc 模拟 CreateDocumentByFile 方法
+	CreateDocumentByFileFunc func(ctx context.Context, datasetID string, req *knowledge.CreateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*knowledge.Result, error)
+
+	// GetDocumentIndexingStatusFunc 模拟 GetDocumentIndexingStatus 方法
+	GetDocumentIndexingStatusFunc func(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*knowledge.DocumentIndexingStatus, error)
+
+	// GetBatchIndexingStatusFunc 模拟 GetBatchIndexingStatus 方法
+	GetBatchIndexingStatusFunc func(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*knowledge.BatchIndexingStatus, error)
+
+	// UpdateDocumentByTextFunc 模拟 UpdateDocumentByText 方法
+	UpdateDocumentByTextFunc func(ctx context.Context, datasetID string, documentID string, req *knowledge.UpdateDocumentByTextRequest, opts ...dify.RequestOption) (*knowledge.Result, error)
+
+	// UpdateDocumentByFileFunc 模拟 UpdateDocumentByFile 方法
+	UpdateDocumentByFileFunc func(ctx context.Context, datasetID string, documentID string, req *knowledge.UpdateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*knowledge.Result, error)
+
+	// DeleteDocumentFunc 模拟 DeleteDocument 方法
+	DeleteDocumentFunc func(ctx context.Context, datasetID string, documentID string, opts ...dify.RequestOption) error
+
+	// ListDocumentsFunc 模拟 ListDocuments 方法
+	ListDocumentsFunc func(ctx context.Context, datasetID string, req *knowledge.ListDocumentsRequest, opts ...dify.RequestOption) (*knowledge.ListDocumentsResponse, error)
+
+	// GetDocumentFunc 模拟 GetDocument 方法
+	GetDocumentFunc func(ctx context.Context, datasetID string, documentID string, mode knowledge.MetadataMode, opts ...dify.RequestOption) (*knowledge.Document, error)
+
+	// UpdateDocumentsStatusFunc 模拟 UpdateDocumentsStatus 方法
+	UpdateDocumentsStatusFunc func(ctx context.Context, datasetID string, action knowledge.DocumentAction, documentIDs []string, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateDocumentByText      []DocumentManagerMockCreateDocumentByTextCall
+		CreateDocumentByFile      []DocumentManagerMockCreateDocumentByFileCall
+		GetDocumentIndexingStatus []DocumentManagerMockGetDocumentIndexingStatusCall
+		GetBatchIndexingStatus    []DocumentManagerMockGetBatchIndexingStatusCall
+		UpdateDocumentByText      []DocumentManagerMockUpdateDocumentByTextCall
+		UpdateDocumentByFile      []DocumentManagerMockUpdateDocumentByFileCall
+		DeleteDocument            []DocumentManagerMockDeleteDocumentCall
+		ListDocuments             []DocumentManagerMockListDocumentsCall
+		GetDocument               []DocumentManagerMockGetDocumentCall
+		UpdateDocumentsStatus     []DocumentManagerMockUpdateDocumentsStatusCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.DocumentManager = (*DocumentManagerMock)(nil)
+
+// DocumentManagerMockCreateDocumentByTextCall CreateDocumentByText 的一次调用
+type DocumentManagerMockCreateDocumentByTextCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Req       *knowledge.CreateDocumentByTextRequest
+	Opts      []dify.RequestOption
+}
+
+// CreateDocumentByText 记录调用并执行 CreateDocumentByTextFunc
+func (mock *DocumentManagerMock) CreateDocumentByText(ctx context.Context, datasetID string, req *knowledge.CreateDocumentByTextRequest, opts ...dify.RequestOption) (*knowledge.Result, error) {
+	if mock.CreateDocumentByTextFunc == nil {
+		panic("DocumentManagerMock.CreateDocumentByTextFunc: method is nil but DocumentManager.CreateDocumentByText was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateDocumentByText = append(mock.calls.CreateDocumentByText, DocumentManagerMockCreateDocumentByTextCall{Ctx: ctx, DatasetID: datasetID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateDocumentByTextFunc(ctx, datasetID, req, opts...)
+}
+
+// CreateDocumentByTextCalls 返回 CreateDocumentByText 的所有调用
+func (mock *DocumentManagerMock) CreateDocumentByTextCalls() []DocumentManagerMockCreateDocumentByTextCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockCreateDocumentByTextCall(nil), mock.calls.CreateDocumentByText...)
+}
+
+// DocumentManagerMockCreateDocumentByFileCall CreateDocumentByFile 的一次调用
+type DocumentManagerMockCreateDocumentByFileCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Req       *knowledge.CreateDocumentByFileRequest
+	File      io.Reader
+	Opts      []dify.RequestOption
+}
+
+// CreateDocumentByFile 记录调用并执行 CreateDocumentByFileFunc
+func (mock *DocumentManagerMock) CreateDocumentByFile(ctx context.Context, datasetID string, req *knowledge.CreateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*knowledge.Result, error) {
+	if mock.CreateDocumentByFileFunc == nil {
+		panic("DocumentManagerMock.CreateDocumentByFileFunc: method is nil but DocumentManager.CreateDocumentByFile was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateDocumentByFile = append(mock.calls.CreateDocumentByFile, DocumentManagerMockCreateDocumentByFileCall{Ctx: ctx, DatasetID: datasetID, Req: req, File: file, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateDocumentByFileFunc(ctx, datasetID, req, file, opts...)
+}
+
+// CreateDocumentByFileCalls 返回 CreateDocumentByFile 的所有调用
+func (mock *DocumentManagerMock) CreateDocumentByFileCalls() []DocumentManagerMockCreateDocumentByFileCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockCreateDocumentByFileCall(nil), mock.calls.CreateDocumentByFile...)
+}
+
+// DocumentManagerMockGetDocumentIndexingStatusCall GetDocumentIndexingStatus 的一次调用
+type DocumentManagerMockGetDocumentIndexingStatusCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Batch     string
+	Opts      []dify.RequestOption
+}
+
+// GetDocumentIndexingStatus 记录调用并执行 GetDocumentIndexingStatusFunc
+func (mock *DocumentManagerMock) GetDocumentIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*knowledge.DocumentIndexingStatus, error) {
+	if mock.GetDocumentIndexingStatusFunc == nil {
+		panic("DocumentManagerMock.GetDocumentIndexingStatusFunc: method is nil but DocumentManager.GetDocumentIndexingStatus was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetDocumentIndexingStatus = append(mock.calls.GetDocumentIndexingStatus, DocumentManagerMockGetDocumentIndexingStatusCall{Ctx: ctx, DatasetID: datasetID, Batch: batch, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetDocumentIndexingStatusFunc(ctx, datasetID, batch, opts...)
+}
+
+// GetDocumentIndexingStatusCalls 返回 GetDocumentIndexingStatus 的所有调用
+func (mock *DocumentManagerMock) GetDocumentIndexingStatusCalls() []DocumentManagerMockGetDocumentIndexingStatusCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockGetDocumentIndexingStatusCall(nil), mock.calls.GetDocumentIndexingStatus...)
+}
+
+// DocumentManagerMockGetBatchIndexingStatusCall GetBatchIndexingStatus 的一次调用
+type DocumentManagerMockGetBatchIndexingStatusCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Batch     string
+	Opts      []dify.RequestOption
+}
+
+// GetBatchIndexingStatus 记录调用并执行 GetBatchIndexingStatusFunc
+func (mock *DocumentManagerMock) GetBatchIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*knowledge.BatchIndexingStatus, error) {
+	if mock.GetBatchIndexingStatusFunc == nil {
+		panic("DocumentManagerMock.GetBatchIndexingStatusFunc: method is nil but DocumentManager.GetBatchIndexingStatus was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetBatchIndexingStatus = append(mock.calls.GetBatchIndexingStatus, DocumentManagerMockGetBatchIndexingStatusCall{Ctx: ctx, DatasetID: datasetID, Batch: batch, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetBatchIndexingStatusFunc(ctx, datasetID, batch, opts...)
+}
+
+// GetBatchIndexingStatusCalls 返回 GetBatchIndexingStatus 的所有调用
+func (mock *DocumentManagerMock) GetBatchIndexingStatusCalls() []DocumentManagerMockGetBatchIndexingStatusCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockGetBatchIndexingStatusCall(nil), mock.calls.GetBatchIndexingStatus...)
+}
+
+// DocumentManagerMockUpdateDocumentByTextCall UpdateDocumentByText 的一次调用
+type DocumentManagerMockUpdateDocumentByTextCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Req        *knowledge.UpdateDocumentByTextRequest
+	Opts       []dify.RequestOption
+}
+
+// UpdateDocumentByText 记录调用并执行 UpdateDocumentByTextFunc
+func (mock *DocumentManagerMock) UpdateDocumentByText(ctx context.Context, datasetID string, documentID string, req *knowledge.UpdateDocumentByTextRequest, opts ...dify.RequestOption) (*knowledge.Result, error) {
+	if mock.UpdateDocumentByTextFunc == nil {
+		panic("DocumentManagerMock.UpdateDocumentByTextFunc: method is nil but DocumentManager.UpdateDocumentByText was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateDocumentByText = append(mock.calls.UpdateDocumentByText, DocumentManagerMockUpdateDocumentByTextCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateDocumentByTextFunc(ctx, datasetID, documentID, req, opts...)
+}
+
+// UpdateDocumentByTextCalls 返回 UpdateDocumentByText 的所有调用
+func (mock *DocumentManagerMock) UpdateDocumentByTextCalls() []DocumentManagerMockUpdateDocumentByTextCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockUpdateDocumentByTextCall(nil), mock.calls.UpdateDocumentByText...)
+}
+
+// DocumentManagerMockUpdateDocumentByFileCall UpdateDocumentByFile 的一次调用
+type DocumentManagerMockUpdateDocumentByFileCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Req        *knowledge.UpdateDocumentByFileRequest
+	File       io.Reader
+	Opts       []dify.RequestOption
+}
+
+// UpdateDocumentByFile 记录调用并执行 UpdateDocumentByFileFunc
+func (mock *DocumentManagerMock) UpdateDocumentByFile(ctx context.Context, datasetID string, documentID string, req *knowledge.UpdateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*knowledge.Result, error) {
+	if mock.UpdateDocumentByFileFunc == nil {
+		panic("DocumentManagerMock.UpdateDocumentByFileFunc: method is nil but DocumentManager.UpdateDocumentByFile was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateDocumentByFile = append(mock.calls.UpdateDocumentByFile, DocumentManagerMockUpdateDocumentByFileCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Req: req, File: file, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateDocumentByFileFunc(ctx, datasetID, documentID, req, file, opts...)
+}
+
+// UpdateDocumentByFileCalls 返回 UpdateDocumentByFile 的所有调用
+func (mock *DocumentManagerMock) UpdateDocumentByFileCalls() []DocumentManagerMockUpdateDocumentByFileCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockUpdateDocumentByFileCall(nil), mock.calls.UpdateDocumentByFile...)
+}
+
+// DocumentManagerMockDeleteDocumentCall DeleteDocument 的一次调用
+type DocumentManagerMockDeleteDocumentCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Opts       []dify.RequestOption
+}
+
+// DeleteDocument 记录调用并执行 DeleteDocumentFunc
+func (mock *DocumentManagerMock) DeleteDocument(ctx context.Context, datasetID string, documentID string, opts ...dify.RequestOption) error {
+	if mock.DeleteDocumentFunc == nil {
+		panic("DocumentManagerMock.DeleteDocumentFunc: method is nil but DocumentManager.DeleteDocument was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteDocument = append(mock.calls.DeleteDocument, DocumentManagerMockDeleteDocumentCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteDocumentFunc(ctx, datasetID, documentID, opts...)
+}
+
+// DeleteDocumentCalls 返回 DeleteDocument 的所有调用
+func (mock *DocumentManagerMock) DeleteDocumentCalls() []DocumentManagerMockDeleteDocumentCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockDeleteDocumentCall(nil), mock.calls.DeleteDocument...)
+}
+
+// DocumentManagerMockListDocumentsCall ListDocuments 的一次调用
+type DocumentManagerMockListDocumentsCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Req       *knowledge.ListDocumentsRequest
+	Opts      []dify.RequestOption
+}
+
+// ListDocuments 记录调用并执行 ListDocumentsFunc
+func (mock *DocumentManagerMock) ListDocuments(ctx context.Context, datasetID string, req *knowledge.ListDocumentsRequest, opts ...dify.RequestOption) (*knowledge.ListDocumentsResponse, error) {
+	if mock.ListDocumentsFunc == nil {
+		panic("DocumentManagerMock.ListDocumentsFunc: method is nil but DocumentManager.ListDocuments was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListDocuments = append(mock.calls.ListDocuments, DocumentManagerMockListDocumentsCall{Ctx: ctx, DatasetID: datasetID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListDocumentsFunc(ctx, datasetID, req, opts...)
+}
+
+// ListDocumentsCalls 返回 ListDocuments 的所有调用
+func (mock *DocumentManagerMock) ListDocumentsCalls() []DocumentManagerMockListDocumentsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockListDocumentsCall(nil), mock.calls.ListDocuments...)
+}
+
+// DocumentManagerMockGetDocumentCall GetDocument 的一次调用
+type DocumentManagerMockGetDocumentCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Mode       knowledge.MetadataMode
+	Opts       []dify.RequestOption
+}
+
+// GetDocument 记录调用并执行 GetDocumentFunc
+func (mock *DocumentManagerMock) GetDocument(ctx context.Context, datasetID string, documentID string, mode knowledge.MetadataMode, opts ...dify.RequestOption) (*knowledge.Document, error) {
+	if mock.GetDocumentFunc == nil {
+		panic("DocumentManagerMock.GetDocumentFunc: method is nil but DocumentManager.GetDocument was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetDocument = append(mock.calls.GetDocument, DocumentManagerMockGetDocumentCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Mode: mode, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetDocumentFunc(ctx, datasetID, documentID, mode, opts...)
+}
+
+// GetDocumentCalls 返回 GetDocument 的所有调用
+func (mock *DocumentManagerMock) GetDocumentCalls() []DocumentManagerMockGetDocumentCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockGetDocumentCall(nil), mock.calls.GetDocument...)
+}
+
+// DocumentManagerMockUpdateDocumentsStatusCall UpdateDocumentsStatus 的一次调用
+type DocumentManagerMockUpdateDocumentsStatusCall struct {
+	Ctx         context.Context
+	DatasetID   string
+	Action      knowledge.DocumentAction
+	DocumentIDs []string
+	Opts        []dify.RequestOption
+}
+
+// UpdateDocumentsStatus 记录调用并执行 UpdateDocumentsStatusFunc
+func (mock *DocumentManagerMock) UpdateDocumentsStatus(ctx context.Context, datasetID string, action knowledge.DocumentAction, documentIDs []string, opts ...dify.RequestOption) error {
+	if mock.UpdateDocumentsStatusFunc == nil {
+		panic("DocumentManagerMock.UpdateDocumentsStatusFunc: method is nil but DocumentManager.UpdateDocumentsStatus was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateDocumentsStatus = append(mock.calls.UpdateDocumentsStatus, DocumentManagerMockUpdateDocumentsStatusCall{Ctx: ctx, DatasetID: datasetID, Action: action, DocumentIDs: documentIDs, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateDocumentsStatusFunc(ctx, datasetID, action, documentIDs, opts...)
+}
+
+// UpdateDocumentsStatusCalls 返回 UpdateDocumentsStatus 的所有调用
+func (mock *DocumentManagerMock) UpdateDocumentsStatusCalls() []DocumentManagerMockUpdateDocumentsStatusCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]DocumentManagerMockUpdateDocumentsStatusCall(nil), mock.calls.UpdateDocumentsStatus...)
+}
+
+// SegmentManagerMock knowledge.SegmentManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type SegmentManagerMock struct {
+	// ListSegmentsFunc 模拟 ListSegments 方法
+	ListSegmentsFunc func(ctx context.Context, datasetID string, documentID string, req *knowledge.ListSegmentsRequest, opts ...dify.RequestOption) (*knowledge.ListSegmentsResponse, error)
+
+	// CreateSegmentsFunc 模拟 CreateSegments 方法
+	CreateSegmentsFunc func(ctx context.Context, datasetID string, documentID string, req *knowledge.CreateSegmentsRequest, opts ...dify.RequestOption) ([]knowledge.Segment, error)
+
+	// GetSegmentFunc 模拟 GetSegment 方法
+	GetSegmentFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) (*knowledge.Segment, error)
+
+	// UpdateSegmentFunc 模拟 UpdateSegment 方法
+	UpdateSegmentFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, req *knowledge.UpdateSegmentRequest, opts ...dify.RequestOption) (*knowledge.Segment, error)
+
+	// DeleteSegmentFunc 模拟 DeleteSegment 方法
+	DeleteSegmentFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) error
+
+	// SetSegmentsEnabledFunc 模拟 SetSegmentsEnabled 方法
+	SetSegmentsEnabledFunc func(ctx context.Context, datasetID string, documentID string, enabled bool, segmentIDs []string, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		ListSegments       []SegmentManagerMockListSegmentsCall
+		CreateSegments     []SegmentManagerMockCreateSegmentsCall
+		GetSegment         []SegmentManagerMockGetSegmentCall
+		UpdateSegment      []SegmentManagerMockUpdateSegmentCall
+		DeleteSegment      []SegmentManagerMockDeleteSegmentCall
+		SetSegmentsEnabled []SegmentManagerMockSetSegmentsEnabledCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.SegmentManager = (*SegmentManagerMock)(nil)
+
+// SegmentManagerMockListSegmentsCall ListSegments 的一次调用
+type SegmentManagerMockListSegmentsCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Req        *knowledge.ListSegmentsRequest
+	Opts       []dify.RequestOption
+}
+
+// ListSegments 记录调用并执行 ListSegmentsFunc
+func (mock *SegmentManagerMock) ListSegments(ctx context.Context, datasetID string, documentID string, req *knowledge.ListSegmentsRequest, opts ...dify.RequestOption) (*knowledge.ListSegmentsResponse, error) {
+	if mock.ListSegmentsFunc == nil {
+		panic("SegmentManagerMock.ListSegmentsFunc: method is nil but SegmentManager.ListSegments was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListSegments = append(mock.calls.ListSegments, SegmentManagerMockListSegmentsCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListSegmentsFunc(ctx, datasetID, documentID, req, opts...)
+}
+
+// ListSegmentsCalls 返回 ListSegments 的所有调用
+func (mock *SegmentManagerMock) ListSegmentsCalls() []SegmentManagerMockListSegmentsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockListSegmentsCall(nil), mock.calls.ListSegments...)
+}
+
+// SegmentManagerMockCreateSegmentsCall CreateSegments 的一次调用
+type SegmentManagerMockCreateSegmentsCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Req        *knowledge.CreateSegmentsRequest
+	Opts       []dify.RequestOption
+}
+
+// CreateSegments 记录调用并执行 CreateSegmentsFunc
+func (mock *SegmentManagerMock) CreateSegments(ctx context.Context, datasetID string, documentID string, req *knowledge.CreateSegmentsRequest, opts ...dify.RequestOption) ([]knowledge.Segment, error) {
+	if mock.CreateSegmentsFunc == nil {
+		panic("SegmentManagerMock.CreateSegmentsFunc: method is nil but SegmentManager.CreateSegments was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateSegments = append(mock.calls.CreateSegments, SegmentManagerMockCreateSegmentsCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateSegmentsFunc(ctx, datasetID, documentID, req, opts...)
+}
+
+// CreateSegmentsCalls 返回 CreateSegments 的所有调用
+func (mock *SegmentManagerMock) CreateSegmentsCalls() []SegmentManagerMockCreateSegmentsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockCreateSegmentsCall(nil), mock.calls.CreateSegments...)
+}
+
+// SegmentManagerMockGetSegmentCall GetSegment 的一次调用
+type SegmentManagerMockGetSegmentCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	SegmentID  string
+	Opts       []dify.RequestOption
+}
+
+// GetSegment 记录调用并执行 GetSegmentFunc
+func (mock *SegmentManagerMock) GetSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) (*knowledge.Segment, error) {
+	if mock.GetSegmentFunc == nil {
+		panic("SegmentManagerMock.GetSegmentFunc: method is nil but SegmentManager.GetSegment was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetSegment = append(mock.calls.GetSegment, SegmentManagerMockGetSegmentCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetSegmentFunc(ctx, datasetID, documentID, segmentID, opts...)
+}
+
+// GetSegmentCalls 返回 GetSegment 的所有调用
+func (mock *SegmentManagerMock) GetSegmentCalls() []SegmentManagerMockGetSegmentCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockGetSegmentCall(nil), mock.calls.GetSegment...)
+}
+
+// SegmentManagerMockUpdateSegmentCall UpdateSegment 的一次调用
+type SegmentManagerMockUpdateSegmentCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	SegmentID  string
+	Req        *knowledge.UpdateSegmentRequest
+	Opts       []dify.RequestOption
+}
+
+// UpdateSegment 记录调用并执行 UpdateSegmentFunc
+func (mock *SegmentManagerMock) UpdateSegment(ctx context.Context, datasetID string, documentID string, segmentID string, req *knowledge.UpdateSegmentRequest, opts ...dify.RequestOption) (*knowledge.Segment, error) {
+	if mock.UpdateSegmentFunc == nil {
+		panic("SegmentManagerMock.UpdateSegmentFunc: method is nil but SegmentManager.UpdateSegment was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateSegment = append(mock.calls.UpdateSegment, SegmentManagerMockUpdateSegmentCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateSegmentFunc(ctx, datasetID, documentID, segmentID, req, opts...)
+}
+
+// UpdateSegmentCalls 返回 UpdateSegment 的所有调用
+func (mock *SegmentManagerMock) UpdateSegmentCalls() []SegmentManagerMockUpdateSegmentCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockUpdateSegmentCall(nil), mock.calls.UpdateSegment...)
+}
+
+// SegmentManagerMockDeleteSegmentCall DeleteSegment 的一次调用
+type SegmentManagerMockDeleteSegmentCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	SegmentID  string
+	Opts       []dify.RequestOption
+}
+
+// DeleteSegment 记录调用并执行 DeleteSegmentFunc
+func (mock *SegmentManagerMock) DeleteSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) error {
+	if mock.DeleteSegmentFunc == nil {
+		panic("SegmentManagerMock.DeleteSegmentFunc: method is nil but SegmentManager.DeleteSegment was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteSegment = append(mock.calls.DeleteSegment, SegmentManagerMockDeleteSegmentCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteSegmentFunc(ctx, datasetID, documentID, segmentID, opts...)
+}
+
+// DeleteSegmentCalls 返回 DeleteSegment 的所有调用
+func (mock *SegmentManagerMock) DeleteSegmentCalls() []SegmentManagerMockDeleteSegmentCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockDeleteSegmentCall(nil), mock.calls.DeleteSegment...)
+}
+
+// SegmentManagerMockSetSegmentsEnabledCall SetSegmentsEnabled 的一次调用
+type SegmentManagerMockSetSegmentsEnabledCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	Enabled    bool
+	SegmentIDs []string
+	Opts       []dify.RequestOption
+}
+
+// SetSegmentsEnabled 记录调用并执行 SetSegmentsEnabledFunc
+func (mock *SegmentManagerMock) SetSegmentsEnabled(ctx context.Context, datasetID string, documentID string, enabled bool, segmentIDs []string, opts ...dify.RequestOption) error {
+	if mock.SetSegmentsEnabledFunc == nil {
+		panic("SegmentManagerMock.SetSegmentsEnabledFunc: method is nil but SegmentManager.SetSegmentsEnabled was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.SetSegmentsEnabled = append(mock.calls.SetSegmentsEnabled, SegmentManagerMockSetSegmentsEnabledCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, Enabled: enabled, SegmentIDs: segmentIDs, Opts: opts})
+	mock.mu.Unlock()
+	return mock.SetSegmentsEnabledFunc(ctx, datasetID, documentID, enabled, segmentIDs, opts...)
+}
+
+// SetSegmentsEnabledCalls 返回 SetSegmentsEnabled 的所有调用
+func (mock *SegmentManagerMock) SetSegmentsEnabledCalls() []SegmentManagerMockSetSegmentsEnabledCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]SegmentManagerMockSetSegmentsEnabledCall(nil), mock.calls.SetSegmentsEnabled...)
+}
+
+// ChildChunkManagerMock knowledge.ChildChunkManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type ChildChunkManagerMock struct {
+	// ListChildChunksFunc 模拟 ListChildChunks 方法
+	ListChildChunksFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, req *knowledge.ListChildChunksRequest, opts ...dify.RequestOption) (*knowledge.ListChildChunksResponse, error)
+
+	// CreateChildChunkFunc 模拟 CreateChildChunk 方法
+	CreateChildChunkFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, content string, opts ...dify.RequestOption) (*knowledge.ChildChunk, error)
+
+	// UpdateChildChunkFunc 模拟 UpdateChildChunk 方法
+	UpdateChildChunkFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, content string, opts ...dify.RequestOption) (*knowledge.ChildChunk, error)
+
+	// DeleteChildChunkFunc 模拟 DeleteChildChunk 方法
+	DeleteChildChunkFunc func(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		ListChildChunks  []ChildChunkManagerMockListChildChunksCall
+		CreateChildChunk []ChildChunkManagerMockCreateChildChunkCall
+		UpdateChildChunk []ChildChunkManagerMockUpdateChildChunkCall
+		DeleteChildChunk []ChildChunkManagerMockDeleteChildChunkCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.ChildChunkManager = (*ChildChunkManagerMock)(nil)
+
+// ChildChunkManagerMockListChildChunksCall ListChildChunks 的一次调用
+type ChildChunkManagerMockListChildChunksCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	SegmentID  string
+	Req        *knowledge.ListChildChunksRequest
+	Opts       []dify.RequestOption
+}
+
+// ListChildChunks 记录调用并执行 ListChildChunksFunc
+func (mock *ChildChunkManagerMock) ListChildChunks(ctx context.Context, datasetID string, documentID string, segmentID string, req *knowledge.ListChildChunksRequest, opts ...dify.RequestOption) (*knowledge.ListChildChunksResponse, error) {
+	if mock.ListChildChunksFunc == nil {
+		panic("ChildChunkManagerMock.ListChildChunksFunc: method is nil but ChildChunkManager.ListChildChunks was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListChildChunks = append(mock.calls.ListChildChunks, ChildChunkManagerMockListChildChunksCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListChildChunksFunc(ctx, datasetID, documentID, segmentID, req, opts...)
+}
+
+// ListChildChunksCalls 返回 ListChildChunks 的所有调用
+func (mock *ChildChunkManagerMock) ListChildChunksCalls() []ChildChunkManagerMockListChildChunksCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChildChunkManagerMockListChildChunksCall(nil), mock.calls.ListChildChunks...)
+}
+
+// ChildChunkManagerMockCreateChildChunkCall CreateChildChunk 的一次调用
+type ChildChunkManagerMockCreateChildChunkCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	DocumentID string
+	SegmentID  string
+	Content    string
+	Opts       []dify.RequestOption
+}
+
+// CreateChildChunk 记录调用并执行 CreateChildChunkFunc
+func (mock *ChildChunkManagerMock) CreateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, content string, opts ...dify.RequestOption) (*knowledge.ChildChunk, error) {
+	if mock.CreateChildChunkFunc == nil {
+		panic("ChildChunkManagerMock.CreateChildChunkFunc: method is nil but ChildChunkManager.CreateChildChunk was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateChildChunk = append(mock.calls.CreateChildChunk, ChildChunkManagerMockCreateChildChunkCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, Content: content, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateChildChunkFunc(ctx, datasetID, documentID, segmentID, content, opts...)
+}
+
+// CreateChildChunkCalls 返回 CreateChildChunk 的所有调用
+func (mock *ChildChunkManagerMock) CreateChildChunkCalls() []ChildChunkManagerMockCreateChildChunkCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChildChunkManagerMockCreateChildChunkCall(nil), mock.calls.CreateChildChunk...)
+}
+
+// ChildChunkManagerMockUpdateChildChunkCall UpdateChildChunk 的一次调用
+type ChildChunkManagerMockUpdateChildChunkCall struct {
+	Ctx          context.Context
+	DatasetID    string
+	DocumentID   string
+	SegmentID    string
+	ChildChunkID string
+	Content      string
+	Opts         []dify.RequestOption
+}
+
+// UpdateChildChunk 记录调用并执行 UpdateChildChunkFunc
+func (mock *ChildChunkManagerMock) UpdateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, content string, opts ...dify.RequestOption) (*knowledge.ChildChunk, error) {
+	if mock.UpdateChildChunkFunc == nil {
+		panic("ChildChunkManagerMock.UpdateChildChunkFunc: method is nil but ChildChunkManager.UpdateChildChunk was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateChildChunk = append(mock.calls.UpdateChildChunk, ChildChunkManagerMockUpdateChildChunkCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, ChildChunkID: childChunkID, Content: content, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateChildChunkFunc(ctx, datasetID, documentID, segmentID, childChunkID, content, opts...)
+}
+
+// UpdateChildChunkCalls 返回 UpdateChildChunk 的所有调用
+func (mock *ChildChunkManagerMock) UpdateChildChunkCalls() []ChildChunkManagerMockUpdateChildChunkCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChildChunkManagerMockUpdateChildChunkCall(nil), mock.calls.UpdateChildChunk...)
+}
+
+// ChildChunkManagerMockDeleteChildChunkCall DeleteChildChunk 的一次调用
+type ChildChunkManagerMockDeleteChildChunkCall struct {
+	Ctx          context.Context
+	DatasetID    string
+	DocumentID   string
+	SegmentID    string
+	ChildChunkID string
+	Opts         []dify.RequestOption
+}
+
+// DeleteChildChunk 记录调用并执行 DeleteChildChunkFunc
+func (mock *ChildChunkManagerMock) DeleteChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, opts ...dify.RequestOption) error {
+	if mock.DeleteChildChunkFunc == nil {
+		panic("ChildChunkManagerMock.DeleteChildChunkFunc: method is nil but ChildChunkManager.DeleteChildChunk was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteChildChunk = append(mock.calls.DeleteChildChunk, ChildChunkManagerMockDeleteChildChunkCall{Ctx: ctx, DatasetID: datasetID, DocumentID: documentID, SegmentID: segmentID, ChildChunkID: childChunkID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteChildChunkFunc(ctx, datasetID, documentID, segmentID, childChunkID, opts...)
+}
+
+// DeleteChildChunkCalls 返回 DeleteChildChunk 的所有调用
+func (mock *ChildChunkManagerMock) DeleteChildChunkCalls() []ChildChunkManagerMockDeleteChildChunkCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]ChildChunkManagerMockDeleteChildChunkCall(nil), mock.calls.DeleteChildChunk...)
+}
+
+// MetadataManagerMock knowledge.MetadataManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type MetadataManagerMock struct {
+	// CreateMetadataFieldFunc 模拟 CreateMetadataField 方法
+	CreateMetadataFieldFunc func(ctx context.Context, datasetID string, name string, typ knowledge.MetadataType, opts ...dify.RequestOption) (*knowledge.MetadataField, error)
+
+	// ListMetadataFieldsFunc 模拟 ListMetadataFields 方法
+	ListMetadataFieldsFunc func(ctx context.Context, datasetID string, opts ...dify.RequestOption) (*knowledge.ListMetadataFieldsResponse, error)
+
+	// RenameMetadataFieldFunc 模拟 RenameMetadataField 方法
+	RenameMetadataFieldFunc func(ctx context.Context, datasetID string, metadataID string, name string, opts ...dify.RequestOption) (*knowledge.MetadataField, error)
+
+	// DeleteMetadataFieldFunc 模拟 DeleteMetadataField 方法
+	DeleteMetadataFieldFunc func(ctx context.Context, datasetID string, metadataID string, opts ...dify.RequestOption) error
+
+	// SetBuiltInMetadataFunc 模拟 SetBuiltInMetadata 方法
+	SetBuiltInMetadataFunc func(ctx context.Context, datasetID string, enabled bool, opts ...dify.RequestOption) error
+
+	// UpdateDocumentsMetadataFunc 模拟 UpdateDocumentsMetadata 方法
+	UpdateDocumentsMetadataFunc func(ctx context.Context, datasetID string, updates []knowledge.DocumentMetadataUpdate, opts ...dify.RequestOption) error
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateMetadataField     []MetadataManagerMockCreateMetadataFieldCall
+		ListMetadataFields      []MetadataManagerMockListMetadataFieldsCall
+		RenameMetadataField     []MetadataManagerMockRenameMetadataFieldCall
+		DeleteMetadataField     []MetadataManagerMockDeleteMetadataFieldCall
+		SetBuiltInMetadata      []MetadataManagerMockSetBuiltInMetadataCall
+		UpdateDocumentsMetadata []MetadataManagerMockUpdateDocumentsMetadataCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.MetadataManager = (*MetadataManagerMock)(nil)
+
+// MetadataManagerMockCreateMetadataFieldCall CreateMetadataField 的一次调用
+type MetadataManagerMockCreateMetadataFieldCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Name      string
+	Typ       knowledge.MetadataType
+	Opts      []dify.RequestOption
+}
+
+// CreateMetadataField 记录调用并执行 CreateMetadataFieldFunc
+func (mock *MetadataManagerMock) CreateMetadataField(ctx context.Context, datasetID string, name string, typ knowledge.MetadataType, opts ...dify.RequestOption) (*knowledge.MetadataField, error) {
+	if mock.CreateMetadataFieldFunc == nil {
+		panic("MetadataManagerMock.CreateMetadataFieldFunc: method is nil but MetadataManager.CreateMetadataField was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateMetadataField = append(mock.calls.CreateMetadataField, MetadataManagerMockCreateMetadataFieldCall{Ctx: ctx, DatasetID: datasetID, Name: name, Typ: typ, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateMetadataFieldFunc(ctx, datasetID, name, typ, opts...)
+}
+
+// CreateMetadataFieldCalls 返回 CreateMetadataField 的所有调用
+func (mock *MetadataManagerMock) CreateMetadataFieldCalls() []MetadataManagerMockCreateMetadataFieldCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockCreateMetadataFieldCall(nil), mock.calls.CreateMetadataField...)
+}
+
+// MetadataManagerMockListMetadataFieldsCall ListMetadataFields 的一次调用
+type MetadataManagerMockListMetadataFieldsCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Opts      []dify.RequestOption
+}
+
+// ListMetadataFields 记录调用并执行 ListMetadataFieldsFunc
+func (mock *MetadataManagerMock) ListMetadataFields(ctx context.Context, datasetID string, opts ...dify.RequestOption) (*knowledge.ListMetadataFieldsResponse, error) {
+	if mock.ListMetadataFieldsFunc == nil {
+		panic("MetadataManagerMock.ListMetadataFieldsFunc: method is nil but MetadataManager.ListMetadataFields was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListMetadataFields = append(mock.calls.ListMetadataFields, MetadataManagerMockListMetadataFieldsCall{Ctx: ctx, DatasetID: datasetID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListMetadataFieldsFunc(ctx, datasetID, opts...)
+}
+
+// ListMetadataFieldsCalls 返回 ListMetadataFields 的所有调用
+func (mock *MetadataManagerMock) ListMetadataFieldsCalls() []MetadataManagerMockListMetadataFieldsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockListMetadataFieldsCall(nil), mock.calls.ListMetadataFields...)
+}
+
+// MetadataManagerMockRenameMetadataFieldCall RenameMetadataField 的一次调用
+type MetadataManagerMockRenameMetadataFieldCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	MetadataID string
+	Name       string
+	Opts       []dify.RequestOption
+}
+
+// RenameMetadataField 记录调用并执行 RenameMetadataFieldFunc
+func (mock *MetadataManagerMock) RenameMetadataField(ctx context.Context, datasetID string, metadataID string, name string, opts ...dify.RequestOption) (*knowledge.MetadataField, error) {
+	if mock.RenameMetadataFieldFunc == nil {
+		panic("MetadataManagerMock.RenameMetadataFieldFunc: method is nil but MetadataManager.RenameMetadataField was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.RenameMetadataField = append(mock.calls.RenameMetadataField, MetadataManagerMockRenameMetadataFieldCall{Ctx: ctx, DatasetID: datasetID, MetadataID: metadataID, Name: name, Opts: opts})
+	mock.mu.Unlock()
+	return mock.RenameMetadataFieldFunc(ctx, datasetID, metadataID, name, opts...)
+}
+
+// RenameMetadataFieldCalls 返回 RenameMetadataField 的所有调用
+func (mock *MetadataManagerMock) RenameMetadataFieldCalls() []MetadataManagerMockRenameMetadataFieldCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockRenameMetadataFieldCall(nil), mock.calls.RenameMetadataField...)
+}
+
+// MetadataManagerMockDeleteMetadataFieldCall DeleteMetadataField 的一次调用
+type MetadataManagerMockDeleteMetadataFieldCall struct {
+	Ctx        context.Context
+	DatasetID  string
+	MetadataID string
+	Opts       []dify.RequestOption
+}
+
+// DeleteMetadataField 记录调用并执行 DeleteMetadataFieldFunc
+func (mock *MetadataManagerMock) DeleteMetadataField(ctx context.Context, datasetID string, metadataID string, opts ...dify.RequestOption) error {
+	if mock.DeleteMetadataFieldFunc == nil {
+		panic("MetadataManagerMock.DeleteMetadataFieldFunc: method is nil but MetadataManager.DeleteMetadataField was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteMetadataField = append(mock.calls.DeleteMetadataField, MetadataManagerMockDeleteMetadataFieldCall{Ctx: ctx, DatasetID: datasetID, MetadataID: metadataID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteMetadataFieldFunc(ctx, datasetID, metadataID, opts...)
+}
+
+// DeleteMetadataFieldCalls 返回 DeleteMetadataField 的所有调用
+func (mock *MetadataManagerMock) DeleteMetadataFieldCalls() []MetadataManagerMockDeleteMetadataFieldCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockDeleteMetadataFieldCall(nil), mock.calls.DeleteMetadataField...)
+}
+
+// MetadataManagerMockSetBuiltInMetadataCall SetBuiltInMetadata 的一次调用
+type MetadataManagerMockSetBuiltInMetadataCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Enabled   bool
+	Opts      []dify.RequestOption
+}
+
+// SetBuiltInMetadata 记录调用并执行 SetBuiltInMetadataFunc
+func (mock *MetadataManagerMock) SetBuiltInMetadata(ctx context.Context, datasetID string, enabled bool, opts ...dify.RequestOption) error {
+	if mock.SetBuiltInMetadataFunc == nil {
+		panic("MetadataManagerMock.SetBuiltInMetadataFunc: method is nil but MetadataManager.SetBuiltInMetadata was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.SetBuiltInMetadata = append(mock.calls.SetBuiltInMetadata, MetadataManagerMockSetBuiltInMetadataCall{Ctx: ctx, DatasetID: datasetID, Enabled: enabled, Opts: opts})
+	mock.mu.Unlock()
+	return mock.SetBuiltInMetadataFunc(ctx, datasetID, enabled, opts...)
+}
+
+// SetBuiltInMetadataCalls 返回 SetBuiltInMetadata 的所有调用
+func (mock *MetadataManagerMock) SetBuiltInMetadataCalls() []MetadataManagerMockSetBuiltInMetadataCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockSetBuiltInMetadataCall(nil), mock.calls.SetBuiltInMetadata...)
+}
+
+// MetadataManagerMockUpdateDocumentsMetadataCall UpdateDocumentsMetadata 的一次调用
+type MetadataManagerMockUpdateDocumentsMetadataCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Updates   []knowledge.DocumentMetadataUpdate
+	Opts      []dify.RequestOption
+}
+
+// UpdateDocumentsMetadata 记录调用并执行 UpdateDocumentsMetadataFunc
+func (mock *MetadataManagerMock) UpdateDocumentsMetadata(ctx context.Context, datasetID string, updates []knowledge.DocumentMetadataUpdate, opts ...dify.RequestOption) error {
+	if mock.UpdateDocumentsMetadataFunc == nil {
+		panic("MetadataManagerMock.UpdateDocumentsMetadataFunc: method is nil but MetadataManager.UpdateDocumentsMetadata was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateDocumentsMetadata = append(mock.calls.UpdateDocumentsMetadata, MetadataManagerMockUpdateDocumentsMetadataCall{Ctx: ctx, DatasetID: datasetID, Updates: updates, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UpdateDocumentsMetadataFunc(ctx, datasetID, updates, opts...)
+}
+
+// UpdateDocumentsMetadataCalls 返回 UpdateDocumentsMetadata 的所有调用
+func (mock *MetadataManagerMock) UpdateDocumentsMetadataCalls() []MetadataManagerMockUpdateDocumentsMetadataCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]MetadataManagerMockUpdateDocumentsMetadataCall(nil), mock.calls.UpdateDocumentsMetadata...)
+}
+
+// TagManagerMock knowledge.TagManager 的模拟实现，未设置 Func 的方法被调用时会 panic
+type TagManagerMock struct {
+	// CreateKnowledgeTagFunc 模拟 CreateKnowledgeTag 方法
+	CreateKnowledgeTagFunc func(ctx context.Context, name string, opts ...dify.RequestOption) (*knowledge.Tag, error)
+
+	// ListKnowledgeTagsFunc 模拟 ListKnowledgeTags 方法
+	ListKnowledgeTagsFunc func(ctx context.Context, opts ...dify.RequestOption) ([]knowledge.Tag, error)
+
+	// RenameKnowledgeTagFunc 模拟 RenameKnowledgeTag 方法
+	RenameKnowledgeTagFunc func(ctx context.Context, tagID string, name string, opts ...dify.RequestOption) (*knowledge.Tag, error)
+
+	// DeleteKnowledgeTagFunc 模拟 DeleteKnowledgeTag 方法
+	DeleteKnowledgeTagFunc func(ctx context.Context, tagID string, opts ...dify.RequestOption) error
+
+	// BindKnowledgeTagsFunc 模拟 BindKnowledgeTags 方法
+	BindKnowledgeTagsFunc func(ctx context.Context, datasetID string, tagIDs []string, opts ...dify.RequestOption) error
+
+	// UnbindKnowledgeTagFunc 模拟 UnbindKnowledgeTag 方法
+	UnbindKnowledgeTagFunc func(ctx context.Context, datasetID string, tagID string, opts ...dify.RequestOption) error
+
+	// GetKnowledgeTagsFunc 模拟 GetKnowledgeTags 方法
+	GetKnowledgeTagsFunc func(ctx context.Context, datasetID string, opts ...dify.RequestOption) ([]knowledge.Tag, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		CreateKnowledgeTag []TagManagerMockCreateKnowledgeTagCall
+		ListKnowledgeTags  []TagManagerMockListKnowledgeTagsCall
+		RenameKnowledgeTag []TagManagerMockRenameKnowledgeTagCall
+		DeleteKnowledgeTag []TagManagerMockDeleteKnowledgeTagCall
+		BindKnowledgeTags  []TagManagerMockBindKnowledgeTagsCall
+		UnbindKnowledgeTag []TagManagerMockUnbindKnowledgeTagCall
+		GetKnowledgeTags   []TagManagerMockGetKnowledgeTagsCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.TagManager = (*TagManagerMock)(nil)
+
+// TagManagerMockCreateKnowledgeTagCall CreateKnowledgeTag 的一次调用
+type TagManagerMockCreateKnowledgeTagCall struct {
+	Ctx  context.Context
+	Name string
+	Opts []dify.RequestOption
+}
+
+// CreateKnowledgeTag 记录调用并执行 CreateKnowledgeTagFunc
+func (mock *TagManagerMock) CreateKnowledgeTag(ctx context.Context, name string, opts ...dify.RequestOption) (*knowledge.Tag, error) {
+	if mock.CreateKnowledgeTagFunc == nil {
+		panic("TagManagerMock.CreateKnowledgeTagFunc: method is nil but TagManager.CreateKnowledgeTag was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateKnowledgeTag = append(mock.calls.CreateKnowledgeTag, TagManagerMockCreateKnowledgeTagCall{Ctx: ctx, Name: name, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CreateKnowledgeTagFunc(ctx, name, opts...)
+}
+
+// CreateKnowledgeTagCalls 返回 CreateKnowledgeTag 的所有调用
+func (mock *TagManagerMock) CreateKnowledgeTagCalls() []TagManagerMockCreateKnowledgeTagCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockCreateKnowledgeTagCall(nil), mock.calls.CreateKnowledgeTag...)
+}
+
+// TagManagerMockListKnowledgeTagsCall ListKnowledgeTags 的一次调用
+type TagManagerMockListKnowledgeTagsCall struct {
+	Ctx  context.Context
+	Opts []dify.RequestOption
+}
+
+// ListKnowledgeTags 记录调用并执行 ListKnowledgeTagsFunc
+func (mock *TagManagerMock) ListKnowledgeTags(ctx context.Context, opts ...dify.RequestOption) ([]knowledge.Tag, error) {
+	if mock.ListKnowledgeTagsFunc == nil {
+		panic("TagManagerMock.ListKnowledgeTagsFunc: method is nil but TagManager.ListKnowledgeTags was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListKnowledgeTags = append(mock.calls.ListKnowledgeTags, TagManagerMockListKnowledgeTagsCall{Ctx: ctx, Opts: opts})
+	mock.mu.Unlock()
+	return mock.ListKnowledgeTagsFunc(ctx, opts...)
+}
+
+// ListKnowledgeTagsCalls 返回 ListKnowledgeTags 的所有调用
+func (mock *TagManagerMock) ListKnowledgeTagsCalls() []TagManagerMockListKnowledgeTagsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockListKnowledgeTagsCall(nil), mock.calls.ListKnowledgeTags...)
+}
+
+// TagManagerMockRenameKnowledgeTagCall RenameKnowledgeTag 的一次调用
+type TagManagerMockRenameKnowledgeTagCall struct {
+	Ctx   context.Context
+	TagID string
+	Name  string
+	Opts  []dify.RequestOption
+}
+
+// RenameKnowledgeTag 记录调用并执行 RenameKnowledgeTagFunc
+func (mock *TagManagerMock) RenameKnowledgeTag(ctx context.Context, tagID string, name string, opts ...dify.RequestOption) (*knowledge.Tag, error) {
+	if mock.RenameKnowledgeTagFunc == nil {
+		panic("TagManagerMock.RenameKnowledgeTagFunc: method is nil but TagManager.RenameKnowledgeTag was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.RenameKnowledgeTag = append(mock.calls.RenameKnowledgeTag, TagManagerMockRenameKnowledgeTagCall{Ctx: ctx, TagID: tagID, Name: name, Opts: opts})
+	mock.mu.Unlock()
+	return mock.RenameKnowledgeTagFunc(ctx, tagID, name, opts...)
+}
+
+// RenameKnowledgeTagCalls 返回 RenameKnowledgeTag 的所有调用
+func (mock *TagManagerMock) RenameKnowledgeTagCalls() []TagManagerMockRenameKnowledgeTagCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockRenameKnowledgeTagCall(nil), mock.calls.RenameKnowledgeTag...)
+}
+
+// TagManagerMockDeleteKnowledgeTagCall DeleteKnowledgeTag 的一次调用
+type TagManagerMockDeleteKnowledgeTagCall struct {
+	Ctx   context.Context
+	TagID string
+	Opts  []dify.RequestOption
+}
+
+// DeleteKnowledgeTag 记录调用并执行 DeleteKnowledgeTagFunc
+func (mock *TagManagerMock) DeleteKnowledgeTag(ctx context.Context, tagID string, opts ...dify.RequestOption) error {
+	if mock.DeleteKnowledgeTagFunc == nil {
+		panic("TagManagerMock.DeleteKnowledgeTagFunc: method is nil but TagManager.DeleteKnowledgeTag was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteKnowledgeTag = append(mock.calls.DeleteKnowledgeTag, TagManagerMockDeleteKnowledgeTagCall{Ctx: ctx, TagID: tagID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DeleteKnowledgeTagFunc(ctx, tagID, opts...)
+}
+
+// DeleteKnowledgeTagCalls 返回 DeleteKnowledgeTag 的所有调用
+func (mock *TagManagerMock) DeleteKnowledgeTagCalls() []TagManagerMockDeleteKnowledgeTagCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockDeleteKnowledgeTagCall(nil), mock.calls.DeleteKnowledgeTag...)
+}
+
+// TagManagerMockBindKnowledgeTagsCall BindKnowledgeTags 的一次调用
+type TagManagerMockBindKnowledgeTagsCall struct {
+	Ctx       context.Context
+	DatasetID string
+	TagIDs    []string
+	Opts      []dify.RequestOption
+}
+
+// BindKnowledgeTags 记录调用并执行 BindKnowledgeTagsFunc
+func (mock *TagManagerMock) BindKnowledgeTags(ctx context.Context, datasetID string, tagIDs []string, opts ...dify.RequestOption) error {
+	if mock.BindKnowledgeTagsFunc == nil {
+		panic("TagManagerMock.BindKnowledgeTagsFunc: method is nil but TagManager.BindKnowledgeTags was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.BindKnowledgeTags = append(mock.calls.BindKnowledgeTags, TagManagerMockBindKnowledgeTagsCall{Ctx: ctx, DatasetID: datasetID, TagIDs: tagIDs, Opts: opts})
+	mock.mu.Unlock()
+	return mock.BindKnowledgeTagsFunc(ctx, datasetID, tagIDs, opts...)
+}
+
+// BindKnowledgeTagsCalls 返回 BindKnowledgeTags 的所有调用
+func (mock *TagManagerMock) BindKnowledgeTagsCalls() []TagManagerMockBindKnowledgeTagsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockBindKnowledgeTagsCall(nil), mock.calls.BindKnowledgeTags...)
+}
+
+// TagManagerMockUnbindKnowledgeTagCall UnbindKnowledgeTag 的一次调用
+type TagManagerMockUnbindKnowledgeTagCall struct {
+	Ctx       context.Context
+	DatasetID string
+	TagID     string
+	Opts      []dify.RequestOption
+}
+
+// UnbindKnowledgeTag 记录调用并执行 UnbindKnowledgeTagFunc
+func (mock *TagManagerMock) UnbindKnowledgeTag(ctx context.Context, datasetID string, tagID string, opts ...dify.RequestOption) error {
+	if mock.UnbindKnowledgeTagFunc == nil {
+		panic("TagManagerMock.UnbindKnowledgeTagFunc: method is nil but TagManager.UnbindKnowledgeTag was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UnbindKnowledgeTag = append(mock.calls.UnbindKnowledgeTag, TagManagerMockUnbindKnowledgeTagCall{Ctx: ctx, DatasetID: datasetID, TagID: tagID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.UnbindKnowledgeTagFunc(ctx, datasetID, tagID, opts...)
+}
+
+// UnbindKnowledgeTagCalls 返回 UnbindKnowledgeTag 的所有调用
+func (mock *TagManagerMock) UnbindKnowledgeTagCalls() []TagManagerMockUnbindKnowledgeTagCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockUnbindKnowledgeTagCall(nil), mock.calls.UnbindKnowledgeTag...)
+}
+
+// TagManagerMockGetKnowledgeTagsCall GetKnowledgeTags 的一次调用
+type TagManagerMockGetKnowledgeTagsCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Opts      []dify.RequestOption
+}
+
+// GetKnowledgeTags 记录调用并执行 GetKnowledgeTagsFunc
+func (mock *TagManagerMock) GetKnowledgeTags(ctx context.Context, datasetID string, opts ...dify.RequestOption) ([]knowledge.Tag, error) {
+	if mock.GetKnowledgeTagsFunc == nil {
+		panic("TagManagerMock.GetKnowledgeTagsFunc: method is nil but TagManager.GetKnowledgeTags was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetKnowledgeTags = append(mock.calls.GetKnowledgeTags, TagManagerMockGetKnowledgeTagsCall{Ctx: ctx, DatasetID: datasetID, Opts: opts})
+	mock.mu.Unlock()
+	return mock.GetKnowledgeTagsFunc(ctx, datasetID, opts...)
+}
+
+// GetKnowledgeTagsCalls 返回 GetKnowledgeTags 的所有调用
+func (mock *TagManagerMock) GetKnowledgeTagsCalls() []TagManagerMockGetKnowledgeTagsCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]TagManagerMockGetKnowledgeTagsCall(nil), mock.calls.GetKnowledgeTags...)
+}
+
+// RetrieverMock knowledge.Retriever 的模拟实现，未设置 Func 的方法被调用时会 panic
+type RetrieverMock struct {
+	// RetrieveFunc 模拟 Retrieve 方法
+	RetrieveFunc func(ctx context.Context, datasetID string, req *knowledge.RetrieveRequest, opts ...dify.RequestOption) (*knowledge.RetrieveResponse, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		Retrieve []RetrieverMockRetrieveCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.Retriever = (*RetrieverMock)(nil)
+
+// RetrieverMockRetrieveCall Retrieve 的一次调用
+type RetrieverMockRetrieveCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Req       *knowledge.RetrieveRequest
+	Opts      []dify.RequestOption
+}
+
+// Retrieve 记录调用并执行 RetrieveFunc
+func (mock *RetrieverMock) Retrieve(ctx context.Context, datasetID string, req *knowledge.RetrieveRequest, opts ...dify.RequestOption) (*knowledge.RetrieveResponse, error) {
+	if mock.RetrieveFunc == nil {
+		panic("RetrieverMock.RetrieveFunc: method is nil but Retriever.Retrieve was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Retrieve = append(mock.calls.Retrieve, RetrieverMockRetrieveCall{Ctx: ctx, DatasetID: datasetID, Req: req, Opts: opts})
+	mock.mu.Unlock()
+	return mock.RetrieveFunc(ctx, datasetID, req, opts...)
+}
+
+// RetrieveCalls 返回 Retrieve 的所有调用
+func (mock *RetrieverMock) RetrieveCalls() []RetrieverMockRetrieveCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]RetrieverMockRetrieveCall(nil), mock.calls.Retrieve...)
+}
+
+// IndexingWaiterMock knowledge.IndexingWaiter 的模拟实现，未设置 Func 的方法被调用时会 panic
+type IndexingWaiterMock struct {
+	// WaitForIndexingFunc 模拟 WaitForIndexing 方法
+	WaitForIndexingFunc func(ctx context.Context, datasetID string, batch string, opts ...knowledge.WaitOption) ([]knowledge.DocumentIndexingStatus, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		WaitForIndexing []IndexingWaiterMockWaitForIndexingCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.IndexingWaiter = (*IndexingWaiterMock)(nil)
+
+// IndexingWaiterMockWaitForIndexingCall WaitForIndexing 的一次调用
+type IndexingWaiterMockWaitForIndexingCall struct {
+	Ctx       context.Context
+	DatasetID string
+	Batch     string
+	Opts      []knowledge.WaitOption
+}
+
+// WaitForIndexing 记录调用并执行 WaitForIndexingFunc
+func (mock *IndexingWaiterMock) WaitForIndexing(ctx context.Context, datasetID string, batch string, opts ...knowledge.WaitOption) ([]knowledge.DocumentIndexingStatus, error) {
+	if mock.WaitForIndexingFunc == nil {
+		panic("IndexingWaiterMock.WaitForIndexingFunc: method is nil but IndexingWaiter.WaitForIndexing was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.WaitForIndexing = append(mock.calls.WaitForIndexing, IndexingWaiterMockWaitForIndexingCall{Ctx: ctx, DatasetID: datasetID, Batch: batch, Opts: opts})
+	mock.mu.Unlock()
+	return mock.WaitForIndexingFunc(ctx, datasetID, batch, opts...)
+}
+
+// WaitForIndexingCalls 返回 WaitForIndexing 的所有调用
+func (mock *IndexingWaiterMock) WaitForIndexingCalls() []IndexingWaiterMockWaitForIndexingCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]IndexingWaiterMockWaitForIndexingCall(nil), mock.calls.WaitForIndexing...)
+}
+
+// KnowledgeRawCallerMock knowledge.RawCaller 的模拟实现，未设置 Func 的方法被调用时会 panic
+type KnowledgeRawCallerMock struct {
+	// DoFunc 模拟 Do 方法
+	DoFunc func(ctx context.Context, method string, path string, body interface{}, out interface{}, opts ...dify.RequestOption) error
+
+	// DoStreamFunc 模拟 DoStream 方法
+	DoStreamFunc func(ctx context.Context, method string, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error)
+
+	// calls 记录每个方法的调用参数
+	calls struct {
+		Do       []KnowledgeRawCallerMockDoCall
+		DoStream []KnowledgeRawCallerMockDoStreamCall
+	}
+	mu sync.RWMutex
+}
+
+var _ knowledge.RawCaller = (*KnowledgeRawCallerMock)(nil)
+
+// KnowledgeRawCallerMockDoCall Do 的一次调用
+type KnowledgeRawCallerMockDoCall struct {
+	Ctx    context.Context
+	Method string
+	Path   string
+	Body   interface{}
+	Out    interface{}
+	Opts   []dify.RequestOption
+}
+
+// Do 记录调用并执行 DoFunc
+func (mock *KnowledgeRawCallerMock) Do(ctx context.Context, method string, path string, body interface{}, out interface{}, opts ...dify.RequestOption) error {
+	if mock.DoFunc == nil {
+		panic("KnowledgeRawCallerMock.DoFunc: method is nil but RawCaller.Do was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Do = append(mock.calls.Do, KnowledgeRawCallerMockDoCall{Ctx: ctx, Method: method, Path: path, Body: body, Out: out, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DoFunc(ctx, method, path, body, out, opts...)
+}
+
+// DoCalls 返回 Do 的所有调用
+func (mock *KnowledgeRawCallerMock) DoCalls() []KnowledgeRawCallerMockDoCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]KnowledgeRawCallerMockDoCall(nil), mock.calls.Do...)
+}
+
+// KnowledgeRawCallerMockDoStreamCall DoStream 的一次调用
+type KnowledgeRawCallerMockDoStreamCall struct {
+	Ctx    context.Context
+	Method string
+	Path   string
+	Body   interface{}
+	Opts   []dify.RequestOption
+}
+
+// DoStream 记录调用并执行 DoStreamFunc
+func (mock *KnowledgeRawCallerMock) DoStream(ctx context.Context, method string, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error) {
+	if mock.DoStreamFunc == nil {
+		panic("KnowledgeRawCallerMock.DoStreamFunc: method is nil but RawCaller.DoStream was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DoStream = append(mock.calls.DoStream, KnowledgeRawCallerMockDoStreamCall{Ctx: ctx, Method: method, Path: path, Body: body, Opts: opts})
+	mock.mu.Unlock()
+	return mock.DoStreamFunc(ctx, method, path, body, opts...)
+}
+
+// DoStreamCalls 返回 DoStream 的所有调用
+func (mock *KnowledgeRawCallerMock) DoStreamCalls() []KnowledgeRawCallerMockDoStreamCall {
+	mock.mu.RLock()
+	defer mock.mu.RUnlock()
+	return append([]KnowledgeRawCallerMockDoStreamCall(nil), mock.calls.DoStream...)
+}
@@ -0,0 +1,77 @@
+package difymock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// 测试模拟实现记录调用参数并返回 Func 的结果
+func TestMockRecordsCalls(t *testing.T) {
+	chatter := &ChatterMock{
+		CreateChatFunc: func(req *dify.ChatRequest, opts ...dify.RequestOption) (*dify.ChatResponse, error) {
+			return &dify.ChatResponse{Answer: "hi " + req.User}, nil
+		},
+	}
+	var c dify.Chatter = chatter
+	resp, err := c.CreateChat(&dify.ChatRequest{Query: "hello", User: "u1"})
+	if err != nil || resp.Answer != "hi u1" {
+		t.Fatalf("resp = %+v, err = %v", resp, err)
+	}
+	if calls := chatter.CreateChatCalls(); len(calls) != 1 || calls[0].Req.Query != "hello" {
+		t.Fatalf("calls = %+v", calls)
+	}
+
+	retriever := &RetrieverMock{
+		RetrieveFunc: func(ctx context.Context, datasetID string, req *knowledge.RetrieveRequest, opts ...dify.RequestOption) (*knowledge.RetrieveResponse, error) {
+			return &knowledge.RetrieveResponse{}, nil
+		},
+	}
+	var r knowledge.Retriever = retriever
+	r.Retrieve(context.Background(), "ds-1", &knowledge.RetrieveRequest{Query: "go"})
+	if calls := retriever.RetrieveCalls(); len(calls) != 1 || calls[0].DatasetID != "ds-1" {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
+
+// 测试未设置 Func 时 panic
+func TestMockPanicsWithoutFunc(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	(&DocumentManagerMock{}).DeleteDocument(context.Background(), "ds", "doc")
+}
+
+// 测试 API 聚合接口中的原始调用和索引等待同样可以替换为模拟实现
+func TestMockRawAndWait(t *testing.T) {
+	raw := &RawCallerMock{
+		DoFunc: func(ctx context.Context, method, path string, body, out interface{}, opts ...dify.RequestOption) error {
+			return nil
+		},
+	}
+	var caller dify.RawCaller = raw
+	caller.Do(context.Background(), "GET", "/site", nil, nil)
+	if calls := raw.DoCalls(); len(calls) != 1 || calls[0].Path != "/site" {
+		t.Fatalf("calls = %+v", calls)
+	}
+
+	waiter := &IndexingWaiterMock{
+		WaitForIndexingFunc: func(ctx context.Context, datasetID, batch string, opts ...knowledge.WaitOption) ([]knowledge.DocumentIndexingStatus, error) {
+			return []knowledge.DocumentIndexingStatus{{ID: "doc", IndexingStatus: knowledge.IndexingStatusCompleted}}, nil
+		},
+	}
+	var w knowledge.IndexingWaiter = waiter
+	statuses, err := w.WaitForIndexing(context.Background(), "ds", "batch", knowledge.WithPollInterval(time.Millisecond))
+	if err != nil || len(statuses) != 1 {
+		t.Fatalf("statuses = %+v, err = %v", statuses, err)
+	}
+	if calls := waiter.WaitForIndexingCalls(); len(calls) != 1 || calls[0].Batch != "batch" || len(calls[0].Opts) != 1 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	var _ knowledge.RawCaller = &KnowledgeRawCallerMock{}
+}
@@ -0,0 +1,366 @@
+// Package difyotel 为 dify.Client 和 knowledge.Client 提供 OpenTelemetry 链路追踪中间件
+//
+//	mw := difyotel.Middleware(difyotel.WithAppName("support-bot"))
+//	client := dify.NewClient(apiKey, dify.WithMiddleware(mw))
+//	kb := knowledge.NewClient(datasetKey, knowledge.WithMiddleware(mw))
+//
+// 每次 API 调用生成一个 client span，流式调用记录首个 token 和 message_end 事件，
+// 工作流的 node_started/node_finished 事件会还原为子 span。
+package difyotel
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 追踪器名称
+const instrumentationName = "github.com/hb1707/dify-go-sdk/difyotel"
+
+// maxCapturedBody 阻塞响应中用于提取属性的最大字节数
+const maxCapturedBody = 1 << 20
+
+// span 属性名
+const (
+	AttrEndpoint         = attribute.Key("dify.endpoint")
+	AttrFamily           = attribute.Key("dify.family")
+	AttrApp              = attribute.Key("dify.app")
+	AttrUserHash         = attribute.Key("dify.user.hash")
+	AttrStream           = attribute.Key("dify.stream")
+	AttrConversationID   = attribute.Key("dify.conversation_id")
+	AttrTaskID           = attribute.Key("dify.task_id")
+	AttrMessageID        = attribute.Key("dify.message_id")
+	AttrWorkflowRunID    = attribute.Key("dify.workflow_run_id")
+	AttrDatasetID        = attribute.Key("dify.dataset_id")
+	AttrDocumentID       = attribute.Key("dify.document_id")
+	AttrErrorCode        = attribute.Key("dify.error_code")
+	AttrPromptTokens     = attribute.Key("dify.usage.prompt_tokens")
+	AttrCompletionTokens = attribute.Key("dify.usage.completion_tokens")
+	AttrTotalTokens      = attribute.Key("dify.usage.total_tokens")
+	AttrTimeToFirstToken = attribute.Key("dify.time_to_first_token_ms")
+	AttrNodeID           = attribute.Key("dify.node.id")
+	AttrNodeType         = attribute.Key("dify.node.type")
+	AttrNodeTitle        = attribute.Key("dify.node.title")
+	AttrNodeIndex        = attribute.Key("dify.node.index")
+	AttrNodeStatus       = attribute.Key("dify.node.status")
+	AttrHTTPMethod       = attribute.Key("http.request.method")
+	AttrHTTPStatusCode   = attribute.Key("http.response.status_code")
+)
+
+// config 中间件配置
+type config struct {
+	provider    trace.TracerProvider
+	propagators propagation.TextMapPropagator
+	app         string
+}
+
+// Option 中间件选项
+type Option func(*config)
+
+// WithTracerProvider 设置 TracerProvider，默认使用 otel.GetTracerProvider()
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithPropagators 设置注入请求头的传播器，默认使用 otel.GetTextMapPropagator()
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = propagators
+	}
+}
+
+// WithAppName 设置写入 dify.app 属性的应用名
+func WithAppName(app string) Option {
+	return func(c *config) {
+		c.app = app
+	}
+}
+
+// Middleware 创建链路追踪中间件
+func Middleware(opts ...Option) dify.Middleware {
+	cfg := &config{
+		provider:    otel.GetTracerProvider(),
+		propagators: otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	tracer := cfg.provider.Tracer(instrumentationName)
+
+	return func(next dify.Handler) dify.Handler {
+		return func(op *dify.Operation, req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(req.Context(), "dify."+op.Name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(operationAttributes(cfg, op, req)...),
+			)
+			req = req.WithContext(ctx)
+			cfg.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			s := &callSpan{
+				ctx:    ctx,
+				span:   span,
+				tracer: tracer,
+				start:  time.Now(),
+				nodes:  make(map[string]trace.Span),
+			}
+			if op.Stream {
+				op.OnStreamEvent(s.event)
+				op.OnStreamEnd(s.end)
+			}
+
+			resp, err := next(op, req)
+			if err != nil {
+				s.end(err)
+				return nil, err
+			}
+
+			span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+			}
+
+			capture := !op.Stream || resp.StatusCode != http.StatusOK
+			resp.Body = &spanBody{ReadCloser: resp.Body, span: s, capture: capture}
+			return resp, nil
+		}
+	}
+}
+
+// operationAttributes 根据 Operation 生成 span 的初始属性
+func operationAttributes(cfg *config, op *dify.Operation, req *http.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		AttrEndpoint.String(op.Name),
+		AttrFamily.String(string(op.Family)),
+		AttrStream.Bool(op.Stream),
+		AttrHTTPMethod.String(req.Method),
+	}
+	if cfg.app != "" {
+		attrs = append(attrs, AttrApp.String(cfg.app))
+	}
+	if op.User != "" {
+		attrs = append(attrs, AttrUserHash.String(hashUser(op.User)))
+	}
+	optional := []struct {
+		key   attribute.Key
+		value string
+	}{
+		{AttrConversationID, op.ConversationID},
+		{AttrTaskID, op.TaskID},
+		{AttrMessageID, op.MessageID},
+		{AttrDatasetID, op.DatasetID},
+		{AttrDocumentID, op.DocumentID},
+	}
+	for _, o := range optional {
+		if o.value != "" {
+			attrs = append(attrs, o.key.String(o.value))
+		}
+	}
+	return attrs
+}
+
+// hashUser 对 User 做哈希，避免在链路中记录原始用户标识
+func hashUser(user string) string {
+	sum := sha256.Sum256([]byte(user))
+	return hex.EncodeToString(sum[:8])
+}
+
+// callSpan 一次 API 调用的 span 状态
+type callSpan struct {
+	ctx    context.Context
+	span   trace.Span
+	tracer trace.Tracer
+	start  time.Time
+
+	firstToken bool
+	ids        bool
+	nodes      map[string]trace.Span
+	once       sync.Once
+}
+
+// streamPayload 流式事件和阻塞响应中用于提取属性的字段
+type streamPayload struct {
+	Event          string `json:"event"`
+	TaskID         string `json:"task_id"`
+	ConversationID string `json:"conversation_id"`
+	MessageID      string `json:"message_id"`
+	WorkflowRunID  string `json:"workflow_run_id"`
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	Metadata       struct {
+		Usage *dify.Usage `json:"usage"`
+	} `json:"metadata"`
+	Data struct {
+		ID                string  `json:"id"`
+		NodeID            string  `json:"node_id"`
+		NodeType          string  `json:"node_type"`
+		Title             string  `json:"title"`
+		Index             int     `json:"index"`
+		Status            string  `json:"status"`
+		Error             string  `json:"error"`
+		ElapsedTime       float64 `json:"elapsed_time"`
+		TotalTokens       int     `json:"total_tokens"`
+		ExecutionMetadata struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"execution_metadata"`
+	} `json:"data"`
+}
+
+// event 处理一个流式事件
+func (s *callSpan) event(ev dify.StreamEvent) {
+	var p streamPayload
+	if err := json.Unmarshal(ev.Data, &p); err != nil {
+		return
+	}
+	s.identifiers(&p)
+
+	switch ev.Event {
+	case "message", "agent_message", "text_chunk":
+		if !s.firstToken {
+			s.firstToken = true
+			ttft := time.Since(s.start)
+			s.span.AddEvent("first_token")
+			s.span.SetAttributes(AttrTimeToFirstToken.Int64(ttft.Milliseconds()))
+		}
+	case "message_end":
+		s.span.AddEvent("message_end")
+		s.usage(p.Metadata.Usage)
+	case "workflow_finished":
+		s.span.AddEvent("workflow_finished")
+		if p.Data.TotalTokens > 0 {
+			s.span.SetAttributes(AttrTotalTokens.Int(p.Data.TotalTokens))
+		}
+		if p.Data.Error != "" {
+			s.span.SetStatus(codes.Error, p.Data.Error)
+		}
+	case "node_started":
+		_, node := s.tracer.Start(s.ctx, "dify.node "+p.Data.Title,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(
+				AttrNodeID.String(p.Data.NodeID),
+				AttrNodeType.String(p.Data.NodeType),
+				AttrNodeTitle.String(p.Data.Title),
+				AttrNodeIndex.Int(p.Data.Index),
+			),
+		)
+		s.nodes[p.Data.ID] = node
+	case "node_finished":
+		node, ok := s.nodes[p.Data.ID]
+		if !ok {
+			return
+		}
+		delete(s.nodes, p.Data.ID)
+		node.SetAttributes(AttrNodeStatus.String(p.Data.Status))
+		if tokens := p.Data.ExecutionMetadata.TotalTokens; tokens > 0 {
+			node.SetAttributes(AttrTotalTokens.Int(tokens))
+		}
+		if p.Data.Error != "" {
+			node.SetStatus(codes.Error, p.Data.Error)
+		}
+		node.End()
+	case "error":
+		if p.Code != "" {
+			s.span.SetAttributes(AttrErrorCode.String(p.Code))
+		}
+		s.span.SetStatus(codes.Error, p.Message)
+	}
+}
+
+// identifiers 记录首次出现的会话、任务、消息和工作流运行ID
+func (s *callSpan) identifiers(p *streamPayload) {
+	if s.ids || (p.TaskID == "" && p.ConversationID == "" && p.MessageID == "" && p.WorkflowRunID == "") {
+		return
+	}
+	s.ids = true
+
+	var attrs []attribute.KeyValue
+	if p.TaskID != "" {
+		attrs = append(attrs, AttrTaskID.String(p.TaskID))
+	}
+	if p.ConversationID != "" {
+		attrs = append(attrs, AttrConversationID.String(p.ConversationID))
+	}
+	if p.MessageID != "" {
+		attrs = append(attrs, AttrMessageID.String(p.MessageID))
+	}
+	if p.WorkflowRunID != "" {
+		attrs = append(attrs, AttrWorkflowRunID.String(p.WorkflowRunID))
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+func (s *callSpan) usage(usage *dify.Usage) {
+	if usage == nil {
+		return
+	}
+	s.span.SetAttributes(
+		AttrPromptTokens.Int(usage.PromptTokens),
+		AttrCompletionTokens.Int(usage.CompletionTokens),
+		AttrTotalTokens.Int(usage.TotalTokens),
+	)
+}
+
+// end 结束 span 以及未收到 node_finished 的节点 span
+func (s *callSpan) end(err error) {
+	s.once.Do(func() {
+		for id, node := range s.nodes {
+			node.End()
+			delete(s.nodes, id)
+		}
+		if err != nil {
+			s.span.RecordError(err)
+			s.span.SetStatus(codes.Error, err.Error())
+		}
+		s.span.End()
+	})
+}
+
+// spanBody 在响应体关闭时结束 span，capture 为 true 时从响应体中提取属性
+type spanBody struct {
+	io.ReadCloser
+	span    *callSpan
+	capture bool
+	buf     bytes.Buffer
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.capture && n > 0 && b.buf.Len() < maxCapturedBody {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.capture {
+		var p streamPayload
+		if json.Unmarshal(b.buf.Bytes(), &p) == nil {
+			b.span.identifiers(&p)
+			b.span.usage(p.Metadata.Usage)
+			if p.Data.TotalTokens > 0 {
+				b.span.span.SetAttributes(AttrTotalTokens.Int(p.Data.TotalTokens))
+			}
+			if p.Code != "" {
+				b.span.span.SetAttributes(AttrErrorCode.String(p.Code))
+			}
+		}
+	}
+	b.span.end(nil)
+	return err
+}
@@ -0,0 +1,155 @@
+package difyotel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// 测试工作流流式调用生成 span、节点子 span 和用量属性
+func TestMiddlewareWorkflowStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") == "" {
+			t.Error("missing traceparent header")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"event":"workflow_started","task_id":"task-1","workflow_run_id":"run-1","data":{"id":"run-1"}}`,
+			`{"event":"node_started","task_id":"task-1","workflow_run_id":"run-1","data":{"id":"exec-1","node_id":"llm","node_type":"llm","title":"LLM","index":1}}`,
+			`{"event":"node_started","task_id":"task-1","workflow_run_id":"run-1","data":{"id":"exec-2","node_id":"tool","node_type":"tool","title":"Tool","index":2}}`,
+			`{"event":"node_finished","task_id":"task-1","workflow_run_id":"run-1","data":{"id":"exec-1","node_id":"llm","status":"succeeded","execution_metadata":{"total_tokens":42}}}`,
+			`{"event":"text_chunk","task_id":"task-1","workflow_run_id":"run-1","data":{"text":"hi"}}`,
+			`{"event":"workflow_finished","task_id":"task-1","workflow_run_id":"run-1","data":{"id":"run-1","status":"succeeded","total_tokens":42}}`,
+		}
+		for _, ev := range events {
+			fmt.Fprintf(w, "data: %s\n\n", ev)
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	mw := Middleware(
+		WithTracerProvider(provider),
+		WithPropagators(propagation.TraceContext{}),
+		WithAppName("flow"),
+	)
+	client := dify.NewClient("test-key", dify.WithBaseURL(server.URL), dify.WithMiddleware(mw))
+
+	err := client.WorkflowRunStreaming(dify.WorkflowRequest{User: "user-1"}, &nopHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("ended spans = %d, want 3", len(spans))
+	}
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+
+	root := byName["dify.WorkflowRunStreaming"]
+	if root == nil {
+		t.Fatal("missing root span")
+	}
+	attrs := attributeMap(root.Attributes())
+	if attrs[AttrApp] != "flow" || attrs[AttrTaskID] != "task-1" || attrs[AttrWorkflowRunID] != "run-1" {
+		t.Fatalf("root attributes = %v", attrs)
+	}
+	if attrs[AttrUserHash] != hashUser("user-1") || attrs[AttrTotalTokens] != "42" {
+		t.Fatalf("root attributes = %v", attrs)
+	}
+	if !hasEvent(root, "first_token") {
+		t.Fatal("missing first_token event")
+	}
+
+	llm := byName["dify.node LLM"]
+	if llm == nil || llm.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Fatal("node span should be a child of the call span")
+	}
+	if attributeMap(llm.Attributes())[AttrNodeStatus] != "succeeded" {
+		t.Fatalf("node attributes = %v", llm.Attributes())
+	}
+	if byName["dify.node Tool"] == nil {
+		t.Fatal("unfinished node span should be ended with the call span")
+	}
+}
+
+// 测试阻塞调用从响应体中提取会话ID和用量，并记录错误状态
+func TestMiddlewareBlocking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"message_id":"msg-1","conversation_id":"conv-1","answer":"hi","metadata":{"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := dify.NewClient("test-key", dify.WithBaseURL(server.URL),
+		dify.WithMiddleware(Middleware(WithTracerProvider(provider))))
+
+	if _, err := client.CreateChat(&dify.ChatRequest{Query: "hi", User: "user-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAppInfo(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	chat := attributeMap(spans[0].Attributes())
+	if chat[AttrConversationID] != "conv-1" || chat[AttrTotalTokens] != "8" || chat[AttrHTTPStatusCode] != "200" {
+		t.Fatalf("chat attributes = %v", chat)
+	}
+	info := attributeMap(spans[1].Attributes())
+	if spans[1].Status().Code != codes.Error || info[AttrHTTPStatusCode] != "400" {
+		t.Fatalf("info status = %v, attributes = %v", spans[1].Status(), info)
+	}
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[attribute.Key]string {
+	m := make(map[attribute.Key]string, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value.Emit()
+	}
+	return m
+}
+
+func hasEvent(span sdktrace.ReadOnlySpan, name string) bool {
+	for _, ev := range span.Events() {
+		if ev.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nopHandler 忽略所有流式事件
+type nopHandler struct{}
+
+func (nopHandler) OnMessage(response *dify.MessageStreamResponse) error { return nil }
+
+func (nopHandler) OnMessageWorkflow(response *dify.WorkflowStreamResponse) error { return nil }
+
+func (nopHandler) OnMessageEnd(response *dify.MessageEndStreamResponse) error { return nil }
+
+func (nopHandler) OnTTS(response *dify.TTSStreamResponse) error { return nil }
+
+func (nopHandler) OnTTSEnd(response *dify.TTSStreamResponse) error { return nil }
+
+func (nopHandler) OnError(err error) error { return err }
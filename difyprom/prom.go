@@ -0,0 +1,200 @@
+// Package difyprom 提供 dify.Metrics 的 Prometheus 实现
+//
+//	collector := difyprom.New()
+//	prometheus.MustRegister(collector)
+//	client := dify.NewClient(apiKey, dify.WithMetrics(collector))
+//	kb := knowledge.NewClient(datasetKey, knowledge.WithMetrics(collector))
+package difyprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 默认直方图桶
+var (
+	// DefaultLatencyBuckets 阻塞请求耗时和首 token 耗时的桶，单位秒
+	DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+	// DefaultChunkGapBuckets 流式事件间隔的桶，单位秒
+	DefaultChunkGapBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	// DefaultTokensPerSecondBuckets 输出速度的桶，单位 token/秒
+	DefaultTokensPerSecondBuckets = []float64{5, 10, 20, 30, 50, 75, 100, 150, 200, 300}
+)
+
+// config 收集器配置
+type config struct {
+	namespace         string
+	constLabels       prometheus.Labels
+	latencyBuckets    []float64
+	chunkGapBuckets   []float64
+	throughputBuckets []float64
+}
+
+// Option 收集器选项
+type Option func(*config)
+
+// WithNamespace 设置指标名前缀，默认为 dify
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels 为所有指标添加固定标签，如应用名
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithLatencyBuckets 设置阻塞请求耗时和首 token 耗时的桶
+func WithLatencyBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.latencyBuckets = buckets
+	}
+}
+
+// WithChunkGapBuckets 设置流式事件间隔的桶
+func WithChunkGapBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.chunkGapBuckets = buckets
+	}
+}
+
+// WithTokensPerSecondBuckets 设置输出速度的桶
+func WithTokensPerSecondBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.throughputBuckets = buckets
+	}
+}
+
+// Collector 同时实现 dify.Metrics 和 prometheus.Collector
+type Collector struct {
+	requests      *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	firstToken    *prometheus.HistogramVec
+	chunkGap      *prometheus.HistogramVec
+	throughput    *prometheus.HistogramVec
+	bytesUploaded *prometheus.CounterVec
+	activeStreams *prometheus.GaugeVec
+}
+
+var _ dify.Metrics = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New 创建收集器，需要注册到 prometheus.Registerer 后才会导出
+func New(opts ...Option) *Collector {
+	cfg := &config{
+		namespace:         "dify",
+		latencyBuckets:    DefaultLatencyBuckets,
+		chunkGapBuckets:   DefaultChunkGapBuckets,
+		throughputBuckets: DefaultTokensPerSecondBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace, Name: name, Help: help, ConstLabels: cfg.constLabels,
+		}, labels)
+	}
+	histogram := func(name, help string, buckets []float64) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace, Name: name, Help: help, ConstLabels: cfg.constLabels, Buckets: buckets,
+		}, []string{"endpoint"})
+	}
+
+	return &Collector{
+		requests: counter("requests_total",
+			"Dify API requests by endpoint and HTTP status code, status 0 means no response was received.",
+			"endpoint", "family", "status"),
+		errors: counter("errors_total",
+			"Failed Dify API requests by endpoint and error code.",
+			"endpoint", "family", "code"),
+		latency: histogram("request_duration_seconds",
+			"Latency of blocking Dify API requests until the response body is closed.", cfg.latencyBuckets),
+		firstToken: histogram("time_to_first_token_seconds",
+			"Time from sending a streaming request to receiving the first text chunk.", cfg.latencyBuckets),
+		chunkGap: histogram("stream_chunk_gap_seconds",
+			"Gap between consecutive events of a streaming response.", cfg.chunkGapBuckets),
+		throughput: histogram("stream_tokens_per_second",
+			"Output tokens per second of streaming responses.", cfg.throughputBuckets),
+		bytesUploaded: counter("uploaded_bytes_total",
+			"Bytes sent by file upload requests.",
+			"endpoint"),
+		activeStreams: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.namespace, Name: "active_streams", Help: "Streaming responses currently being read.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"endpoint"}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests, c.errors, c.latency, c.firstToken, c.chunkGap, c.throughput, c.bytesUploaded, c.activeStreams,
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// IncRequest 实现 dify.Metrics
+func (c *Collector) IncRequest(op *dify.Operation, statusCode int) {
+	c.requests.WithLabelValues(op.Name, string(op.Family), strconv.Itoa(statusCode)).Inc()
+}
+
+// IncError 实现 dify.Metrics
+func (c *Collector) IncError(op *dify.Operation, code string) {
+	c.errors.WithLabelValues(op.Name, string(op.Family), code).Inc()
+}
+
+// ObserveLatency 实现 dify.Metrics
+func (c *Collector) ObserveLatency(op *dify.Operation, d time.Duration) {
+	c.latency.WithLabelValues(op.Name).Observe(d.Seconds())
+}
+
+// ObserveTimeToFirstToken 实现 dify.Metrics
+func (c *Collector) ObserveTimeToFirstToken(op *dify.Operation, d time.Duration) {
+	c.firstToken.WithLabelValues(op.Name).Observe(d.Seconds())
+}
+
+// ObserveChunkGap 实现 dify.Metrics
+func (c *Collector) ObserveChunkGap(op *dify.Operation, d time.Duration) {
+	c.chunkGap.WithLabelValues(op.Name).Observe(d.Seconds())
+}
+
+// ObserveTokensPerSecond 实现 dify.Metrics
+func (c *Collector) ObserveTokensPerSecond(op *dify.Operation, tokensPerSecond float64) {
+	c.throughput.WithLabelValues(op.Name).Observe(tokensPerSecond)
+}
+
+// AddBytesUploaded 实现 dify.Metrics
+func (c *Collector) AddBytesUploaded(op *dify.Operation, n int64) {
+	c.bytesUploaded.WithLabelValues(op.Name).Add(float64(n))
+}
+
+// StreamStarted 实现 dify.Metrics
+func (c *Collector) StreamStarted(op *dify.Operation) {
+	c.activeStreams.WithLabelValues(op.Name).Inc()
+}
+
+// StreamEnded 实现 dify.Metrics
+func (c *Collector) StreamEnded(op *dify.Operation) {
+	c.activeStreams.WithLabelValues(op.Name).Dec()
+}
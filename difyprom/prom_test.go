@@ -0,0 +1,46 @@
+package difyprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// 测试收集器注册后导出请求数、错误码和耗时
+func TestCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == dify.EndpointParameters {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":"app_unavailable","message":"down","status":503}`))
+			return
+		}
+		w.Write([]byte(`{"name":"app"}`))
+	}))
+	defer server.Close()
+
+	collector := New(WithConstLabels(prometheus.Labels{"app": "test"}))
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	client := dify.NewClient("test-key", dify.WithBaseURL(server.URL), dify.WithMetrics(collector))
+	if _, err := client.GetAppInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAppParameters(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if got := testutil.ToFloat64(collector.requests.WithLabelValues("GetAppInfo", "app", "200")); got != 1 {
+		t.Fatalf("requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(collector.errors.WithLabelValues("GetAppParameters", "app", "app_unavailable")); got != 1 {
+		t.Fatalf("errors = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(collector, "dify_request_duration_seconds"); got != 2 {
+		t.Fatalf("latency series = %d, want 2", got)
+	}
+}
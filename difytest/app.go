@@ -0,0 +1,696 @@
+package difytest
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// AppRequest 传给 Responder 的对话、文本生成或工作流请求
+type AppRequest struct {
+	Endpoint       string // chat、completion 或 workflow
+	Query          string
+	Inputs         map[string]interface{}
+	User           string
+	ConversationID string
+}
+
+// Responder 根据请求生成回答
+type Responder func(req AppRequest) string
+
+// EchoResponder 默认的 Responder，返回 "echo: " 加上 query，没有 query 时使用 inputs 中的 query
+func EchoResponder(req AppRequest) string {
+	query := req.Query
+	if query == "" {
+		if v, ok := req.Inputs["query"].(string); ok {
+			query = v
+		}
+	}
+	return "echo: " + query
+}
+
+// WorkflowNode 工作流节点
+type WorkflowNode struct {
+	ID    string
+	Type  string
+	Title string
+}
+
+// DefaultWorkflowNodes 默认的工作流节点
+var DefaultWorkflowNodes = []WorkflowNode{
+	{ID: "start", Type: "start", Title: "Start"},
+	{ID: "llm", Type: "llm", Title: "LLM"},
+	{ID: "end", Type: "end", Title: "End"},
+}
+
+// WorkflowFunc 根据输入生成工作流输出，返回错误时工作流以 failed 状态结束
+type WorkflowFunc func(inputs map[string]interface{}) (map[string]interface{}, error)
+
+// Conversation 模拟服务中的会话
+type Conversation struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Inputs       map[string]interface{} `json:"inputs"`
+	Status       string                 `json:"status"`
+	Introduction string                 `json:"introduction"`
+	User         string                 `json:"-"`
+	CreatedAt    int64                  `json:"created_at"`
+	UpdatedAt    int64                  `json:"updated_at"`
+}
+
+// Message 模拟服务中的消息
+type Message struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	TaskID         string                 `json:"-"`
+	Inputs         map[string]interface{} `json:"inputs"`
+	Query          string                 `json:"query"`
+	Answer         string                 `json:"answer"`
+	User           string                 `json:"-"`
+	Feedback       *dify.FeedbackRequest  `json:"feedback"`
+	CreatedAt      int64                  `json:"created_at"`
+}
+
+// uploadedFile 上传的文件
+type uploadedFile struct {
+	info    dify.FileUploadResponse
+	content []byte
+}
+
+// SetResponder 设置对话、文本生成和默认工作流的回答
+func (s *Server) SetResponder(responder Responder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responder = responder
+}
+
+// SetWorkflow 设置工作流节点和输出，nodes 为空时使用 DefaultWorkflowNodes，run 为空时输出 {"text": 回答}
+func (s *Server) SetWorkflow(nodes []WorkflowNode, run WorkflowFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(nodes) == 0 {
+		nodes = DefaultWorkflowNodes
+	}
+	s.nodes = nodes
+	s.workflow = run
+}
+
+// SetAppInfo 设置 /info 的返回值
+func (s *Server) SetAppInfo(info dify.AppInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appInfo = info
+}
+
+// SetParameters 设置 /parameters 的返回值
+func (s *Server) SetParameters(params dify.AppParameters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.params = params
+}
+
+// Conversation 返回会话
+func (s *Server) Conversation(id string) (Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conv, ok := s.conversations[id]
+	if !ok {
+		return Conversation{}, false
+	}
+	return *conv, true
+}
+
+// Messages 返回会话中的消息，按创建顺序排列
+func (s *Server) Messages(conversationID string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Message
+	for _, id := range s.messageOrder {
+		if msg := s.messages[id]; msg.ConversationID == conversationID {
+			out = append(out, *msg)
+		}
+	}
+	return out
+}
+
+// UploadedFile 返回上传文件的内容
+func (s *Server) UploadedFile(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[id]
+	if !ok {
+		return nil, false
+	}
+	return file.content, true
+}
+
+// Stopped 返回任务是否被停止
+func (s *Server) Stopped(taskID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped[taskID]
+}
+
+func (s *Server) registerAppRoutes() {
+	s.handle(http.MethodPost, dify.EndpointChat, s.chat)
+	s.handle(http.MethodPost, dify.EndpointChat+"/{task_id}/stop", s.stop)
+	s.handle(http.MethodPost, dify.EndpointCompletion, s.completion)
+	s.handle(http.MethodPost, dify.EndpointCompletion+"/{task_id}/stop", s.stop)
+	s.handle(http.MethodPost, dify.EndpointWorkflows+"/run", s.workflowRun)
+	s.handle(http.MethodPost, dify.EndpointWorkflows+"/tasks/{task_id}/stop", s.stop)
+	s.handle(http.MethodPost, dify.EndpointFiles+"/upload", s.upload)
+	s.handle(http.MethodPost, dify.EndpointMessages+"/{message_id}"+dify.EndpointFeedbacks, s.feedback)
+	s.handle(http.MethodGet, dify.EndpointMessages, s.listMessages)
+	s.handle(http.MethodGet, dify.EndpointConversations, s.listConversations)
+	s.handle(http.MethodDelete, dify.EndpointConversations+"/{conversation_id}", s.deleteConversation)
+	s.handle(http.MethodPost, dify.EndpointConversations+"/{conversation_id}", s.deleteConversation)
+	s.handle(http.MethodPost, dify.EndpointConversations+"/{conversation_id}/name", s.renameConversation)
+	s.handle(http.MethodGet, dify.EndpointInfo, s.info)
+	s.handle(http.MethodGet, dify.EndpointParameters, s.parameters)
+	s.handle(http.MethodPost, dify.EndpointAudio, s.textToAudio)
+}
+
+// chat 处理 POST /chat-messages
+func (s *Server) chat(c *call) {
+	var req dify.ChatRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Query == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "query is required")
+		return
+	}
+
+	now := time.Now().Unix()
+	s.mu.Lock()
+	conv, ok := s.conversations[req.ConversationId]
+	if req.ConversationId != "" && (!ok || conv.User != req.User) {
+		s.mu.Unlock()
+		writeError(c.w, http.StatusNotFound, "not_found", "Conversation Not Exists.")
+		return
+	}
+	if conv == nil {
+		conv = &Conversation{
+			ID:        s.nextID("conv"),
+			Name:      "New conversation",
+			Inputs:    req.Inputs,
+			Status:    "normal",
+			User:      req.User,
+			CreatedAt: now,
+		}
+		s.conversations[conv.ID] = conv
+	}
+	conv.UpdatedAt = now
+	msg := s.newMessage(conv.ID, req.Query, req.Inputs, req.User)
+	s.mu.Unlock()
+	s.answer(msg, "chat")
+
+	s.respond(c, "chat", req.ResponseMode, msg)
+}
+
+// completion 处理 POST /completion-messages
+func (s *Server) completion(c *call) {
+	var req dify.CompletionRequest
+	if !c.decode(&req) {
+		return
+	}
+	inputs := make(map[string]interface{}, len(req.Inputs))
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+
+	s.mu.Lock()
+	msg := s.newMessage("", "", inputs, req.User)
+	s.mu.Unlock()
+	s.answer(msg, "completion")
+
+	s.respond(c, "completion", req.ResponseMode, msg)
+}
+
+// newMessage 创建消息，调用方需要持有锁
+func (s *Server) newMessage(conversationID, query string, inputs map[string]interface{}, user string) *Message {
+	msg := &Message{
+		ID:             s.nextID("msg"),
+		ConversationID: conversationID,
+		TaskID:         s.nextID("task"),
+		Inputs:         inputs,
+		Query:          query,
+		User:           user,
+		CreatedAt:      time.Now().Unix(),
+	}
+	s.messages[msg.ID] = msg
+	s.messageOrder = append(s.messageOrder, msg.ID)
+	return msg
+}
+
+// answer 调用 Responder 生成回答，Responder 中可以调用 Server 的方法，因此不能持有锁
+func (s *Server) answer(msg *Message, endpoint string) {
+	s.mu.Lock()
+	responder := s.responder
+	s.mu.Unlock()
+
+	answer := responder(AppRequest{
+		Endpoint:       endpoint,
+		Query:          msg.Query,
+		Inputs:         msg.Inputs,
+		User:           msg.User,
+		ConversationID: msg.ConversationID,
+	})
+
+	s.mu.Lock()
+	msg.Answer = answer
+	s.mu.Unlock()
+}
+
+// respond 按响应模式返回消息
+func (s *Server) respond(c *call, mode, responseMode string, msg *Message) {
+	usage := dify.Usage{
+		PromptTokens:     tokens(msg.Query),
+		CompletionTokens: tokens(msg.Answer),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	metadata := dify.ResponseMetadata{Usage: usage, RetrieverResources: []dify.RetrieverResource{}}
+
+	if responseMode != dify.ResponseModeStreaming {
+		writeJSON(c.w, http.StatusOK, map[string]interface{}{
+			"event":           "message",
+			"task_id":         msg.TaskID,
+			"id":              msg.ID,
+			"message_id":      msg.ID,
+			"conversation_id": msg.ConversationID,
+			"mode":            mode,
+			"answer":          msg.Answer,
+			"metadata":        metadata,
+			"created_at":      msg.CreatedAt,
+		})
+		return
+	}
+
+	base := func(event string) dify.StreamResponse {
+		return dify.StreamResponse{
+			Event:          event,
+			TaskID:         msg.TaskID,
+			ConversationId: msg.ConversationID,
+			MessageID:      msg.ID,
+			CreatedAt:      msg.CreatedAt,
+		}
+	}
+	e := s.newSSE(c, msg.TaskID)
+	for _, chunk := range chunks(msg.Answer) {
+		if s.Stopped(msg.TaskID) {
+			break
+		}
+		if !e.send(dify.MessageStreamResponse{StreamResponse: base("message"), Answer: chunk}) {
+			return
+		}
+	}
+	e.send(dify.MessageEndStreamResponse{StreamResponse: base("message_end"), Metadata: metadata})
+}
+
+// workflowRun 处理 POST /workflows/run
+func (s *Server) workflowRun(c *call) {
+	var req dify.WorkflowRequest
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	taskID := s.nextID("task")
+	runID := s.nextID("run")
+	nodes := s.nodes
+	run := s.workflow
+	responder := s.responder
+	s.mu.Unlock()
+
+	start := time.Now()
+	var outputs map[string]interface{}
+	var runErr error
+	if run != nil {
+		outputs, runErr = run(req.Inputs)
+	} else {
+		outputs = map[string]interface{}{
+			"text": responder(AppRequest{Endpoint: "workflow", Inputs: req.Inputs, User: req.User}),
+		}
+	}
+	status := "succeeded"
+	errMsg := ""
+	if runErr != nil {
+		status = "failed"
+		errMsg = runErr.Error()
+	}
+	totalTokens := 0
+	if text, ok := outputs["text"].(string); ok {
+		totalTokens = tokens(text)
+	}
+
+	finished := func() dify.WorkflowDataResp {
+		return dify.WorkflowDataResp{
+			Id:          runID,
+			WorkflowId:  "workflow-1",
+			Status:      status,
+			Outputs:     outputs,
+			Error:       errMsg,
+			ElapsedTime: time.Since(start).Seconds(),
+			TotalTokens: totalTokens,
+			TotalSteps:  len(nodes),
+			CreatedAt:   int(start.Unix()),
+			FinishedAt:  int(time.Now().Unix()),
+		}
+	}
+
+	if req.ResponseMode != dify.ResponseModeStreaming {
+		writeJSON(c.w, http.StatusOK, dify.WorkflowResponse{TaskID: taskID, WorkflowRunId: runID, Data: finished()})
+		return
+	}
+
+	event := func(name string, data interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"event":           name,
+			"task_id":         taskID,
+			"workflow_run_id": runID,
+			"data":            data,
+		}
+	}
+	e := s.newSSE(c, taskID)
+	if !e.send(event("workflow_started", map[string]interface{}{
+		"id": runID, "workflow_id": "workflow-1", "sequence_number": 1, "created_at": start.Unix(),
+	})) {
+		return
+	}
+	for i, node := range nodes {
+		execID := runID + "-" + node.ID
+		nodeData := map[string]interface{}{
+			"id": execID, "node_id": node.ID, "node_type": node.Type, "title": node.Title, "index": i + 1,
+			"created_at": time.Now().Unix(),
+		}
+		if !e.send(event("node_started", nodeData)) {
+			return
+		}
+		nodeStatus := "succeeded"
+		if runErr != nil && i == len(nodes)-1 {
+			nodeStatus = "failed"
+			nodeData["error"] = errMsg
+		}
+		nodeData["status"] = nodeStatus
+		nodeData["elapsed_time"] = 0.01
+		nodeData["execution_metadata"] = map[string]interface{}{}
+		if node.Type == "llm" {
+			nodeData["execution_metadata"] = map[string]interface{}{"total_tokens": totalTokens}
+		}
+		if !e.send(event("node_finished", nodeData)) {
+			return
+		}
+	}
+	e.send(event("workflow_finished", finished()))
+}
+
+// stop 处理停止响应
+func (s *Server) stop(c *call) {
+	var req struct {
+		User string `json:"user"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	s.mu.Lock()
+	s.stopped[c.params["task_id"]] = true
+	s.mu.Unlock()
+	success(c.w)
+}
+
+// upload 处理 POST /files/upload
+func (s *Server) upload(c *call) {
+	c.r.Body = io.NopCloser(bytes.NewReader(c.body))
+	file, header, err := c.r.FormFile("file")
+	if err != nil {
+		writeError(c.w, http.StatusBadRequest, "no_file_uploaded", "Please upload your file.")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", err.Error())
+		return
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+	mimeType := header.Header.Get("Content-Type")
+	if t := mime.TypeByExtension(filepath.Ext(header.Filename)); t != "" {
+		mimeType = t
+	}
+
+	s.mu.Lock()
+	f := &uploadedFile{
+		info: dify.FileUploadResponse{
+			ID:        s.nextID("file"),
+			Name:      header.Filename,
+			Size:      int64(len(content)),
+			Extension: ext,
+			MimeType:  mimeType,
+			CreatedBy: c.r.FormValue("user"),
+			CreatedAt: time.Now().Unix(),
+		},
+		content: content,
+	}
+	s.files[f.info.ID] = f
+	s.mu.Unlock()
+
+	writeJSON(c.w, http.StatusOK, f.info)
+}
+
+// feedback 处理 POST /messages/{message_id}/feedbacks
+func (s *Server) feedback(c *call) {
+	var req dify.FeedbackRequest
+	if !c.decode(&req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.messages[c.params["message_id"]]
+	if !ok {
+		writeError(c.w, http.StatusNotFound, "not_found", "Message Not Exists.")
+		return
+	}
+	if req.Rating == "" || req.Rating == "null" {
+		msg.Feedback = nil
+	} else {
+		msg.Feedback = &req
+	}
+	success(c.w)
+}
+
+// listMessages 处理 GET /messages
+func (s *Server) listMessages(c *call) {
+	query := c.r.URL.Query()
+	conversationID := query.Get("conversation_id")
+	user := query.Get("user")
+	limit := intParam(query.Get("limit"), 20)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conv, ok := s.conversations[conversationID]
+	if !ok || conv.User != user {
+		writeError(c.w, http.StatusNotFound, "not_found", "Conversation Not Exists.")
+		return
+	}
+	var messages []Message
+	for _, id := range s.messageOrder {
+		if msg := s.messages[id]; msg.ConversationID == conversationID {
+			messages = append(messages, *msg)
+		}
+	}
+	// first_id 之前的消息，最新的在最后
+	if firstID := query.Get("first_id"); firstID != "" {
+		for i, msg := range messages {
+			if msg.ID == firstID {
+				messages = messages[:i]
+				break
+			}
+		}
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[len(messages)-limit:]
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{
+		"limit":    limit,
+		"has_more": hasMore,
+		"data":     nonNil(messages),
+	})
+}
+
+// listConversations 处理 GET /conversations
+func (s *Server) listConversations(c *call) {
+	query := c.r.URL.Query()
+	user := query.Get("user")
+	limit := intParam(query.Get("limit"), 20)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var conversations []Conversation
+	for _, id := range s.sortedConversationIDs() {
+		if conv := s.conversations[id]; conv.User == user {
+			conversations = append(conversations, *conv)
+		}
+	}
+	if lastID := query.Get("last_id"); lastID != "" {
+		for i, conv := range conversations {
+			if conv.ID == lastID {
+				conversations = conversations[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(conversations) > limit
+	if hasMore {
+		conversations = conversations[:limit]
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{
+		"limit":    limit,
+		"has_more": hasMore,
+		"data":     nonNil(conversations),
+	})
+}
+
+// sortedConversationIDs 按创建时间倒序返回会话ID，调用方需要持有锁
+func (s *Server) sortedConversationIDs() []string {
+	ids := make([]string, 0, len(s.conversations))
+	for i := s.seq["conv"]; i > 0; i-- {
+		id := "conv-" + strconv.Itoa(i)
+		if _, ok := s.conversations[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// deleteConversation 处理删除会话
+func (s *Server) deleteConversation(c *call) {
+	var req struct {
+		User string `json:"user"`
+	}
+	if len(c.body) > 0 && !c.decode(&req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := c.params["conversation_id"]
+	conv, ok := s.conversations[id]
+	if !ok || conv.User != req.User {
+		writeError(c.w, http.StatusNotFound, "not_found", "Conversation Not Exists.")
+		return
+	}
+	delete(s.conversations, id)
+	success(c.w)
+}
+
+// renameConversation 处理 POST /conversations/{conversation_id}/name
+func (s *Server) renameConversation(c *call) {
+	var req struct {
+		Name         string `json:"name"`
+		AutoGenerate bool   `json:"auto_generate"`
+		User         string `json:"user"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conv, ok := s.conversations[c.params["conversation_id"]]
+	if !ok || conv.User != req.User {
+		writeError(c.w, http.StatusNotFound, "not_found", "Conversation Not Exists.")
+		return
+	}
+	if req.AutoGenerate || req.Name == "" {
+		req.Name = "Conversation " + conv.ID
+	}
+	conv.Name = req.Name
+	conv.UpdatedAt = time.Now().Unix()
+	writeJSON(c.w, http.StatusOK, conv)
+}
+
+// info 处理 GET /info
+func (s *Server) info(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(c.w, http.StatusOK, s.appInfo)
+}
+
+// parameters 处理 GET /parameters
+func (s *Server) parameters(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(c.w, http.StatusOK, s.params)
+}
+
+// textToAudio 处理 POST /audio，返回的音频内容为 "audio:" 加上文本
+func (s *Server) textToAudio(c *call) {
+	var req dify.TTSRequest
+	if !c.decode(&req) {
+		return
+	}
+	text := req.Text
+	if req.MessageID != "" {
+		s.mu.Lock()
+		msg, ok := s.messages[req.MessageID]
+		s.mu.Unlock()
+		if !ok {
+			writeError(c.w, http.StatusNotFound, "not_found", "Message Not Exists.")
+			return
+		}
+		text = msg.Answer
+	}
+	if text == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "text or message_id is required")
+		return
+	}
+	c.w.Header().Set("Content-Type", "audio/mpeg")
+	c.w.Write([]byte("audio:" + text))
+}
+
+// chunks 将回答拆分为流式片段
+func chunks(answer string) []string {
+	const size = 8
+	var out []string
+	for _, word := range strings.SplitAfter(answer, " ") {
+		for utf8.RuneCountInString(word) > size {
+			runes := []rune(word)
+			out = append(out, string(runes[:size]))
+			word = string(runes[size:])
+		}
+		if word != "" {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// tokens 以字符数近似 token 数
+func tokens(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// intParam 解析整数查询参数
+func intParam(value string, def int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// nonNil 保证空列表序列化为 []
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
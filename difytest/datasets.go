@@ -0,0 +1,1183 @@
+package difytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// dataset 模拟服务中的知识库
+type dataset struct {
+	info          knowledge.Knowledge
+	documents     map[string]*document
+	documentOrder []string
+	// processRule 第一个文档的处理规则，作为知识库的默认规则
+	processRule *knowledge.ProcessRule
+	// metadata 自定义元数据字段，按创建顺序排列
+	metadata []*knowledge.MetadataField
+	// builtInMetadata 是否启用内置元数据字段
+	builtInMetadata bool
+	// tagIDs 绑定的标签，按绑定顺序排列
+	tagIDs []string
+}
+
+// document 模拟服务中的文档
+type document struct {
+	info     knowledge.Document
+	batch    string
+	polls    int
+	segments []*knowledge.Segment
+	// childSeparator hierarchical_model 文档的子分段分隔符
+	childSeparator string
+	// failure 不为空时索引以 error 状态结束
+	failure string
+}
+
+// docFormHierarchical 父子分段模式
+const docFormHierarchical = "hierarchical_model"
+
+// Documents 返回知识库中的文档，按创建顺序排列
+func (s *Server) Documents(datasetID string) []knowledge.Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.datasets[datasetID]
+	if !ok {
+		return nil
+	}
+	out := make([]knowledge.Document, 0, len(ds.documentOrder))
+	for _, id := range ds.documentOrder {
+		out = append(out, ds.documents[id].info)
+	}
+	return out
+}
+
+// FailIndexing 使文档的索引以 error 状态结束，文档不存在时返回 false
+//
+// 文档内容被更新并重新分段后恢复正常索引。
+func (s *Server) FailIndexing(datasetID, documentID, message string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.datasets[datasetID]
+	if !ok {
+		return false
+	}
+	doc, ok := ds.documents[documentID]
+	if !ok {
+		return false
+	}
+	doc.failure = message
+	s.updateIndexing(doc)
+	return true
+}
+
+// Segments 返回文档的分段
+func (s *Server) Segments(datasetID, documentID string) []knowledge.Segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.datasets[datasetID]
+	if !ok {
+		return nil
+	}
+	doc, ok := ds.documents[documentID]
+	if !ok {
+		return nil
+	}
+	out := make([]knowledge.Segment, 0, len(doc.segments))
+	for _, seg := range doc.segments {
+		out = append(out, *seg)
+	}
+	return out
+}
+
+func (s *Server) registerDatasetRoutes() {
+	s.handle(http.MethodPost, "/datasets", s.createDataset)
+	s.handle(http.MethodGet, "/datasets", s.listDatasets)
+	s.handle(http.MethodPost, "/datasets/tags", s.createTag)
+	s.handle(http.MethodGet, "/datasets/tags", s.listTags)
+	s.handle(http.MethodPatch, "/datasets/tags", s.renameTag)
+	s.handle(http.MethodDelete, "/datasets/tags", s.deleteTag)
+	s.handle(http.MethodPost, "/datasets/tags/binding", s.bindTags)
+	s.handle(http.MethodPost, "/datasets/tags/unbinding", s.unbindTag)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/tags", s.datasetTags)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}", s.getDataset)
+	s.handle(http.MethodPatch, "/datasets/{dataset_id}", s.updateDataset)
+	s.handle(http.MethodDelete, "/datasets/{dataset_id}", s.deleteDataset)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/retrieve", s.retrieve)
+
+	for _, path := range []string{"create-by-text", "create_by_text"} {
+		s.handle(http.MethodPost, "/datasets/{dataset_id}/document/"+path, s.createDocumentByText)
+	}
+	for _, path := range []string{"create-by-file", "create_by_file"} {
+		s.handle(http.MethodPost, "/datasets/{dataset_id}/document/"+path, s.createDocumentByFile)
+	}
+	for _, path := range []string{"update-by-text", "update_by_text"} {
+		s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/{document_id}/"+path, s.updateDocumentByText)
+	}
+	for _, path := range []string{"update-by-file", "update_by_file"} {
+		s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/{document_id}/"+path, s.updateDocumentByFile)
+	}
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/metadata", s.listMetadata)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/metadata", s.createMetadata)
+	s.handle(http.MethodPatch, "/datasets/{dataset_id}/metadata/{metadata_id}", s.renameMetadata)
+	s.handle(http.MethodDelete, "/datasets/{dataset_id}/metadata/{metadata_id}", s.deleteMetadata)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/metadata/built-in/{action}", s.builtInMetadata)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/metadata", s.updateDocumentsMetadata)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents", s.listDocuments)
+	s.handle(http.MethodPatch, "/datasets/{dataset_id}/documents/status/{action}", s.updateDocumentsStatus)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents/{document_id}", s.getDocument)
+	s.handle(http.MethodDelete, "/datasets/{dataset_id}/documents/{document_id}", s.deleteDocument)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents/{batch}/indexing-status", s.indexingStatus)
+
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents/{document_id}/segments", s.listSegments)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/{document_id}/segments", s.createSegments)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}", s.getSegment)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}", s.updateSegment)
+	s.handle(http.MethodDelete, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}", s.deleteSegment)
+	s.handle(http.MethodGet, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}/child_chunks", s.listChildChunks)
+	s.handle(http.MethodPost, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}/child_chunks", s.createChildChunk)
+	s.handle(http.MethodPatch, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}/child_chunks/{child_chunk_id}", s.updateChildChunk)
+	s.handle(http.MethodDelete, "/datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}/child_chunks/{child_chunk_id}", s.deleteChildChunk)
+}
+
+// lookupDataset 查找知识库，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupDataset(c *call) (*dataset, bool) {
+	ds, ok := s.datasets[c.params["dataset_id"]]
+	if !ok {
+		writeError(c.w, http.StatusNotFound, "dataset_not_found", "Dataset not found.")
+	}
+	return ds, ok
+}
+
+// lookupDocument 查找文档，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupDocument(c *call) (*dataset, *document, bool) {
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return nil, nil, false
+	}
+	doc, ok := ds.documents[c.params["document_id"]]
+	if !ok {
+		writeError(c.w, http.StatusNotFound, "document_not_found", "Document not found.")
+	}
+	return ds, doc, ok
+}
+
+// pageParams 读取分页和关键词参数，GET 请求的 JSON 请求体作为查询参数的补充
+func (c *call) pageParams() (page, limit int, keyword string) {
+	var body struct {
+		Page    int    `json:"page"`
+		Limit   int    `json:"limit"`
+		Keyword string `json:"keyword"`
+	}
+	json.Unmarshal(c.body, &body)
+
+	query := c.r.URL.Query()
+	page = intParam(query.Get("page"), body.Page)
+	limit = intParam(query.Get("limit"), body.Limit)
+	keyword = query.Get("keyword")
+	if keyword == "" {
+		keyword = body.Keyword
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	return page, limit, keyword
+}
+
+// paginate 返回分页后的区间
+func paginate(total, page, limit int) (start, end int, hasMore bool) {
+	start = (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end = start + limit
+	if end > total {
+		end = total
+	}
+	return start, end, end < total
+}
+
+// createDataset 处理 POST /datasets
+func (s *Server) createDataset(c *call) {
+	var req knowledge.CreateKnowledgeRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ds := range s.datasets {
+		if ds.info.Name == req.Name {
+			writeError(c.w, http.StatusConflict, "dataset_name_duplicate", "The dataset name already exists. Please modify your dataset name.")
+			return
+		}
+	}
+	now := time.Now().Unix()
+	ds := &dataset{
+		info: knowledge.Knowledge{
+			ID:                s.nextID("dataset"),
+			Name:              req.Name,
+			Description:       req.Description,
+			IndexingTechnique: req.IndexingTechnique,
+			Permission:        firstNonEmpty(req.Permission, "only_me"),
+			Provider:          firstNonEmpty(req.Provider, "vendor"),
+			DataSourceType:    "upload_file",
+			CreatedBy:         "api",
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		},
+		documents: make(map[string]*document),
+	}
+	s.datasets[ds.info.ID] = ds
+	s.datasetOrder = append(s.datasetOrder, ds.info.ID)
+	writeJSON(c.w, http.StatusOK, s.datasetView(ds))
+}
+
+// listDatasets 处理 GET /datasets
+func (s *Server) listDatasets(c *call) {
+	page, limit, keyword := c.pageParams()
+	tagIDs := c.r.URL.Query()["tag_ids"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []knowledge.Knowledge
+	for i := len(s.datasetOrder) - 1; i >= 0; i-- {
+		ds, ok := s.datasets[s.datasetOrder[i]]
+		if !ok || !strings.Contains(ds.info.Name, keyword) || !ds.hasTags(tagIDs) {
+			continue
+		}
+		matched = append(matched, s.datasetView(ds))
+	}
+	start, end, hasMore := paginate(len(matched), page, limit)
+	writeJSON(c.w, http.StatusOK, knowledge.ListKnowledgeResponse{
+		Data:    nonNil(matched[start:end]),
+		Total:   len(matched),
+		Page:    page,
+		Limit:   limit,
+		HasMore: hasMore,
+	})
+}
+
+// getDataset 处理 GET /datasets/{dataset_id}
+func (s *Server) getDataset(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ds, ok := s.lookupDataset(c); ok {
+		writeJSON(c.w, http.StatusOK, s.datasetView(ds))
+	}
+}
+
+// updateDataset 处理 PATCH /datasets/{dataset_id}，只更新请求中出现的字段
+func (s *Server) updateDataset(c *call) {
+	var req struct {
+		Name                   *string                   `json:"name"`
+		Description            *string                   `json:"description"`
+		IndexingTechnique      *string                   `json:"indexing_technique"`
+		Permission             *string                   `json:"permission"`
+		PartialMemberList      []knowledge.PartialMember `json:"partial_member_list"`
+		EmbeddingModelProvider *string                   `json:"embedding_model_provider"`
+		EmbeddingModel         *string                   `json:"embedding_model"`
+		RetrievalModel         *knowledge.RetrievalModel `json:"retrieval_model"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	if req.IndexingTechnique != nil && *req.IndexingTechnique != "high_quality" && *req.IndexingTechnique != "economy" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "Invalid indexing technique.")
+		return
+	}
+	if req.Permission != nil {
+		switch *req.Permission {
+		case "only_me", "all_team_members":
+		case "partial_members":
+			if len(req.PartialMemberList) == 0 {
+				writeError(c.w, http.StatusBadRequest, "invalid_param", "partial_member_list is required when permission is partial_members.")
+				return
+			}
+		default:
+			writeError(c.w, http.StatusBadRequest, "invalid_param", "Invalid permission.")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&ds.info.Name, req.Name},
+		{&ds.info.Description, req.Description},
+		{&ds.info.IndexingTechnique, req.IndexingTechnique},
+		{&ds.info.Permission, req.Permission},
+		{&ds.info.EmbeddingModelProvider, req.EmbeddingModelProvider},
+		{&ds.info.EmbeddingModel, req.EmbeddingModel},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	if req.Permission != nil {
+		ds.info.PartialMemberList = nil
+		if *req.Permission == "partial_members" {
+			for _, member := range req.PartialMemberList {
+				ds.info.PartialMemberList = append(ds.info.PartialMemberList, member.UserID)
+			}
+		}
+	}
+	if req.RetrievalModel != nil {
+		rm := *req.RetrievalModel
+		ds.info.RetrievalModelDict = &rm
+	}
+	ds.info.UpdatedAt = time.Now().Unix()
+	writeJSON(c.w, http.StatusOK, s.datasetView(ds))
+}
+
+// deleteDataset 处理 DELETE /datasets/{dataset_id}
+func (s *Server) deleteDataset(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	delete(s.datasets, ds.info.ID)
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// documentRequest 创建和更新文档的公共字段
+type documentRequest struct {
+	Name              string                 `json:"name"`
+	Text              *string                `json:"text"`
+	DocType           string                 `json:"doc_type"`
+	DocMetadata       map[string]interface{} `json:"doc_metadata"`
+	IndexingTechnique string                 `json:"indexing_technique"`
+	DocForm           string                 `json:"doc_form"`
+	ProcessRule       *knowledge.ProcessRule `json:"process_rule"`
+}
+
+// createDocumentByText 处理 POST /datasets/{dataset_id}/document/create-by-text
+func (s *Server) createDocumentByText(c *call) {
+	var req documentRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Name == "" || req.Text == nil {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "name and text are required")
+		return
+	}
+	s.createDocument(c, req, "upload_file", *req.Text)
+}
+
+// createDocumentByFile 处理 POST /datasets/{dataset_id}/document/create-by-file
+func (s *Server) createDocumentByFile(c *call) {
+	req, content, filename, ok := c.multipartDocument()
+	if !ok {
+		return
+	}
+	if req.Name == "" {
+		req.Name = filename
+	}
+	s.createDocument(c, req, "upload_file", content)
+}
+
+// multipartDocument 解析 file 和 data 字段
+func (c *call) multipartDocument() (documentRequest, string, string, bool) {
+	var req documentRequest
+	c.r.Body = io.NopCloser(bytes.NewReader(c.body))
+	file, header, err := c.r.FormFile("file")
+	if err != nil {
+		writeError(c.w, http.StatusBadRequest, "no_file_uploaded", "Please upload your file.")
+		return req, "", "", false
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", err.Error())
+		return req, "", "", false
+	}
+	if data := c.r.FormValue("data"); data != "" {
+		if err := json.Unmarshal([]byte(data), &req); err != nil {
+			writeError(c.w, http.StatusBadRequest, "invalid_param", "invalid data field: "+err.Error())
+			return req, "", "", false
+		}
+	}
+	return req, string(content), header.Filename, true
+}
+
+// createDocument 创建文档并按处理规则分段
+func (s *Server) createDocument(c *call, req documentRequest, sourceType, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().Unix()
+	doc := &document{
+		info: knowledge.Document{
+			ID:             s.nextID("document"),
+			Position:       len(ds.documentOrder) + 1,
+			DataSourceType: sourceType,
+			Name:           req.Name,
+			CreatedFrom:    "api",
+			CreatedAt:      now,
+			Enabled:        true,
+			DocForm:        firstNonEmpty(req.DocForm, "text_model"),
+		},
+		batch: s.nextID("batch"),
+	}
+	if req.DocType != "" {
+		docType := req.DocType
+		doc.info.DocType = &docType
+	}
+	if ds.info.IndexingTechnique == "" {
+		ds.info.IndexingTechnique = req.IndexingTechnique
+	}
+	if ds.processRule == nil {
+		ds.processRule = req.ProcessRule
+	}
+	doc.info.DatasetProcessRule = ds.processRule
+	doc.info.DocumentProcessRule = req.ProcessRule
+	doc.info.DocMetadata = docMetadata(req.DocMetadata)
+	s.resegment(doc, content, req.ProcessRule)
+	ds.documents[doc.info.ID] = doc
+	ds.documentOrder = append(ds.documentOrder, doc.info.ID)
+	ds.info.DocumentCount = len(ds.documents)
+
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"document": doc.info, "batch": doc.batch})
+}
+
+// resegment 重新分段并重置索引状态，调用方需要持有锁
+//
+// hierarchical_model 文档默认按空行切分父分段，再按换行切分子分段。
+func (s *Server) resegment(doc *document, content string, rule *knowledge.ProcessRule) {
+	hierarchical := doc.info.DocForm == docFormHierarchical
+	separator, childSeparator := "\n", "\n"
+	if hierarchical {
+		separator = "\n\n"
+	}
+	if rule != nil && rule.Segmentation != nil {
+		if rule.Segmentation.Separator != "" {
+			separator = unescapeSeparator(rule.Segmentation.Separator)
+		}
+		if sub := rule.Segmentation.SubchunkSegmentation; sub != nil && sub.Separator != "" {
+			childSeparator = unescapeSeparator(sub.Separator)
+		}
+	}
+	doc.childSeparator = childSeparator
+
+	doc.segments = nil
+	doc.info.WordCount = 0
+	doc.info.Tokens = 0
+	now := time.Now().Unix()
+	doc.info.UpdatedAt = now
+	doc.info.CompletedAt = nil
+	doc.info.IndexingLatency = nil
+	doc.failure = ""
+	for _, part := range strings.Split(content, separator) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		seg := &knowledge.Segment{
+			ID:         s.nextID("segment"),
+			Position:   len(doc.segments) + 1,
+			DocumentID: doc.info.ID,
+			Content:    part,
+			WordCount:  utf8.RuneCountInString(part),
+			Tokens:     tokens(part),
+			Keywords:   []string{},
+			Enabled:    true,
+			Status:     "completed",
+			CreatedBy:  "api",
+			CreatedAt:  now,
+		}
+		if hierarchical {
+			s.rechunk(doc, seg)
+		}
+		doc.segments = append(doc.segments, seg)
+		doc.info.WordCount += seg.WordCount
+		doc.info.Tokens += seg.Tokens
+	}
+	doc.polls = 0
+	s.updateIndexing(doc)
+}
+
+// rechunk 按文档的子分段分隔符重新生成分段的子分段，调用方需要持有锁
+func (s *Server) rechunk(doc *document, seg *knowledge.Segment) {
+	now := time.Now().Unix()
+	var chunks []knowledge.ChildChunk
+	for _, part := range strings.Split(seg.Content, doc.childSeparator) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		chunks = append(chunks, knowledge.ChildChunk{
+			ID:        s.nextID("child-chunk"),
+			SegmentID: seg.ID,
+			Content:   part,
+			Position:  len(chunks) + 1,
+			WordCount: utf8.RuneCountInString(part),
+			Type:      "automatic",
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	seg.ChildChunks = chunks
+}
+
+// unescapeSeparator 将请求中转义的 \n 还原为换行
+func unescapeSeparator(separator string) string {
+	return strings.ReplaceAll(separator, `\n`, "\n")
+}
+
+// updateIndexing 根据查询次数更新索引状态，调用方需要持有锁
+func (s *Server) updateIndexing(doc *document) {
+	if doc.failure != "" {
+		doc.info.IndexingStatus = "error"
+		doc.info.DisplayStatus = displayStatus(doc.info)
+		message := doc.failure
+		doc.info.Error = &message
+		return
+	}
+	doc.info.Error = nil
+	if doc.polls >= s.indexingSteps {
+		doc.info.IndexingStatus = "completed"
+		doc.info.DisplayStatus = displayStatus(doc.info)
+		if doc.info.CompletedAt == nil {
+			now := time.Now().Unix()
+			latency := float64(now - doc.info.UpdatedAt)
+			doc.info.CompletedAt = &now
+			doc.info.IndexingLatency = &latency
+		}
+		return
+	}
+	doc.info.IndexingStatus = "indexing"
+	doc.info.DisplayStatus = displayStatus(doc.info)
+}
+
+// updateDocumentByText 处理 POST /datasets/{dataset_id}/documents/{document_id}/update-by-text
+func (s *Server) updateDocumentByText(c *call) {
+	var req documentRequest
+	if !c.decode(&req) {
+		return
+	}
+	s.updateDocument(c, req, req.Text)
+}
+
+// updateDocumentByFile 处理 POST /datasets/{dataset_id}/documents/{document_id}/update-by-file
+func (s *Server) updateDocumentByFile(c *call) {
+	req, content, _, ok := c.multipartDocument()
+	if !ok {
+		return
+	}
+	s.updateDocument(c, req, &content)
+}
+
+// updateDocument 更新文档名称和内容，内容变化时重新分段
+func (s *Server) updateDocument(c *call, req documentRequest, content *string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return
+	}
+	if req.Name != "" {
+		doc.info.Name = req.Name
+	}
+	if req.DocType != "" {
+		docType := req.DocType
+		doc.info.DocType = &docType
+	}
+	if req.DocMetadata != nil {
+		doc.info.DocMetadata = docMetadata(req.DocMetadata)
+	}
+	if req.ProcessRule != nil {
+		doc.info.DocumentProcessRule = req.ProcessRule
+	}
+	if content != nil {
+		doc.batch = s.nextID("batch")
+		s.resegment(doc, *content, req.ProcessRule)
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"document": doc.info, "batch": doc.batch})
+}
+
+// listDocuments 处理 GET /datasets/{dataset_id}/documents
+func (s *Server) listDocuments(c *call) {
+	page, limit, keyword := c.pageParams()
+	status := c.r.URL.Query().Get("status")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	var matched []knowledge.Document
+	for i := len(ds.documentOrder) - 1; i >= 0; i-- {
+		doc := ds.documents[ds.documentOrder[i]]
+		if strings.Contains(doc.info.Name, keyword) && (status == "" || doc.info.DisplayStatus == status) {
+			// 列表接口不返回处理规则
+			info := doc.view()
+			info.DatasetProcessRule, info.DocumentProcessRule = nil, nil
+			matched = append(matched, info)
+		}
+	}
+	start, end, hasMore := paginate(len(matched), page, limit)
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{
+		"data":     nonNil(matched[start:end]),
+		"has_more": hasMore,
+		"limit":    limit,
+		"total":    len(matched),
+		"page":     page,
+	})
+}
+
+// getDocument 处理 GET /datasets/{dataset_id}/documents/{document_id}
+//
+// metadata 参数为 only 时仅返回 ID、文档类型和元数据，为 without 时不返回元数据。
+func (s *Server) getDocument(c *call) {
+	metadata := c.r.URL.Query().Get("metadata")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return
+	}
+	info := doc.view()
+	switch metadata {
+	case "", "all":
+	case "only":
+		writeJSON(c.w, http.StatusOK, map[string]interface{}{
+			"id": info.ID, "doc_type": info.DocType, "doc_metadata": nonNil(info.DocMetadata),
+		})
+		return
+	case "without":
+		info.DocMetadata = nil
+	default:
+		writeError(c.w, http.StatusBadRequest, "invalid_metadata", "Invalid metadata value: "+metadata)
+		return
+	}
+	writeJSON(c.w, http.StatusOK, info)
+}
+
+// view 返回带有分段数量的文档信息，调用方需要持有锁
+func (d *document) view() knowledge.Document {
+	info := d.info
+	info.SegmentCount = len(d.segments)
+	return info
+}
+
+// docMetadata 将请求中的元数据转换为按名称排序的字段列表
+func docMetadata(values map[string]interface{}) []knowledge.DocumentMetadata {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]knowledge.DocumentMetadata, 0, len(names))
+	for _, name := range names {
+		typ := knowledge.MetadataTypeString
+		if _, ok := values[name].(float64); ok {
+			typ = knowledge.MetadataTypeNumber
+		}
+		out = append(out, knowledge.DocumentMetadata{ID: name, Name: name, Type: typ, Value: values[name]})
+	}
+	return out
+}
+
+// deleteDocument 处理 DELETE /datasets/{dataset_id}/documents/{document_id}
+func (s *Server) deleteDocument(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return
+	}
+	delete(ds.documents, doc.info.ID)
+	for i, id := range ds.documentOrder {
+		if id == doc.info.ID {
+			ds.documentOrder = append(ds.documentOrder[:i:i], ds.documentOrder[i+1:]...)
+			break
+		}
+	}
+	ds.info.DocumentCount = len(ds.documents)
+	success(c.w)
+}
+
+// indexingStatus 处理 GET /datasets/{dataset_id}/documents/{batch}/indexing-status
+//
+// 每次查询推进一步索引进度，查询次数达到 WithIndexingSteps 后状态变为 completed。
+func (s *Server) indexingStatus(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+
+	var statuses []knowledge.DocumentIndexingStatus
+	for _, id := range ds.documentOrder {
+		doc := ds.documents[id]
+		if doc.batch != c.params["batch"] {
+			continue
+		}
+		doc.polls++
+		s.updateIndexing(doc)
+
+		statuses = append(statuses, s.indexingStatusOf(doc))
+	}
+	if len(statuses) == 0 {
+		writeError(c.w, http.StatusNotFound, "document_not_found", "Documents not found.")
+		return
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": statuses})
+}
+
+// indexingStatusOf 返回文档的索引进度，已完成分段数随查询次数线性增长，调用方需要持有锁
+func (s *Server) indexingStatusOf(doc *document) knowledge.DocumentIndexingStatus {
+	started := float64(doc.info.UpdatedAt)
+	status := knowledge.DocumentIndexingStatus{
+		ID:                   doc.info.ID,
+		IndexingStatus:       doc.info.IndexingStatus,
+		ProcessingStartedAt:  started,
+		ParsingCompletedAt:   started,
+		CleaningCompletedAt:  started,
+		SplittingCompletedAt: started,
+		Error:                doc.info.Error,
+		TotalSegments:        len(doc.segments),
+	}
+	switch {
+	case doc.info.IndexingStatus == "completed":
+		completed := float64(*doc.info.CompletedAt)
+		status.CompletedAt = &completed
+		status.CompletedSegments = len(doc.segments)
+	case doc.info.IndexingStatus == "indexing" && s.indexingSteps > 0:
+		status.CompletedSegments = len(doc.segments) * doc.polls / s.indexingSteps
+	}
+	return status
+}
+
+// listSegments 处理 GET /datasets/{dataset_id}/documents/{document_id}/segments
+func (s *Server) listSegments(c *call) {
+	query := c.r.URL.Query()
+	keyword := query.Get("keyword")
+	status := query.Get("status")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return
+	}
+	var matched []knowledge.Segment
+	for _, seg := range doc.segments {
+		if strings.Contains(seg.Content, keyword) && (status == "" || seg.Status == status) {
+			matched = append(matched, *seg)
+		}
+	}
+	page := intParam(query.Get("page"), 1)
+	limit := intParam(query.Get("limit"), 20)
+	start, end, hasMore := paginate(len(matched), page, limit)
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{
+		"data":     nonNil(matched[start:end]),
+		"doc_form": doc.info.DocForm,
+		"has_more": hasMore,
+		"limit":    limit,
+		"total":    len(matched),
+		"page":     page,
+	})
+}
+
+// segmentRequest 创建和更新分段的字段
+type segmentRequest struct {
+	Content               *string  `json:"content"`
+	Answer                *string  `json:"answer"`
+	Keywords              []string `json:"keywords"`
+	Enabled               *bool    `json:"enabled"`
+	RegenerateChildChunks bool     `json:"regenerate_child_chunks"`
+}
+
+// createSegments 处理 POST /datasets/{dataset_id}/documents/{document_id}/segments
+func (s *Server) createSegments(c *call) {
+	var req struct {
+		Segments []segmentRequest `json:"segments"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return
+	}
+	now := time.Now().Unix()
+	created := make([]knowledge.Segment, 0, len(req.Segments))
+	for _, r := range req.Segments {
+		if r.Content == nil || *r.Content == "" {
+			writeError(c.w, http.StatusBadRequest, "invalid_param", "content is required")
+			return
+		}
+		seg := &knowledge.Segment{
+			ID:         s.nextID("segment"),
+			Position:   len(doc.segments) + 1,
+			DocumentID: doc.info.ID,
+			Keywords:   []string{},
+			Enabled:    true,
+			Status:     "completed",
+			CreatedBy:  "api",
+			CreatedAt:  now,
+		}
+		applySegment(seg, r)
+		if doc.info.DocForm == docFormHierarchical {
+			s.rechunk(doc, seg)
+		}
+		doc.segments = append(doc.segments, seg)
+		created = append(created, *seg)
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": created, "doc_form": doc.info.DocForm})
+}
+
+// applySegment 将请求中出现的字段写入分段
+func applySegment(seg *knowledge.Segment, r segmentRequest) {
+	if r.Content != nil {
+		seg.Content = *r.Content
+		seg.WordCount = utf8.RuneCountInString(seg.Content)
+		seg.Tokens = tokens(seg.Content)
+	}
+	if r.Answer != nil {
+		answer := *r.Answer
+		seg.Answer = &answer
+	}
+	if r.Keywords != nil {
+		seg.Keywords = r.Keywords
+	}
+	if r.Enabled != nil {
+		seg.Enabled = *r.Enabled
+		if !seg.Enabled {
+			disabledAt := time.Now().Unix()
+			seg.DisabledAt = &disabledAt
+		} else {
+			seg.DisabledAt = nil
+		}
+	}
+}
+
+// lookupSegment 查找分段，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupSegment(c *call) (*document, int, bool) {
+	_, doc, ok := s.lookupDocument(c)
+	if !ok {
+		return nil, 0, false
+	}
+	for i, seg := range doc.segments {
+		if seg.ID == c.params["segment_id"] {
+			return doc, i, true
+		}
+	}
+	writeError(c.w, http.StatusNotFound, "segment_not_found", "Segment not found.")
+	return nil, 0, false
+}
+
+// getSegment 处理 GET /datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}
+func (s *Server) getSegment(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if doc, i, ok := s.lookupSegment(c); ok {
+		writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": doc.segments[i], "doc_form": doc.info.DocForm})
+	}
+}
+
+// updateSegment 处理 POST /datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}
+func (s *Server) updateSegment(c *call) {
+	var req struct {
+		Segment segmentRequest `json:"segment"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, i, ok := s.lookupSegment(c)
+	if !ok {
+		return
+	}
+	applySegment(doc.segments[i], req.Segment)
+	if doc.info.DocForm == docFormHierarchical && req.Segment.RegenerateChildChunks {
+		s.rechunk(doc, doc.segments[i])
+	}
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": doc.segments[i], "doc_form": doc.info.DocForm})
+}
+
+// deleteSegment 处理 DELETE /datasets/{dataset_id}/documents/{document_id}/segments/{segment_id}
+func (s *Server) deleteSegment(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, i, ok := s.lookupSegment(c)
+	if !ok {
+		return
+	}
+	doc.segments = append(doc.segments[:i:i], doc.segments[i+1:]...)
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// listChildChunks 处理 GET .../segments/{segment_id}/child_chunks
+func (s *Server) listChildChunks(c *call) {
+	page, limit, keyword := c.pageParams()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, i, ok := s.lookupSegment(c)
+	if !ok {
+		return
+	}
+	var matched []knowledge.ChildChunk
+	for _, chunk := range doc.segments[i].ChildChunks {
+		if strings.Contains(chunk.Content, keyword) {
+			matched = append(matched, chunk)
+		}
+	}
+	start, end, _ := paginate(len(matched), page, limit)
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{
+		"data":        nonNil(matched[start:end]),
+		"total":       len(matched),
+		"total_pages": (len(matched) + limit - 1) / limit,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// childChunkRequest 创建和更新子分段的字段
+type childChunkRequest struct {
+	Content string `json:"content"`
+}
+
+// createChildChunk 处理 POST .../segments/{segment_id}/child_chunks
+func (s *Server) createChildChunk(c *call) {
+	var req childChunkRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Content == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "content is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, i, ok := s.lookupSegment(c)
+	if !ok {
+		return
+	}
+	if doc.info.DocForm != docFormHierarchical {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "Child chunks are only supported in hierarchical mode.")
+		return
+	}
+	seg := doc.segments[i]
+	now := time.Now().Unix()
+	chunk := knowledge.ChildChunk{
+		ID:        s.nextID("child-chunk"),
+		SegmentID: seg.ID,
+		Content:   req.Content,
+		Position:  len(seg.ChildChunks) + 1,
+		WordCount: utf8.RuneCountInString(req.Content),
+		Type:      "customized",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	seg.ChildChunks = append(append([]knowledge.ChildChunk(nil), seg.ChildChunks...), chunk)
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": chunk})
+}
+
+// lookupChildChunk 查找子分段，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupChildChunk(c *call) (*knowledge.Segment, int, bool) {
+	doc, i, ok := s.lookupSegment(c)
+	if !ok {
+		return nil, 0, false
+	}
+	seg := doc.segments[i]
+	for j, chunk := range seg.ChildChunks {
+		if chunk.ID == c.params["child_chunk_id"] {
+			return seg, j, true
+		}
+	}
+	writeError(c.w, http.StatusNotFound, "child_chunk_not_found", "Child chunk not found.")
+	return nil, 0, false
+}
+
+// updateChildChunk 处理 PATCH .../child_chunks/{child_chunk_id}
+func (s *Server) updateChildChunk(c *call) {
+	var req childChunkRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Content == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "content is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg, j, ok := s.lookupChildChunk(c)
+	if !ok {
+		return
+	}
+	chunks := append([]knowledge.ChildChunk(nil), seg.ChildChunks...)
+	chunks[j].Content = req.Content
+	chunks[j].WordCount = utf8.RuneCountInString(req.Content)
+	chunks[j].Type = "customized"
+	chunks[j].UpdatedAt = time.Now().Unix()
+	seg.ChildChunks = chunks
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": chunks[j]})
+}
+
+// deleteChildChunk 处理 DELETE .../child_chunks/{child_chunk_id}
+func (s *Server) deleteChildChunk(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg, j, ok := s.lookupChildChunk(c)
+	if !ok {
+		return
+	}
+	seg.ChildChunks = append(seg.ChildChunks[:j:j], seg.ChildChunks[j+1:]...)
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// retrieve 处理 POST /datasets/{dataset_id}/retrieve，按查询词在分段中出现的比例打分
+func (s *Server) retrieve(c *call) {
+	var req knowledge.RetrieveRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Query == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "query is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	// 请求未指定检索参数时使用知识库的默认检索参数
+	topK := 4
+	threshold := 0.0
+	rm := req.RetrievalModel
+	if rm == nil {
+		rm = ds.info.RetrievalModelDict
+	}
+	var filter *knowledge.MetadataFilter
+	if rm != nil {
+		if msg := checkRetrievalModel(rm); msg != "" {
+			writeError(c.w, http.StatusBadRequest, "invalid_param", msg)
+			return
+		}
+		if rm.TopK > 0 {
+			topK = rm.TopK
+		}
+		if rm.ScoreThresholdEnabled {
+			threshold = rm.ScoreThreshold
+		}
+		filter = rm.MetadataFilteringConditions
+	}
+	terms := strings.Fields(strings.ToLower(req.Query))
+	if len(terms) == 0 {
+		terms = []string{strings.ToLower(req.Query)}
+	}
+	records := []knowledge.Record{}
+	for _, id := range ds.documentOrder {
+		doc := ds.documents[id]
+		if !doc.info.Enabled || doc.info.Archived || !matchMetadata(doc.info.DocMetadata, filter) {
+			continue
+		}
+		for _, seg := range doc.segments {
+			if !seg.Enabled {
+				continue
+			}
+			score := termScore(seg.Content, terms)
+			var children []knowledge.RetrievedChildChunk
+			if doc.info.DocForm == docFormHierarchical {
+				// 父子分段模式按子分段打分，返回命中的子分段和父分段
+				score = 0
+				for _, chunk := range seg.ChildChunks {
+					chunkScore := termScore(chunk.Content, terms)
+					if chunkScore == 0 || chunkScore < threshold {
+						continue
+					}
+					children = append(children, knowledge.RetrievedChildChunk{
+						ID: chunk.ID, Content: chunk.Content, Position: chunk.Position, Score: chunkScore,
+					})
+					if chunkScore > score {
+						score = chunkScore
+					}
+				}
+			}
+			if score == 0 || score < threshold {
+				continue
+			}
+			record := knowledge.Record{Segment: *seg, ChildChunks: children, Score: score}
+			docInfo := doc.info
+			record.Segment.Document = &docInfo
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Score > records[j].Score })
+	if len(records) > topK {
+		records = records[:topK]
+	}
+	for _, record := range records {
+		for _, seg := range ds.documents[record.Segment.DocumentID].segments {
+			if seg.ID == record.Segment.ID {
+				seg.HitCount++
+			}
+		}
+	}
+
+	var resp knowledge.RetrieveResponse
+	resp.Query.Content = req.Query
+	resp.Records = records
+	writeJSON(c.w, http.StatusOK, resp)
+}
+
+// termScore 返回查询词在内容中出现的比例
+func termScore(content string, terms []string) float64 {
+	content = strings.ToLower(content)
+	hits := 0
+	for _, term := range terms {
+		if strings.Contains(content, term) {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(terms))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,375 @@
+package difytest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// builtInMetadataFields 内置元数据字段名称，不能用作自定义字段
+var builtInMetadataFields = map[string]bool{
+	"document_name": true, "uploader": true, "upload_date": true, "last_update_date": true, "source": true,
+}
+
+// listMetadata 处理 GET /datasets/{dataset_id}/metadata
+func (s *Server) listMetadata(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	fields := make([]knowledge.MetadataField, 0, len(ds.metadata))
+	for _, field := range ds.metadata {
+		f := *field
+		f.Count = ds.metadataCount(field.ID)
+		fields = append(fields, f)
+	}
+	writeJSON(c.w, http.StatusOK, knowledge.ListMetadataFieldsResponse{
+		DocMetadata:         fields,
+		BuiltInFieldEnabled: ds.builtInMetadata,
+	})
+}
+
+// createMetadata 处理 POST /datasets/{dataset_id}/metadata
+func (s *Server) createMetadata(c *call) {
+	var req struct {
+		Name string                 `json:"name"`
+		Type knowledge.MetadataType `json:"type"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	switch req.Type {
+	case knowledge.MetadataTypeString, knowledge.MetadataTypeNumber, knowledge.MetadataTypeTime:
+	default:
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "Invalid metadata type.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok || !ds.checkMetadataName(c, req.Name) {
+		return
+	}
+	field := &knowledge.MetadataField{ID: s.nextID("metadata"), Name: req.Name, Type: req.Type}
+	ds.metadata = append(ds.metadata, field)
+	writeJSON(c.w, http.StatusCreated, field)
+}
+
+// renameMetadata 处理 PATCH /datasets/{dataset_id}/metadata/{metadata_id}，同步修改文档上的字段名
+func (s *Server) renameMetadata(c *call) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	field, ok := ds.lookupMetadata(c)
+	if !ok || !ds.checkMetadataName(c, req.Name) {
+		return
+	}
+	field.Name = req.Name
+	for _, doc := range ds.documents {
+		doc.info.DocMetadata = doc.mapMetadata(func(m knowledge.DocumentMetadata) (knowledge.DocumentMetadata, bool) {
+			if m.ID == field.ID {
+				m.Name = req.Name
+			}
+			return m, true
+		})
+	}
+	writeJSON(c.w, http.StatusOK, field)
+}
+
+// deleteMetadata 处理 DELETE /datasets/{dataset_id}/metadata/{metadata_id}，同时删除文档上的值
+func (s *Server) deleteMetadata(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	field, ok := ds.lookupMetadata(c)
+	if !ok {
+		return
+	}
+	for i, f := range ds.metadata {
+		if f == field {
+			ds.metadata = append(ds.metadata[:i:i], ds.metadata[i+1:]...)
+			break
+		}
+	}
+	for _, doc := range ds.documents {
+		doc.info.DocMetadata = doc.mapMetadata(func(m knowledge.DocumentMetadata) (knowledge.DocumentMetadata, bool) {
+			return m, m.ID != field.ID
+		})
+	}
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// builtInMetadata 处理 POST /datasets/{dataset_id}/metadata/built-in/{action}
+func (s *Server) builtInMetadata(c *call) {
+	action := c.params["action"]
+	if action != "enable" && action != "disable" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "Invalid action.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ds, ok := s.lookupDataset(c); ok {
+		ds.builtInMetadata = action == "enable"
+		success(c.w)
+	}
+}
+
+// updateDocumentsMetadata 处理 POST /datasets/{dataset_id}/documents/metadata
+//
+// 每个文档的元数据被请求中的列表整体替换，字段必须已在知识库中创建。
+func (s *Server) updateDocumentsMetadata(c *call) {
+	var req struct {
+		OperationData []knowledge.DocumentMetadataUpdate `json:"operation_data"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	// 先校验全部文档和字段，避免部分更新
+	updates := make(map[*document][]knowledge.DocumentMetadata)
+	for _, op := range req.OperationData {
+		doc, ok := ds.documents[op.DocumentID]
+		if !ok {
+			writeError(c.w, http.StatusNotFound, "document_not_found", "Document not found.")
+			return
+		}
+		values := make([]knowledge.DocumentMetadata, 0, len(op.MetadataList))
+		for _, m := range op.MetadataList {
+			field := ds.metadataByID(m.ID)
+			if field == nil {
+				writeError(c.w, http.StatusNotFound, "metadata_not_found", "Metadata not found: "+m.ID)
+				return
+			}
+			values = append(values, knowledge.DocumentMetadata{ID: field.ID, Name: field.Name, Type: field.Type, Value: m.Value})
+		}
+		updates[doc] = values
+	}
+	for doc, values := range updates {
+		doc.info.DocMetadata = values
+	}
+	success(c.w)
+}
+
+// lookupMetadata 查找元数据字段，不存在时返回 404，调用方需要持有锁
+func (ds *dataset) lookupMetadata(c *call) (*knowledge.MetadataField, bool) {
+	if field := ds.metadataByID(c.params["metadata_id"]); field != nil {
+		return field, true
+	}
+	writeError(c.w, http.StatusNotFound, "metadata_not_found", "Metadata not found.")
+	return nil, false
+}
+
+// metadataByID 按ID查找元数据字段，调用方需要持有锁
+func (ds *dataset) metadataByID(id string) *knowledge.MetadataField {
+	for _, field := range ds.metadata {
+		if field.ID == id {
+			return field
+		}
+	}
+	return nil
+}
+
+// checkMetadataName 检查字段名称非空、不与内置字段和已有字段重复，调用方需要持有锁
+func (ds *dataset) checkMetadataName(c *call, name string) bool {
+	if name == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "name is required")
+		return false
+	}
+	if builtInMetadataFields[name] {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "Metadata name already exists in built-in fields.")
+		return false
+	}
+	for _, field := range ds.metadata {
+		if field.Name == name {
+			writeError(c.w, http.StatusBadRequest, "invalid_param", "Metadata name already exists.")
+			return false
+		}
+	}
+	return true
+}
+
+// metadataCount 返回设置了该字段的文档数，调用方需要持有锁
+func (ds *dataset) metadataCount(id string) int {
+	count := 0
+	for _, doc := range ds.documents {
+		for _, m := range doc.info.DocMetadata {
+			if m.ID == id {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// mapMetadata 返回转换后的文档元数据副本，fn 返回 false 的值被删除
+func (d *document) mapMetadata(fn func(knowledge.DocumentMetadata) (knowledge.DocumentMetadata, bool)) []knowledge.DocumentMetadata {
+	if d.info.DocMetadata == nil {
+		return nil
+	}
+	out := make([]knowledge.DocumentMetadata, 0, len(d.info.DocMetadata))
+	for _, m := range d.info.DocMetadata {
+		if m, ok := fn(m); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// checkRetrievalModel 检查检索参数，合法时返回空字符串
+func checkRetrievalModel(rm *knowledge.RetrievalModel) string {
+	switch rm.SearchMethod {
+	case "", knowledge.SearchMethodSemantic, knowledge.SearchMethodFullText, knowledge.SearchMethodKeyword:
+	case knowledge.SearchMethodHybrid:
+		if rm.RerankingMode == knowledge.RerankingModeWeighted {
+			w := rm.Weights
+			if w == nil || w.KeywordSetting == nil || w.VectorSetting == nil {
+				return "weights is required when reranking_mode is weighted_score"
+			}
+			if sum := w.KeywordSetting.KeywordWeight + w.VectorSetting.VectorWeight; sum < 0.999 || sum > 1.001 {
+				return "keyword_weight and vector_weight must sum to 1"
+			}
+		}
+	default:
+		return "Invalid search method: " + rm.SearchMethod
+	}
+	if rm.RerankingEnable && (rm.RerankingModel == nil || rm.RerankingModel.ModelName == "") {
+		return "reranking_model is required when reranking is enabled"
+	}
+	if f := rm.MetadataFilteringConditions; f != nil {
+		if f.LogicalOperator != "and" && f.LogicalOperator != "or" {
+			return "Invalid logical operator: " + f.LogicalOperator
+		}
+		for _, cond := range f.Conditions {
+			if !validOperators[cond.ComparisonOperator] {
+				return "Invalid comparison operator: " + cond.ComparisonOperator
+			}
+		}
+	}
+	return ""
+}
+
+// validOperators 支持的元数据比较运算符
+var validOperators = map[string]bool{
+	knowledge.OperatorContains: true, knowledge.OperatorNotContains: true,
+	knowledge.OperatorStartWith: true, knowledge.OperatorEndWith: true,
+	knowledge.OperatorIs: true, knowledge.OperatorIsNot: true,
+	knowledge.OperatorEmpty: true, knowledge.OperatorNotEmpty: true,
+	knowledge.OperatorEqual: true, knowledge.OperatorNotEqual: true,
+	knowledge.OperatorGreater: true, knowledge.OperatorLess: true,
+	knowledge.OperatorGreaterEq: true, knowledge.OperatorLessEq: true,
+	knowledge.OperatorBefore: true, knowledge.OperatorAfter: true,
+}
+
+// matchMetadata 判断文档元数据是否满足过滤条件，filter 为 nil 时总是满足
+func matchMetadata(values []knowledge.DocumentMetadata, filter *knowledge.MetadataFilter) bool {
+	if filter == nil || len(filter.Conditions) == 0 {
+		return true
+	}
+	for _, cond := range filter.Conditions {
+		var value interface{}
+		for _, m := range values {
+			if m.Name == cond.Name {
+				value = m.Value
+				break
+			}
+		}
+		matched := matchCondition(value, cond)
+		if filter.LogicalOperator == "or" && matched {
+			return true
+		}
+		if filter.LogicalOperator != "or" && !matched {
+			return false
+		}
+	}
+	return filter.LogicalOperator != "or"
+}
+
+// matchCondition 判断单个字段值是否满足条件，value 为 nil 表示未设置
+func matchCondition(value interface{}, cond knowledge.MetadataCondition) bool {
+	switch cond.ComparisonOperator {
+	case knowledge.OperatorEmpty:
+		return value == nil || value == ""
+	case knowledge.OperatorNotEmpty:
+		return value != nil && value != ""
+	}
+	if value == nil {
+		return false
+	}
+	switch cond.ComparisonOperator {
+	case knowledge.OperatorEqual, knowledge.OperatorNotEqual, knowledge.OperatorGreater, knowledge.OperatorLess,
+		knowledge.OperatorGreaterEq, knowledge.OperatorLessEq, knowledge.OperatorBefore, knowledge.OperatorAfter:
+		a, ok1 := number(value)
+		b, ok2 := number(cond.Value)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch cond.ComparisonOperator {
+		case knowledge.OperatorEqual:
+			return a == b
+		case knowledge.OperatorNotEqual:
+			return a != b
+		case knowledge.OperatorGreater, knowledge.OperatorAfter:
+			return a > b
+		case knowledge.OperatorLess, knowledge.OperatorBefore:
+			return a < b
+		case knowledge.OperatorGreaterEq:
+			return a >= b
+		default:
+			return a <= b
+		}
+	}
+	str, want := fmt.Sprint(value), fmt.Sprint(cond.Value)
+	switch cond.ComparisonOperator {
+	case knowledge.OperatorIs:
+		return str == want
+	case knowledge.OperatorIsNot:
+		return str != want
+	case knowledge.OperatorContains:
+		return strings.Contains(str, want)
+	case knowledge.OperatorNotContains:
+		return !strings.Contains(str, want)
+	case knowledge.OperatorStartWith:
+		return strings.HasPrefix(str, want)
+	default:
+		return strings.HasSuffix(str, want)
+	}
+}
+
+// number 将 JSON 数值转换为 float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
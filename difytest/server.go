@@ -0,0 +1,451 @@
+// Package difytest 提供基于 httptest 的 Dify 模拟服务，用于离线测试
+//
+//	srv := difytest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.DifyClient()
+//	kb := srv.KnowledgeClient()
+//
+// 模拟服务实现了应用 API（对话、文本生成、工作流、文件上传、反馈、停止响应、应用信息与参数、会话和消息）
+// 以及知识库 API（知识库、文档、分段、检索），状态保存在内存中。
+// 回答内容、工作流节点和输出可以通过 SetResponder、SetWorkflow 编排，
+// 也可以通过 Handle 替换任意端点；InjectFault 和 SetLatency 用于模拟错误和延迟。
+package difytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// Server Dify 模拟服务
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	apiKey         string
+	latency        time.Duration
+	streamInterval time.Duration
+	indexingSteps  int
+
+	routes   []*route
+	custom   []*route
+	faults   []*Fault
+	requests []Request
+	seq      map[string]int
+
+	responder Responder
+	nodes     []WorkflowNode
+	workflow  WorkflowFunc
+	appInfo   dify.AppInfo
+	params    dify.AppParameters
+
+	conversations map[string]*Conversation
+	messages      map[string]*Message
+	messageOrder  []string
+	files         map[string]*uploadedFile
+	stopped       map[string]bool
+
+	datasets     map[string]*dataset
+	datasetOrder []string
+	tags         []*knowledge.Tag
+}
+
+// Option 模拟服务选项
+type Option func(*Server)
+
+// WithAPIKey 要求请求携带指定的 API Key，未设置时接受任意非空 Bearer Token
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithLatency 设置每个请求返回前的延迟
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithStreamInterval 设置流式响应中相邻事件的间隔
+func WithStreamInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.streamInterval = interval
+	}
+}
+
+// WithIndexingSteps 设置文档需要查询多少次索引状态后才变为 completed，默认为 0 即创建后立即完成
+func WithIndexingSteps(steps int) Option {
+	return func(s *Server) {
+		s.indexingSteps = steps
+	}
+}
+
+// NewServer 创建并启动模拟服务，使用完毕后需要调用 Close
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		seq:           make(map[string]int),
+		responder:     EchoResponder,
+		nodes:         DefaultWorkflowNodes,
+		appInfo:       dify.AppInfo{Name: "difytest", Description: "Dify fake server", Tags: []string{}},
+		conversations: make(map[string]*Conversation),
+		messages:      make(map[string]*Message),
+		files:         make(map[string]*uploadedFile),
+		stopped:       make(map[string]bool),
+		datasets:      make(map[string]*dataset),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.registerAppRoutes()
+	s.registerDatasetRoutes()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// DifyClient 创建指向模拟服务的应用客户端
+func (s *Server) DifyClient(opts ...dify.ClientOption) *dify.Client {
+	opts = append([]dify.ClientOption{dify.WithBaseURL(s.URL)}, opts...)
+	return dify.NewClient(s.key(), opts...)
+}
+
+// KnowledgeClient 创建指向模拟服务的知识库客户端
+func (s *Server) KnowledgeClient(opts ...knowledge.Option) *knowledge.Client {
+	opts = append([]knowledge.Option{knowledge.WithBaseURL(s.URL)}, opts...)
+	return knowledge.NewClient(s.key(), opts...)
+}
+
+func (s *Server) key() string {
+	if s.apiKey != "" {
+		return s.apiKey
+	}
+	return "difytest-key"
+}
+
+// Request 模拟服务收到的请求
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Requests 返回收到的所有请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// SetLatency 修改每个请求返回前的延迟
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// SetStreamInterval 修改流式响应中相邻事件的间隔
+func (s *Server) SetStreamInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streamInterval = interval
+}
+
+// Handle 替换或新增一个端点，pattern 形如 /chat-messages 或 /datasets/{dataset_id}/retrieve，
+// 自定义端点优先于内置实现
+func (s *Server) Handle(method, pattern string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.custom = append([]*route{newRoute(method, pattern, func(c *call) {
+		c.r.Body = io.NopCloser(bytes.NewReader(c.body))
+		handler(c.w, c.r)
+	})}, s.custom...)
+}
+
+// Fault 注入的错误
+type Fault struct {
+	// Method 匹配的请求方法，为空时匹配所有方法
+	Method string
+	// Path 匹配的路由模式或请求路径，为空时匹配所有请求
+	Path string
+	// Status HTTP 状态码，默认为 500
+	Status int
+	// Code、Message 错误响应中的错误码和描述
+	Code    string
+	Message string
+	// Times 生效次数，0 表示一直生效
+	Times int
+	// Delay 返回错误前的延迟
+	Delay time.Duration
+	// AfterEvents 大于 0 时流式请求先正常返回指定数量的事件，再发送 error 事件并结束
+	AfterEvents int
+}
+
+// InjectFault 注入错误，多个错误按注入顺序匹配
+func (s *Server) InjectFault(fault Fault) {
+	if fault.Status == 0 {
+		fault.Status = http.StatusInternalServerError
+	}
+	if fault.Code == "" {
+		fault.Code = "internal_server_error"
+	}
+	if fault.Message == "" {
+		fault.Message = http.StatusText(fault.Status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults 清除所有注入的错误
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault 返回匹配的错误并扣减生效次数
+func (s *Server) takeFault(r *http.Request, rt *route) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if f.Path != "" && f.Path != rt.pattern && f.Path != r.URL.Path {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		copied := *f
+		return &copied
+	}
+	return nil
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	latency := s.latency
+	rt, params := s.match(r)
+	var fault *Fault
+	if rt != nil {
+		fault = s.takeFault(r, rt)
+	}
+	s.mu.Unlock()
+
+	if !sleep(r, latency) {
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "Access token is invalid")
+		return
+	}
+	if rt == nil {
+		writeError(w, http.StatusNotFound, "not_found", "The requested URL was not found on the server.")
+		return
+	}
+	if fault != nil && fault.AfterEvents == 0 {
+		if sleep(r, fault.Delay) {
+			writeError(w, fault.Status, fault.Code, fault.Message)
+		}
+		return
+	}
+
+	rt.handler(&call{w: w, r: r, body: body, params: params, fault: fault})
+}
+
+// authorized 校验 Authorization 请求头
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.apiKey != "" {
+		return token == s.apiKey
+	}
+	return token != "" && token != r.Header.Get("Authorization")
+}
+
+// nextID 生成带前缀的顺序ID，调用方需要持有锁
+func (s *Server) nextID(prefix string) string {
+	s.seq[prefix]++
+	return fmt.Sprintf("%s-%d", prefix, s.seq[prefix])
+}
+
+// call 一次请求的上下文
+type call struct {
+	w      http.ResponseWriter
+	r      *http.Request
+	body   []byte
+	params map[string]string
+	fault  *Fault
+}
+
+// decode 解析 JSON 请求体，失败时返回 400
+func (c *call) decode(v interface{}) bool {
+	if err := json.Unmarshal(c.body, v); err != nil {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// route 一个端点
+type route struct {
+	method  string
+	pattern string
+	parts   []string
+	handler func(c *call)
+}
+
+func newRoute(method, pattern string, handler func(c *call)) *route {
+	return &route{
+		method:  method,
+		pattern: pattern,
+		parts:   strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler: handler,
+	}
+}
+
+// handle 注册内置端点
+func (s *Server) handle(method, pattern string, handler func(c *call)) {
+	s.routes = append(s.routes, newRoute(method, pattern, handler))
+}
+
+// match 查找请求对应的端点，调用方需要持有锁
+func (s *Server) match(r *http.Request) (*route, map[string]string) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, routes := range [][]*route{s.custom, s.routes} {
+		for _, rt := range routes {
+			if rt.method != r.Method || len(rt.parts) != len(parts) {
+				continue
+			}
+			params := make(map[string]string)
+			matched := true
+			for i, part := range rt.parts {
+				if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+					params[part[1:len(part)-1]] = parts[i]
+					continue
+				}
+				if part != parts[i] {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return rt, params
+			}
+		}
+	}
+	return nil, nil
+}
+
+// sleep 等待 d，请求被取消时返回 false
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写入 Dify 格式的错误响应
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, dify.DifyError{Code: code, Message: message, Status: status})
+}
+
+// success 写入 {"result":"success"}
+func success(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// sseWriter 写入 SSE 事件，处理事件间隔和流式错误注入
+type sseWriter struct {
+	w        http.ResponseWriter
+	r        *http.Request
+	interval time.Duration
+	fault    *Fault
+	taskID   string
+	sent     int
+	stopped  bool
+}
+
+func (s *Server) newSSE(c *call, taskID string) *sseWriter {
+	s.mu.Lock()
+	interval := s.streamInterval
+	s.mu.Unlock()
+
+	c.w.Header().Set("Content-Type", "text/event-stream")
+	c.w.Header().Set("Cache-Control", "no-cache")
+	c.w.WriteHeader(http.StatusOK)
+	return &sseWriter{w: c.w, r: c.r, interval: interval, fault: c.fault, taskID: taskID}
+}
+
+// send 写入一个事件，流已结束时返回 false
+func (e *sseWriter) send(event interface{}) bool {
+	if e.stopped {
+		return false
+	}
+	if e.sent > 0 && !sleep(e.r, e.interval) {
+		e.stopped = true
+		return false
+	}
+	if e.fault != nil && e.sent >= e.fault.AfterEvents {
+		e.write(map[string]interface{}{
+			"event":   "error",
+			"task_id": e.taskID,
+			"status":  e.fault.Status,
+			"code":    e.fault.Code,
+			"message": e.fault.Message,
+		})
+		e.stopped = true
+		return false
+	}
+	e.write(event)
+	e.sent++
+	return true
+}
+
+func (e *sseWriter) write(event interface{}) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(e.w, "data: %s\n\n", data)
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,936 @@
+package difytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// 测试对话、流式对话、会话延续、反馈和停止响应
+func TestChat(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetResponder(func(req AppRequest) string {
+		return "answer to " + req.Query
+	})
+	client := srv.DifyClient()
+
+	resp, err := client.CreateChat(&dify.ChatRequest{Query: "hello", User: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Answer != "answer to hello" || resp.ConversationId == "" {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	handler := &collectHandler{}
+	err = client.CreateStreamingChat(&dify.ChatRequest{
+		Query:          "a longer question for streaming",
+		User:           "u1",
+		ConversationId: resp.ConversationId,
+	}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(handler.answers, ""); got != "answer to a longer question for streaming" {
+		t.Fatalf("streamed answer = %q", got)
+	}
+	if len(handler.answers) < 2 || !handler.ended {
+		t.Fatalf("chunks = %d, ended = %v", len(handler.answers), handler.ended)
+	}
+	if msgs := srv.Messages(resp.ConversationId); len(msgs) != 2 {
+		t.Fatalf("messages = %d, want 2", len(msgs))
+	}
+
+	_, err = client.CreateChat(&dify.ChatRequest{Query: "hi", User: "u1", ConversationId: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("error = %v, want 404", err)
+	}
+
+	if err := client.SendFeedback(resp.MessageID, &dify.FeedbackRequest{Rating: "like", User: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := srv.Messages(resp.ConversationId); msgs[0].Feedback == nil || msgs[0].Feedback.Rating != "like" {
+		t.Fatal("feedback not recorded")
+	}
+	if err := client.StopResponse("task-9", "u1"); err != nil || !srv.Stopped("task-9") {
+		t.Fatalf("stop error = %v", err)
+	}
+	if err := client.ConversationsDel(resp.ConversationId, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Conversation(resp.ConversationId); ok {
+		t.Fatal("conversation should be deleted")
+	}
+}
+
+// 测试工作流的节点事件和失败状态
+func TestWorkflow(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.DifyClient()
+
+	var events []string
+	streaming := srv.DifyClient(dify.WithMiddleware(recordEvents(&events)))
+	err := streaming.WorkflowRunStreaming(dify.WorkflowRequest{
+		Inputs:       map[string]interface{}{"query": "x"},
+		ResponseMode: dify.ResponseModeStreaming,
+		User:         "u1",
+	}, &collectHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "workflow_started,node_started,node_finished,node_started,node_finished,node_started,node_finished,workflow_finished"
+	if strings.Join(events, ",") != want {
+		t.Fatalf("events = %v", events)
+	}
+
+	srv.SetWorkflow(nil, func(inputs map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	resp, err := client.WorkflowRun(dify.WorkflowRequest{User: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Status != "failed" || resp.Data.Error != "boom" {
+		t.Fatalf("workflow data = %+v", resp.Data)
+	}
+}
+
+// 测试文件上传、应用信息和 API Key 校验
+func TestUploadAndAuth(t *testing.T) {
+	srv := NewServer(WithAPIKey("app-secret"))
+	defer srv.Close()
+	srv.SetAppInfo(dify.AppInfo{Name: "bot"})
+
+	path := filepath.Join(t.TempDir(), "note.txt")
+	if err := os.WriteFile(path, []byte("hello file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := srv.DifyClient().UploadFile(path, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, ok := srv.UploadedFile(file.ID); !ok || string(content) != "hello file" || file.Extension != "txt" {
+		t.Fatalf("file = %+v", file)
+	}
+
+	info, err := srv.DifyClient().GetAppInfo()
+	if err != nil || info.Name != "bot" {
+		t.Fatalf("info = %+v, err = %v", info, err)
+	}
+	if _, err := dify.NewClient("wrong", dify.WithBaseURL(srv.URL)).GetAppInfo(); err == nil {
+		t.Fatal("expected unauthorized error")
+	}
+}
+
+// 测试注入 HTTP 错误、流式中途错误和延迟
+func TestFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.DifyClient()
+
+	srv.InjectFault(Fault{Path: dify.EndpointChat, Status: http.StatusTooManyRequests, Code: "rate_limit", Times: 1})
+	if _, err := client.CreateChat(&dify.ChatRequest{Query: "hi", User: "u1"}); err == nil || !strings.Contains(err.Error(), "rate_limit") {
+		t.Fatalf("error = %v, want rate_limit", err)
+	}
+	if _, err := client.CreateChat(&dify.ChatRequest{Query: "hi", User: "u1"}); err != nil {
+		t.Fatalf("fault should apply once: %v", err)
+	}
+
+	srv.InjectFault(Fault{Path: dify.EndpointChat, Code: "completion_request_error", AfterEvents: 1})
+	var events []string
+	streaming := srv.DifyClient(dify.WithMiddleware(recordEvents(&events)))
+	streaming.CreateStreamingChat(&dify.ChatRequest{Query: "one two three", User: "u1"}, &collectHandler{})
+	if strings.Join(events, ",") != "message,error" {
+		t.Fatalf("events = %v, want message,error", events)
+	}
+	srv.ClearFaults()
+
+	srv.SetLatency(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow := srv.DifyClient().WithContext(ctx)
+	if _, err := slow.GetAppInfo(); err == nil {
+		t.Fatal("expected timeout")
+	}
+}
+
+// 测试知识库、文档、索引进度、分段和检索
+func TestKnowledge(t *testing.T) {
+	srv := NewServer(WithIndexingSteps(2))
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name:              "faq",
+		Text:              "Go is a programming language\nDify builds LLM apps\nGo SDK for Dify",
+		IndexingTechnique: "high_quality",
+		DocForm:           "text_model",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Batch == "" || result.Document.IndexingStatus != "indexing" {
+		t.Fatalf("result = %+v", result)
+	}
+	if segs := srv.Segments(ds.ID, result.Document.ID); len(segs) != 3 {
+		t.Fatalf("segments = %d, want 3", len(segs))
+	}
+
+	status, err := kb.GetDocumentIndexingStatus(ctx, ds.ID, result.Batch)
+	if err != nil || status.ID != result.Document.ID || status.IndexingStatus != "indexing" || status.TotalSegments != 3 {
+		t.Fatalf("status = %+v, err = %v", status, err)
+	}
+	batch, err := kb.GetBatchIndexingStatus(ctx, ds.ID, result.Batch)
+	if err != nil || len(batch.Data) != 1 || !batch.AllCompleted() || batch.Progress() != 100 || len(batch.Failed()) != 0 {
+		t.Fatalf("batch = %+v, err = %v", batch, err)
+	}
+	var difyErr *dify.DifyError
+	if _, err := kb.GetBatchIndexingStatus(ctx, ds.ID, "missing"); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+	if docs := srv.Documents(ds.ID); docs[0].IndexingStatus != "completed" {
+		t.Fatalf("indexing status = %s, want completed", docs[0].IndexingStatus)
+	}
+
+	retrieved, err := kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "go dify"}, dify.WithRequestHeader("X-Trace", "kb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reqs := srv.Requests(); reqs[len(reqs)-1].Header.Get("X-Trace") != "kb" {
+		t.Fatal("request option not applied")
+	}
+	if len(retrieved.Records) != 3 || retrieved.Records[0].Segment.Content != "Go SDK for Dify" {
+		t.Fatalf("records = %+v", retrieved.Records)
+	}
+
+	var segments struct {
+		Data []knowledge.Segment `json:"data"`
+	}
+	if err := kb.Do(ctx, http.MethodGet, "/datasets/"+ds.ID+"/documents/"+result.Document.ID+"/segments", nil, &segments); err != nil {
+		t.Fatal(err)
+	}
+	if len(segments.Data) != 3 {
+		t.Fatalf("raw segments = %d, want 3", len(segments.Data))
+	}
+
+	list, err := kb.ListKnowledge(ctx, &knowledge.ListKnowledgeRequest{Keyword: "doc"})
+	if err != nil || list.Total != 1 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+	if err := kb.DeleteDocument(ctx, ds.ID, result.Document.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.DeleteKnowledge(ctx, ds.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 测试分段的查询、新增、更新和删除
+func TestUpdateKnowledge(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "faq", Text: "go one\ngo two\ngo three", IndexingTechnique: "high_quality",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := kb.UpdateKnowledge(ctx, ds.ID, &knowledge.UpdateKnowledgeRequest{
+		Name:                   knowledge.String("handbook"),
+		Description:            knowledge.String("team handbook"),
+		Permission:             knowledge.String(knowledge.PermissionPartialMembers),
+		PartialMemberList:      []knowledge.PartialMember{{UserID: "u1"}, {UserID: "u2"}},
+		EmbeddingModelProvider: knowledge.String("openai"),
+		EmbeddingModel:         knowledge.String("text-embedding-3-small"),
+		RetrievalModel:         &knowledge.RetrievalModel{SearchMethod: "semantic_search", TopK: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "handbook" || updated.Description != "team handbook" || strings.Join(updated.PartialMemberList, ",") != "u1,u2" || updated.EmbeddingModel != "text-embedding-3-small" {
+		t.Fatalf("updated = %+v", updated)
+	}
+	got, err := kb.GetKnowledge(ctx, ds.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Permission != knowledge.PermissionPartialMembers || got.EmbeddingModelProvider != "openai" ||
+		got.RetrievalModelDict == nil || got.RetrievalModelDict.TopK != 2 || got.IndexingTechnique != "high_quality" {
+		t.Fatalf("dataset = %+v", got)
+	}
+
+	// 指向空字符串的字段会被清空，nil 字段保持不变
+	cleared, err := kb.UpdateKnowledge(ctx, ds.ID, &knowledge.UpdateKnowledgeRequest{Description: knowledge.String("")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared.Description != "" || cleared.Name != "handbook" || cleared.Permission != knowledge.PermissionPartialMembers {
+		t.Fatalf("cleared = %+v", cleared)
+	}
+
+	// 未指定检索参数时使用知识库的默认检索参数
+	retrieved, err := kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "go"})
+	if err != nil || len(retrieved.Records) != 2 {
+		t.Fatalf("records = %+v, err = %v", retrieved, err)
+	}
+
+	if _, err := kb.UpdateKnowledge(ctx, ds.ID, &knowledge.UpdateKnowledgeRequest{Permission: knowledge.String(knowledge.PermissionPartialMembers)}); !errors.Is(err, knowledge.ErrInvalidRequest) {
+		t.Fatalf("error = %v, want ErrInvalidRequest", err)
+	}
+	var difyErr *dify.DifyError
+	if _, err := kb.UpdateKnowledge(ctx, ds.ID, &knowledge.UpdateKnowledgeRequest{IndexingTechnique: knowledge.String("fast")}); !errors.As(err, &difyErr) || !dify.IsInvalidParam(difyErr) {
+		t.Fatalf("error = %v, want invalid_param", err)
+	}
+	if _, err := kb.GetKnowledge(ctx, "missing"); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	docs, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	faq, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "faq"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	team, err := kb.CreateKnowledgeTag(ctx, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := kb.CreateKnowledgeTag(ctx, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Type != "knowledge" {
+		t.Fatalf("tag = %+v", team)
+	}
+
+	if err := kb.BindKnowledgeTags(ctx, docs.ID, []string{team.ID, public.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.BindKnowledgeTags(ctx, faq.ID, []string{public.ID}); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := kb.ListKnowledgeTags(ctx)
+	if err != nil || len(tags) != 2 || tags[0].BindingCount != 1 || tags[1].BindingCount != 2 {
+		t.Fatalf("tags = %+v, err = %v", tags, err)
+	}
+
+	// 按多个标签过滤时返回绑定了其中任一标签的知识库
+	list, err := kb.ListKnowledge(ctx, &knowledge.ListKnowledgeRequest{TagIDs: []string{team.ID, public.ID}})
+	if err != nil || list.Total != 2 || list.Data[0].ID != faq.ID || list.Data[1].ID != docs.ID {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+	list, err = kb.ListKnowledge(ctx, &knowledge.ListKnowledgeRequest{TagIDs: []string{team.ID}})
+	if err != nil || len(list.Data) != 1 || list.Data[0].ID != docs.ID || len(list.Data[0].Tags) != 2 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+
+	if _, err := kb.RenameKnowledgeTag(ctx, public.ID, "shared"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.UnbindKnowledgeTag(ctx, docs.ID, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	bound, err := kb.GetKnowledgeTags(ctx, docs.ID)
+	if err != nil || len(bound) != 1 || bound[0].Name != "shared" {
+		t.Fatalf("tags = %+v, err = %v", bound, err)
+	}
+
+	if err := kb.DeleteKnowledgeTag(ctx, public.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := kb.GetKnowledge(ctx, faq.ID)
+	if err != nil || len(got.Tags) != 0 {
+		t.Fatalf("dataset = %+v, err = %v", got, err)
+	}
+
+	var difyErr *dify.DifyError
+	if err := kb.BindKnowledgeTags(ctx, docs.ID, []string{public.ID}); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+	if err := kb.DeleteKnowledgeTag(ctx, ""); !errors.Is(err, knowledge.ErrInvalidTagID) {
+		t.Fatalf("error = %v, want ErrInvalidTagID", err)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docIDs []string
+	for _, name := range []string{"a", "b"} {
+		result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{Name: name, Text: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		docIDs = append(docIDs, result.Document.ID)
+	}
+
+	author, err := kb.CreateMetadataField(ctx, ds.ID, "author", knowledge.MetadataTypeString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := kb.CreateMetadataField(ctx, ds.ID, "version", knowledge.MetadataTypeNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var difyErr *dify.DifyError
+	if _, err := kb.CreateMetadataField(ctx, ds.ID, "author", knowledge.MetadataTypeString); !errors.As(err, &difyErr) || !dify.IsInvalidParam(difyErr) {
+		t.Fatalf("error = %v, want duplicate name", err)
+	}
+	if _, err := kb.CreateMetadataField(ctx, ds.ID, "size", "bytes"); !errors.Is(err, knowledge.ErrInvalidRequest) {
+		t.Fatalf("error = %v, want ErrInvalidRequest", err)
+	}
+
+	err = kb.UpdateDocumentsMetadata(ctx, ds.ID, []knowledge.DocumentMetadataUpdate{
+		{DocumentID: docIDs[0], MetadataList: []knowledge.DocumentMetadata{{ID: author.ID, Name: "author", Value: "ann"}, {ID: version.ID, Name: "version", Value: 2}}},
+		{DocumentID: docIDs[1], MetadataList: []knowledge.DocumentMetadata{{ID: author.ID, Name: "author", Value: "bob"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.RenameMetadataField(ctx, ds.ID, author.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.DeleteMetadataField(ctx, ds.ID, version.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.SetBuiltInMetadata(ctx, ds.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := kb.ListMetadataFields(ctx, ds.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fields.BuiltInFieldEnabled || len(fields.DocMetadata) != 1 || fields.DocMetadata[0].Name != "owner" || fields.DocMetadata[0].Count != 2 {
+		t.Fatalf("fields = %+v", fields)
+	}
+	doc, err := kb.GetDocument(ctx, ds.ID, docIDs[0], knowledge.MetadataOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.DocMetadata) != 1 || doc.DocMetadata[0].Name != "owner" || doc.DocMetadata[0].Value != "ann" || doc.DocMetadata[0].Type != knowledge.MetadataTypeString {
+		t.Fatalf("metadata = %+v", doc.DocMetadata)
+	}
+
+	err = kb.UpdateDocumentsMetadata(ctx, ds.ID, []knowledge.DocumentMetadataUpdate{
+		{DocumentID: docIDs[0], MetadataList: []knowledge.DocumentMetadata{{ID: version.ID, Value: 3}}},
+	})
+	if !errors.As(err, &difyErr) || difyErr.Code != "metadata_not_found" {
+		t.Fatalf("error = %v, want metadata_not_found", err)
+	}
+	if err := kb.DeleteMetadataField(ctx, ds.ID, ""); !errors.Is(err, knowledge.ErrInvalidMetadataID) {
+		t.Fatalf("error = %v, want ErrInvalidMetadataID", err)
+	}
+}
+
+func TestRetrieveBuilder(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant, _ := kb.CreateMetadataField(ctx, ds.ID, "tenant", knowledge.MetadataTypeString)
+	year, _ := kb.CreateMetadataField(ctx, ds.ID, "year", knowledge.MetadataTypeNumber)
+	published, _ := kb.CreateMetadataField(ctx, ds.ID, "published", knowledge.MetadataTypeTime)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var updates []knowledge.DocumentMetadataUpdate
+	for i, owner := range []string{"acme", "acme", "globex"} {
+		result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+			Name: fmt.Sprintf("policy-%d", i), Text: fmt.Sprintf("refund policy %d", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		updates = append(updates, knowledge.DocumentMetadataUpdate{DocumentID: result.Document.ID, MetadataList: []knowledge.DocumentMetadata{
+			{ID: tenant.ID, Name: "tenant", Value: owner},
+			{ID: year.ID, Name: "year", Value: 2022 + i},
+			{ID: published.ID, Name: "published", Value: base.AddDate(0, i, 0).Unix()},
+		}})
+	}
+	if err := kb.UpdateDocumentsMetadata(ctx, ds.ID, updates); err != nil {
+		t.Fatal(err)
+	}
+
+	retrieve := func(b *knowledge.RetrieveBuilder) []string {
+		t.Helper()
+		req, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := kb.Retrieve(ctx, ds.ID, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, record := range resp.Records {
+			names = append(names, record.Segment.Document.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	got := retrieve(knowledge.NewRetrieveRequest("refund").HybridSearch(0.3, 0.7).TopK(5).
+		MatchAll(knowledge.Is("tenant", "acme"), knowledge.GreaterOrEqual("year", 2023)))
+	if strings.Join(got, ",") != "policy-1" {
+		t.Fatalf("and filter = %v", got)
+	}
+	got = retrieve(knowledge.NewRetrieveRequest("refund").
+		MatchAny(knowledge.Is("tenant", "globex"), knowledge.Before("published", base.AddDate(0, 0, 1))))
+	if strings.Join(got, ",") != "policy-0,policy-2" {
+		t.Fatalf("or filter = %v", got)
+	}
+
+	req, err := knowledge.NewRetrieveRequest("refund").HybridSearch(0.4, 0.6).ScoreThreshold(0.5).
+		MatchAll(knowledge.StartsWith("tenant", "ac")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm := req.RetrievalModel
+	if rm.SearchMethod != knowledge.SearchMethodHybrid || rm.RerankingMode != knowledge.RerankingModeWeighted ||
+		rm.Weights.VectorSetting.VectorWeight != 0.6 || rm.TopK != 3 || !rm.ScoreThresholdEnabled {
+		t.Fatalf("retrieval model = %+v", rm)
+	}
+	if _, err := kb.Retrieve(ctx, ds.ID, req); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if body := string(reqs[len(reqs)-1].Body); !strings.Contains(body, `"keyword_weight":0.4`) || !strings.Contains(body, `"comparison_operator":"start with"`) {
+		t.Fatalf("body = %s", body)
+	}
+
+	if req, _ := knowledge.NewRetrieveRequest("refund").Build(); req.RetrievalModel != nil {
+		t.Fatal("retrieval model should be nil when no option is set")
+	}
+	_, err = knowledge.NewRetrieveRequest("refund").HybridSearch(0.5, 0.6).MatchAll(knowledge.Is("a", "b")).MatchAny(knowledge.Is("c", "d")).Build()
+	if !errors.Is(err, knowledge.ErrInvalidRequest) || !strings.Contains(err.Error(), "sum to 1") || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+			Name:              fmt.Sprintf("doc-%d", i),
+			Text:              "alpha\nbeta",
+			IndexingTechnique: "economy",
+			DocMetadata:       map[string]interface{}{"author": "ann", "version": 2},
+			ProcessRule:       &knowledge.ProcessRule{Mode: "custom", Segmentation: &knowledge.SegmentationRule{Separator: "\n"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := kb.ListDocuments(ctx, ds.ID, &knowledge.ListDocumentsRequest{Keyword: "doc-", Status: "available", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || len(page.Data) != 2 || !page.HasMore || page.Data[0].DocumentProcessRule != nil {
+		t.Fatalf("page = %+v", page)
+	}
+
+	var names []string
+	it := kb.Documents(ctx, ds.ID, &knowledge.ListDocumentsRequest{Limit: 2})
+	for it.Next() {
+		names = append(names, it.Document().Name)
+	}
+	if it.Err() != nil || it.Total() != 5 || strings.Join(names, ",") != "doc-4,doc-3,doc-2,doc-1,doc-0" {
+		t.Fatalf("names = %v, total = %d, err = %v", names, it.Total(), it.Err())
+	}
+
+	doc, err := kb.GetDocument(ctx, ds.ID, page.Data[0].ID, knowledge.MetadataAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.SegmentCount != 2 || doc.CompletedAt == nil || doc.DocumentProcessRule == nil || doc.DocumentProcessRule.Mode != "custom" ||
+		len(doc.DocMetadata) != 2 || doc.DocMetadata[1].Name != "version" || doc.DocMetadata[1].Type != "number" {
+		t.Fatalf("document = %+v", doc)
+	}
+	only, err := kb.GetDocument(ctx, ds.ID, doc.ID, knowledge.MetadataOnly)
+	if err != nil || only.ID != doc.ID || only.Name != "" || len(only.DocMetadata) != 2 {
+		t.Fatalf("only = %+v, err = %v", only, err)
+	}
+	without, err := kb.GetDocument(ctx, ds.ID, doc.ID, knowledge.MetadataWithout)
+	if err != nil || without.Name != doc.Name || without.DocMetadata != nil {
+		t.Fatalf("without = %+v, err = %v", without, err)
+	}
+
+	var difyErr *dify.DifyError
+	if _, err := kb.GetDocument(ctx, ds.ID, "missing", ""); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+	it = kb.Documents(ctx, "missing", nil)
+	if it.Next() || !errors.As(it.Err(), &difyErr) {
+		t.Fatalf("iterator error = %v", it.Err())
+	}
+}
+
+func TestWaitForIndexing(t *testing.T) {
+	srv := NewServer(WithIndexingSteps(3))
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "faq", Text: "a\nb\nc\nd\ne\nf", IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var completed []int
+	ch := make(chan knowledge.IndexingProgress, 10)
+	statuses, err := kb.WaitForIndexing(ctx, ds.ID, result.Batch,
+		knowledge.WithPollInterval(time.Millisecond),
+		knowledge.WithProgress(func(p knowledge.IndexingProgress) { completed = append(completed, p.CompletedSegments) }),
+		knowledge.WithProgressChannel(ch),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].IndexingStatus != knowledge.IndexingStatusCompleted || statuses[0].CompletedAt == nil {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	if fmt.Sprint(completed) != "[2 4 6]" || len(ch) != 3 {
+		t.Fatalf("completed = %v, channel = %d", completed, len(ch))
+	}
+
+	failed, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "broken", Text: "x", IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.FailIndexing(ds.ID, failed.Document.ID, "embedding quota exceeded")
+	_, err = kb.WaitForIndexing(ctx, ds.ID, failed.Batch, knowledge.WithPollInterval(time.Millisecond))
+	var indexingErr *knowledge.IndexingError
+	if !errors.As(err, &indexingErr) || !errors.Is(err, knowledge.ErrIndexingFailed) ||
+		len(indexingErr.Failed) != 1 || *indexingErr.Failed[0].Error != "embedding quota exceeded" {
+		t.Fatalf("error = %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	pending, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{Name: "slow", Text: "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.WaitForIndexing(waitCtx, ds.ID, pending.Batch, knowledge.WithPollInterval(time.Hour)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestDocumentStatus(t *testing.T) {
+	srv := NewServer(WithIndexingSteps(1))
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "stale", Text: "refund within 7 days", IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.GetBatchIndexingStatus(ctx, ds.ID, stale.Batch); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "fresh", Text: "refund within 30 days\nshipping is free", IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 仍在索引中的文档被拒绝，其余文档照常停用
+	err = kb.UpdateDocumentsStatus(ctx, ds.ID, knowledge.DocumentActionDisable, []string{stale.Document.ID, fresh.Document.ID})
+	var statusErr *knowledge.StatusUpdateError
+	if !errors.As(err, &statusErr) || len(statusErr.Failed) != 1 || statusErr.Failed[0].ID != fresh.Document.ID ||
+		strings.Join(statusErr.Succeeded, ",") != stale.Document.ID {
+		t.Fatalf("error = %v", err)
+	}
+	var difyErr *dify.DifyError
+	if !errors.As(err, &difyErr) || difyErr.Code != "document_indexing" {
+		t.Fatalf("error = %v, want document_indexing", err)
+	}
+
+	if _, err := kb.GetBatchIndexingStatus(ctx, ds.ID, fresh.Batch); err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "refund"})
+	if err != nil || len(retrieved.Records) != 1 || retrieved.Records[0].Segment.DocumentID != fresh.Document.ID {
+		t.Fatalf("records = %+v, err = %v", retrieved, err)
+	}
+
+	if err := kb.UpdateDocumentsStatus(ctx, ds.ID, knowledge.DocumentActionArchive, []string{stale.Document.ID}); err != nil {
+		t.Fatal(err)
+	}
+	archived, err := kb.ListDocuments(ctx, ds.ID, &knowledge.ListDocumentsRequest{Status: "archived"})
+	if err != nil || len(archived.Data) != 1 || !archived.Data[0].Archived || archived.Data[0].Enabled {
+		t.Fatalf("documents = %+v, err = %v", archived, err)
+	}
+	err = kb.UpdateDocumentsStatus(ctx, ds.ID, knowledge.DocumentActionEnable, []string{stale.Document.ID})
+	if !errors.As(err, &statusErr) || !errors.As(err, &difyErr) || difyErr.Code != "archived_document_immutable" {
+		t.Fatalf("error = %v, want archived_document_immutable", err)
+	}
+
+	// 知识库不存在时直接返回批量请求的错误，不逐个文档重试
+	sent := len(srv.Requests())
+	err = kb.UpdateDocumentsStatus(ctx, "missing", knowledge.DocumentActionDisable, []string{stale.Document.ID, fresh.Document.ID})
+	if errors.As(err, &statusErr) || !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+	if n := len(srv.Requests()) - sent; n != 1 {
+		t.Fatalf("sent %d requests, want 1", n)
+	}
+	if err := kb.UpdateDocumentsStatus(ctx, ds.ID, "delete", []string{stale.Document.ID}); !errors.Is(err, knowledge.ErrInvalidRequest) {
+		t.Fatalf("error = %v, want ErrInvalidRequest", err)
+	}
+
+	// 停用单个分段后该分段不再被检索到
+	segments := srv.Segments(ds.ID, fresh.Document.ID)
+	err = kb.SetSegmentsEnabled(ctx, ds.ID, fresh.Document.ID, false, []string{segments[0].ID, "missing"})
+	if !errors.As(err, &statusErr) || len(statusErr.Failed) != 1 || statusErr.Failed[0].ID != "missing" {
+		t.Fatalf("error = %v", err)
+	}
+	retrieved, err = kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "refund"})
+	if err != nil || len(retrieved.Records) != 0 {
+		t.Fatalf("records = %+v, err = %v", retrieved, err)
+	}
+	if err := kb.SetSegmentsEnabled(ctx, ds.ID, fresh.Document.ID, true, []string{segments[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := kb.GetSegment(ctx, ds.ID, fresh.Document.ID, segments[0].ID)
+	if err != nil || !got.Enabled || got.Content != segments[0].Content {
+		t.Fatalf("segment = %+v, err = %v", got, err)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name: "faq", Text: "alpha\nbeta\ngamma", IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docID := result.Document.ID
+
+	created, err := kb.CreateSegments(ctx, ds.ID, docID, &knowledge.CreateSegmentsRequest{Segments: []knowledge.SegmentInput{
+		{Content: "what is dify", Answer: "an LLM app platform", Keywords: []string{"dify"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].Answer == nil || *created[0].Answer != "an LLM app platform" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	page, err := kb.ListSegments(ctx, ds.ID, docID, &knowledge.ListSegmentsRequest{Page: 2, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 4 || len(page.Data) != 1 || page.HasMore || page.Data[0].ID != created[0].ID {
+		t.Fatalf("page = %+v", page)
+	}
+	filtered, err := kb.ListSegments(ctx, ds.ID, docID, &knowledge.ListSegmentsRequest{Keyword: "beta", Status: "completed"})
+	if err != nil || filtered.Total != 1 {
+		t.Fatalf("filtered = %+v, err = %v", filtered, err)
+	}
+
+	disabled := false
+	updated, err := kb.UpdateSegment(ctx, ds.ID, docID, created[0].ID, &knowledge.UpdateSegmentRequest{
+		Content: "what is Dify?", Answer: "an open-source LLM app platform", Enabled: &disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != "what is Dify?" || updated.Enabled {
+		t.Fatalf("updated = %+v", updated)
+	}
+	got, err := kb.GetSegment(ctx, ds.ID, docID, created[0].ID)
+	if err != nil || got.Content != "what is Dify?" || strings.Join(got.Keywords, ",") != "dify" {
+		t.Fatalf("segment = %+v, err = %v", got, err)
+	}
+
+	if err := kb.DeleteSegment(ctx, ds.ID, docID, created[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	var difyErr *dify.DifyError
+	if _, err := kb.GetSegment(ctx, ds.ID, docID, created[0].ID); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
+	if _, err := kb.GetSegment(ctx, ds.ID, docID, ""); !errors.Is(err, knowledge.ErrInvalidParagraphID) {
+		t.Fatalf("error = %v, want ErrInvalidParagraphID", err)
+	}
+}
+
+func TestChildChunks(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	kb := srv.KnowledgeClient()
+	ctx := context.Background()
+
+	ds, err := kb.CreateKnowledge(ctx, &knowledge.CreateKnowledgeRequest{Name: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := kb.CreateDocumentByText(ctx, ds.ID, &knowledge.CreateDocumentByTextRequest{
+		Name:              "guide",
+		Text:              "Go is fast\nGo is simple\n\nDify builds apps\nDify hosts models",
+		IndexingTechnique: "high_quality",
+		DocForm:           "hierarchical_model",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docID := result.Document.ID
+	segs, err := kb.ListSegments(ctx, ds.ID, docID, nil)
+	if err != nil || len(segs.Data) != 2 || len(segs.Data[0].ChildChunks) != 2 {
+		t.Fatalf("segments = %+v, err = %v", segs, err)
+	}
+	segID := segs.Data[1].ID
+
+	chunk, err := kb.CreateChildChunk(ctx, ds.ID, docID, segID, "Dify runs workflows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk.SegmentID != segID || chunk.Position != 3 {
+		t.Fatalf("chunk = %+v", chunk)
+	}
+	chunk, err = kb.UpdateChildChunk(ctx, ds.ID, docID, segID, chunk.ID, "Dify runs agent workflows")
+	if err != nil || chunk.Content != "Dify runs agent workflows" {
+		t.Fatalf("chunk = %+v, err = %v", chunk, err)
+	}
+	page, err := kb.ListChildChunks(ctx, ds.ID, docID, segID, &knowledge.ListChildChunksRequest{Keyword: "Dify", Page: 2, Limit: 2})
+	if err != nil || page.Total != 3 || len(page.Data) != 1 || page.Data[0].ID != chunk.ID {
+		t.Fatalf("page = %+v, err = %v", page, err)
+	}
+
+	retrieved, err := kb.Retrieve(ctx, ds.ID, &knowledge.RetrieveRequest{Query: "workflows"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrieved.Records) != 1 || retrieved.Records[0].Segment.ID != segID ||
+		len(retrieved.Records[0].ChildChunks) != 1 || retrieved.Records[0].ChildChunks[0].ID != chunk.ID {
+		t.Fatalf("records = %+v", retrieved.Records)
+	}
+
+	if err := kb.DeleteChildChunk(ctx, ds.ID, docID, segID, chunk.ID); err != nil {
+		t.Fatal(err)
+	}
+	var difyErr *dify.DifyError
+	if _, err := kb.UpdateChildChunk(ctx, ds.ID, docID, segID, chunk.ID, "gone"); !errors.As(err, &difyErr) || difyErr.Code != "child_chunk_not_found" {
+		t.Fatalf("error = %v, want child_chunk_not_found", err)
+	}
+	if err := kb.DeleteChildChunk(ctx, ds.ID, docID, segID, ""); !errors.Is(err, knowledge.ErrInvalidChildChunkID) {
+		t.Fatalf("error = %v, want ErrInvalidChildChunkID", err)
+	}
+}
+
+// recordEvents 返回记录流式事件类型的中间件
+func recordEvents(events *[]string) dify.Middleware {
+	return func(next dify.Handler) dify.Handler {
+		return func(op *dify.Operation, req *http.Request) (*http.Response, error) {
+			op.OnStreamEvent(func(ev dify.StreamEvent) { *events = append(*events, ev.Event) })
+			return next(op, req)
+		}
+	}
+}
+
+// collectHandler 收集流式回答
+type collectHandler struct {
+	answers []string
+	ended   bool
+}
+
+func (h *collectHandler) OnMessage(response *dify.MessageStreamResponse) error {
+	if response.Event == "message_end" {
+		h.ended = true
+		return nil
+	}
+	h.answers = append(h.answers, response.Answer)
+	return nil
+}
+
+func (h *collectHandler) OnMessageWorkflow(response *dify.WorkflowStreamResponse) error { return nil }
+
+func (h *collectHandler) OnMessageEnd(response *dify.MessageEndStreamResponse) error {
+	h.ended = true
+	return nil
+}
+
+func (h *collectHandler) OnTTS(response *dify.TTSStreamResponse) error { return nil }
+
+func (h *collectHandler) OnTTSEnd(response *dify.TTSStreamResponse) error { return nil }
+
+func (h *collectHandler) OnError(err error) error { return err }
@@ -0,0 +1,92 @@
+package difytest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// updateDocumentsStatus 处理 PATCH /datasets/{dataset_id}/documents/status/{action}
+//
+// 与 Dify 一样先检查全部文档，任一文档不满足条件时整个请求失败，不修改任何文档。
+func (s *Server) updateDocumentsStatus(c *call) {
+	var req struct {
+		DocumentIDs []string `json:"document_ids"`
+	}
+	if !c.decode(&req) {
+		return
+	}
+	action := knowledge.DocumentAction(c.params["action"])
+	switch action {
+	case knowledge.DocumentActionEnable, knowledge.DocumentActionDisable, knowledge.DocumentActionArchive, knowledge.DocumentActionUnarchive:
+	default:
+		writeError(c.w, http.StatusBadRequest, "invalid_action", "Invalid action.")
+		return
+	}
+	if len(req.DocumentIDs) == 0 {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "document_ids is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	docs := make([]*document, 0, len(req.DocumentIDs))
+	for _, id := range req.DocumentIDs {
+		doc, ok := ds.documents[id]
+		if !ok {
+			writeError(c.w, http.StatusNotFound, "document_not_found", "Document "+id+" not found.")
+			return
+		}
+		switch {
+		case doc.info.IndexingStatus != "completed" && (action == knowledge.DocumentActionEnable || action == knowledge.DocumentActionDisable):
+			writeError(c.w, http.StatusBadRequest, "document_indexing", "Document "+doc.info.Name+" is being indexed, please try again later.")
+			return
+		case doc.info.Archived && action == knowledge.DocumentActionEnable:
+			writeError(c.w, http.StatusForbidden, "archived_document_immutable", "The archived document is not editable.")
+			return
+		}
+		docs = append(docs, doc)
+	}
+
+	now := time.Now().Unix()
+	for _, doc := range docs {
+		switch action {
+		case knowledge.DocumentActionEnable:
+			doc.info.Enabled = true
+			doc.info.DisabledAt = nil
+		case knowledge.DocumentActionDisable:
+			if doc.info.Enabled {
+				disabledAt := now
+				doc.info.Enabled = false
+				doc.info.DisabledAt = &disabledAt
+			}
+		case knowledge.DocumentActionArchive:
+			doc.info.Archived = true
+		case knowledge.DocumentActionUnarchive:
+			doc.info.Archived = false
+		}
+		doc.info.UpdatedAt = now
+		doc.info.DisplayStatus = displayStatus(doc.info)
+	}
+	success(c.w)
+}
+
+// displayStatus 根据索引、归档和启用状态计算文档的 display_status
+func displayStatus(info knowledge.Document) string {
+	switch {
+	case info.IndexingStatus == "error":
+		return "error"
+	case info.IndexingStatus != "completed":
+		return "indexing"
+	case info.Archived:
+		return "archived"
+	case !info.Enabled:
+		return "disabled"
+	}
+	return "available"
+}
@@ -0,0 +1,218 @@
+package difytest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hb1707/dify-go-sdk/knowledge"
+)
+
+// tagRequest 标签接口的请求字段
+type tagRequest struct {
+	TagID    string   `json:"tag_id"`
+	TagIDs   []string `json:"tag_ids"`
+	Name     string   `json:"name"`
+	TargetID string   `json:"target_id"`
+}
+
+// createTag 处理 POST /datasets/tags
+func (s *Server) createTag(c *call) {
+	var req tagRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag := &knowledge.Tag{ID: s.nextID("tag"), Name: req.Name, Type: "knowledge"}
+	s.tags = append(s.tags, tag)
+	writeJSON(c.w, http.StatusOK, tag)
+}
+
+// listTags 处理 GET /datasets/tags，binding_count 与 Dify 一样以字符串返回
+func (s *Server) listTags(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags := make([]map[string]string, 0, len(s.tags))
+	for _, tag := range s.tags {
+		count := 0
+		for _, ds := range s.datasets {
+			if ds.hasTags([]string{tag.ID}) {
+				count++
+			}
+		}
+		tags = append(tags, map[string]string{
+			"id": tag.ID, "name": tag.Name, "type": tag.Type, "binding_count": strconv.Itoa(count),
+		})
+	}
+	writeJSON(c.w, http.StatusOK, tags)
+}
+
+// renameTag 处理 PATCH /datasets/tags
+func (s *Server) renameTag(c *call) {
+	var req tagRequest
+	if !c.decode(&req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tag, ok := s.lookupTag(c, req.TagID); ok {
+		tag.Name = req.Name
+		writeJSON(c.w, http.StatusOK, tag)
+	}
+}
+
+// deleteTag 处理 DELETE /datasets/tags，同时解除全部绑定
+func (s *Server) deleteTag(c *call) {
+	var req tagRequest
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag, ok := s.lookupTag(c, req.TagID)
+	if !ok {
+		return
+	}
+	for i, t := range s.tags {
+		if t == tag {
+			s.tags = append(s.tags[:i:i], s.tags[i+1:]...)
+			break
+		}
+	}
+	for _, ds := range s.datasets {
+		ds.unbind(tag.ID)
+	}
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// bindTags 处理 POST /datasets/tags/binding
+func (s *Server) bindTags(c *call) {
+	var req tagRequest
+	if !c.decode(&req) {
+		return
+	}
+	if len(req.TagIDs) == 0 {
+		writeError(c.w, http.StatusBadRequest, "invalid_param", "tag_ids is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupTarget(c, req.TargetID)
+	if !ok {
+		return
+	}
+	for _, id := range req.TagIDs {
+		if _, ok := s.lookupTag(c, id); !ok {
+			return
+		}
+	}
+	for _, id := range req.TagIDs {
+		if !ds.hasTags([]string{id}) {
+			ds.tagIDs = append(ds.tagIDs, id)
+		}
+	}
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// unbindTag 处理 POST /datasets/tags/unbinding
+func (s *Server) unbindTag(c *call) {
+	var req tagRequest
+	if !c.decode(&req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupTarget(c, req.TargetID)
+	if !ok {
+		return
+	}
+	if _, ok := s.lookupTag(c, req.TagID); !ok {
+		return
+	}
+	ds.unbind(req.TagID)
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// datasetTags 处理 GET /datasets/{dataset_id}/tags
+func (s *Server) datasetTags(c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ds, ok := s.lookupDataset(c)
+	if !ok {
+		return
+	}
+	tags := s.datasetView(ds).Tags
+	writeJSON(c.w, http.StatusOK, map[string]interface{}{"data": tags, "total": len(tags)})
+}
+
+// datasetView 返回带有绑定标签的知识库信息，调用方需要持有锁
+func (s *Server) datasetView(ds *dataset) knowledge.Knowledge {
+	info := ds.info
+	info.Tags = []knowledge.Tag{}
+	for _, id := range ds.tagIDs {
+		for _, tag := range s.tags {
+			if tag.ID == id {
+				info.Tags = append(info.Tags, knowledge.Tag{ID: tag.ID, Name: tag.Name, Type: tag.Type})
+			}
+		}
+	}
+	return info
+}
+
+// lookupTag 查找标签，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupTag(c *call, id string) (*knowledge.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.ID == id {
+			return tag, true
+		}
+	}
+	writeError(c.w, http.StatusNotFound, "not_found", "Tag not found.")
+	return nil, false
+}
+
+// lookupTarget 查找绑定目标知识库，不存在时返回 404，调用方需要持有锁
+func (s *Server) lookupTarget(c *call, id string) (*dataset, bool) {
+	if ds, ok := s.datasets[id]; ok {
+		return ds, true
+	}
+	writeError(c.w, http.StatusNotFound, "dataset_not_found", "Dataset not found.")
+	return nil, false
+}
+
+// hasTags 判断知识库是否绑定了任一标签，与 Dify 一样 tagIDs 为空时不过滤
+func (ds *dataset) hasTags(tagIDs []string) bool {
+	if len(tagIDs) == 0 {
+		return true
+	}
+	for _, id := range tagIDs {
+		for _, bound := range ds.tagIDs {
+			if bound == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unbind 解除标签绑定
+func (ds *dataset) unbind(tagID string) {
+	for i, id := range ds.tagIDs {
+		if id == tagID {
+			ds.tagIDs = append(ds.tagIDs[:i:i], ds.tagIDs[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package transport 存放 dify 与 knowledge 共享的请求管线实现，不属于 SDK 的公开 API
+//
+// 依赖 dify 包类型的函数无法在这里直接实现（dify 会导入本包），由 dify 包在初始化时设置到下面的变量中，
+// 变量类型为 interface{}，注释中给出了实际的函数类型，使用方需要先做类型断言。
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// DecodeError 将非 2xx 响应转换为 *dify.DifyError，由 dify 包设置
+	DecodeError func(resp *http.Response) error
+
+	// RequestOptionsMiddleware 返回应用单次请求选项的中间件，由 dify 包设置
+	//
+	// 实际类型为 func(baseURL string, opts ...dify.RequestOption) dify.Middleware
+	RequestOptionsMiddleware interface{}
+
+	// OpenRawStream 检查流式响应的状态码并返回原始事件流，由 dify 包设置
+	//
+	// 实际类型为 func(op *dify.Operation, resp *http.Response) (*dify.RawStream, error)
+	OpenRawStream interface{}
+)
+
+// EncodeBody 按 Do 的规则编码请求体，并返回对应的 Content-Type
+//
+// 请求体为 JSON 时 contentType 为 application/json；nil、[]byte 和 io.Reader 无法确定内容类型，contentType 为空。
+func EncodeBody(body interface{}) (data []byte, contentType string, err error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case json.RawMessage:
+		return b, "application/json", nil
+	case []byte:
+		return b, "", nil
+	case io.Reader:
+		data, err := io.ReadAll(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		return data, "", nil
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal request: %w", err)
+		}
+		return data, "application/json", nil
+	}
+}
+
+// DecodeResponse 检查响应状态码并将响应 JSON 解码到 out，非 2xx 响应通过 DecodeError 转换为错误
+func DecodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return DecodeError(resp)
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
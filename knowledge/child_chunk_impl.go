@@ -0,0 +1,104 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// ListChildChunks 查询父子分段模式下某个分段的子分段
+func (c *Client) ListChildChunks(ctx context.Context, datasetID string, documentID string, segmentID string, req *ListChildChunksRequest, opts ...dify.RequestOption) (*ListChildChunksResponse, error) {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if req != nil {
+		if req.Keyword != "" {
+			query.Set("keyword", req.Keyword)
+		}
+		if req.Page > 0 {
+			query.Set("page", strconv.Itoa(req.Page))
+		}
+		if req.Limit > 0 {
+			query.Set("limit", strconv.Itoa(req.Limit))
+		}
+	}
+	path := childChunksPath(datasetID, documentID, segmentID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var result ListChildChunksResponse
+	op := &dify.Operation{Name: "ListChildChunks", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodGet, path, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateChildChunk 在分段下新增子分段
+func (c *Client) CreateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, content string, opts ...dify.RequestOption) (*ChildChunk, error) {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%w: content is empty", ErrInvalidRequest)
+	}
+
+	var result childChunkResponse
+	op := &dify.Operation{Name: "CreateChildChunk", DatasetID: datasetID, DocumentID: documentID}
+	body := map[string]string{"content": content}
+	if err := c.call(ctx, op, http.MethodPost, childChunksPath(datasetID, documentID, segmentID), body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// UpdateChildChunk 更新子分段内容
+func (c *Client) UpdateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, content string, opts ...dify.RequestOption) (*ChildChunk, error) {
+	if err := checkChildChunk(datasetID, documentID, segmentID, childChunkID); err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%w: content is empty", ErrInvalidRequest)
+	}
+
+	var result childChunkResponse
+	op := &dify.Operation{Name: "UpdateChildChunk", DatasetID: datasetID, DocumentID: documentID}
+	body := map[string]string{"content": content}
+	if err := c.call(ctx, op, http.MethodPatch, childChunksPath(datasetID, documentID, segmentID)+"/"+childChunkID, body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// DeleteChildChunk 删除子分段
+func (c *Client) DeleteChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, opts ...dify.RequestOption) error {
+	if err := checkChildChunk(datasetID, documentID, segmentID, childChunkID); err != nil {
+		return err
+	}
+
+	op := &dify.Operation{Name: "DeleteChildChunk", DatasetID: datasetID, DocumentID: documentID}
+	return c.call(ctx, op, http.MethodDelete, childChunksPath(datasetID, documentID, segmentID)+"/"+childChunkID, nil, nil, opts)
+}
+
+// childChunksPath 返回分段的子分段路径
+func childChunksPath(datasetID, documentID, segmentID string) string {
+	return segmentsPath(datasetID, documentID) + "/" + segmentID + "/child_chunks"
+}
+
+// checkChildChunk 检查知识库、文档、分段和子分段ID
+func checkChildChunk(datasetID, documentID, segmentID, childChunkID string) error {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return err
+	}
+	if childChunkID == "" {
+		return ErrInvalidChildChunkID
+	}
+	return nil
+}
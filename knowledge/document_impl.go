@@ -8,6 +8,8 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+
+	"github.com/hb1707/dify-go-sdk/dify"
 )
 
 type Result struct {
@@ -16,7 +18,7 @@ type Result struct {
 }
 
 // CreateDocumentByText 通过文本创建文档
-func (c *Client) CreateDocumentByText(ctx context.Context, datasetID string, req *CreateDocumentByTextRequest) (*Result, error) {
+func (c *Client) CreateDocumentByText(ctx context.Context, datasetID string, req *CreateDocumentByTextRequest, opts ...dify.RequestOption) (*Result, error) {
 	url := fmt.Sprintf("%s/datasets/%s/document/create-by-text", c.baseURL, datasetID)
 
 	jsonData, err := json.Marshal(req)
@@ -32,7 +34,7 @@ func (c *Client) CreateDocumentByText(ctx context.Context, datasetID string, req
 	httpReq.Header.Set("Authorization", c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "CreateDocumentByText", DatasetID: datasetID}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("do request failed: %w", err)
 	}
@@ -46,13 +48,15 @@ func (c *Client) CreateDocumentByText(ctx context.Context, datasetID string, req
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("decode response failed: %w", err)
 	}
-	fmt.Printf("batch: %v", result.Batch)
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, "document created", "dataset_id", datasetID, "document_id", result.Document.ID, "batch", result.Batch)
+	}
 
 	return &result, nil
 }
 
 // CreateDocumentByFile 通过文件创建文档
-func (c *Client) CreateDocumentByFile(ctx context.Context, datasetID string, req *CreateDocumentByFileRequest, file io.Reader) (*Result, error) {
+func (c *Client) CreateDocumentByFile(ctx context.Context, datasetID string, req *CreateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*Result, error) {
 	// 构造 API URL
 	url := fmt.Sprintf("%s/datasets/%s/document/create-by-file", c.baseURL, datasetID)
 
@@ -127,7 +131,7 @@ func (c *Client) CreateDocumentByFile(ctx context.Context, datasetID string, req
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// 发送请求
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "CreateDocumentByFile", DatasetID: datasetID}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
@@ -154,38 +158,21 @@ func (c *Client) CreateDocumentByFile(ctx context.Context, datasetID string, req
 }
 
 // GetDocumentIndexingStatus 获取文档嵌入状态
-func (c *Client) GetDocumentIndexingStatus(ctx context.Context, datasetID string, batch string) (*DocumentIndexingStatus, error) {
-	url := fmt.Sprintf("%s/datasets/%s/documents/%s/indexing-status", c.baseURL, datasetID, batch)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("do request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+//
+// 返回批次中第一个文档的状态，批次包含多个文档时使用 GetBatchIndexingStatus。
+func (c *Client) GetDocumentIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*DocumentIndexingStatus, error) {
+	result, err := c.getBatchIndexingStatus(ctx, "GetDocumentIndexingStatus", datasetID, batch, opts)
 	if err != nil {
-		return nil, fmt.Errorf("read response body failed: %w", err)
+		return nil, err
 	}
-
-	// 读取响应体
-	var statuses DocumentIndexingStatus
-	if err := json.Unmarshal(body, &statuses); err != nil {
-		return nil, fmt.Errorf("decode response failed: %w", err)
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("%w: no documents in batch %s", ErrNotFound, batch)
 	}
-
-	return &statuses, nil
+	return &result.Data[0], nil
 }
 
 // UpdateDocumentByText 通过文本更新文档
-func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByTextRequest) (*Result, error) {
+func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByTextRequest, opts ...dify.RequestOption) (*Result, error) {
 	url := fmt.Sprintf("%s/datasets/%s/documents/%s/update-by-text", c.baseURL, datasetID, documentID)
 
 	jsonData, err := json.Marshal(req)
@@ -201,7 +188,7 @@ func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID string, doc
 	httpReq.Header.Set("Authorization", c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "UpdateDocumentByText", DatasetID: datasetID, DocumentID: documentID}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("do request failed: %w", err)
 	}
@@ -221,7 +208,7 @@ func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID string, doc
 }
 
 // UpdateDocumentByFile 通过文件更新文档
-func (c *Client) UpdateDocumentByFile(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByFileRequest, file io.Reader) (*Result, error) {
+func (c *Client) UpdateDocumentByFile(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*Result, error) {
 	url := fmt.Sprintf("%s/datasets/%s/documents/%s/update-by-file", c.baseURL, datasetID, documentID)
 
 	// 创建 multipart form
@@ -277,7 +264,7 @@ func (c *Client) UpdateDocumentByFile(ctx context.Context, datasetID string, doc
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// 发送请求
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "UpdateDocumentByFile", DatasetID: datasetID, DocumentID: documentID}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
@@ -304,7 +291,7 @@ func (c *Client) UpdateDocumentByFile(ctx context.Context, datasetID string, doc
 }
 
 // DeleteDocument 删除文档
-func (c *Client) DeleteDocument(ctx context.Context, datasetID string, documentID string) error {
+func (c *Client) DeleteDocument(ctx context.Context, datasetID string, documentID string, opts ...dify.RequestOption) error {
 	url := fmt.Sprintf("%s/datasets/%s/documents/%s", c.baseURL, datasetID, documentID)
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
@@ -314,7 +301,7 @@ func (c *Client) DeleteDocument(ctx context.Context, datasetID string, documentI
 
 	httpReq.Header.Set("Authorization", c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "DeleteDocument", DatasetID: datasetID, DocumentID: documentID}, httpReq, opts...)
 	if err != nil {
 		return fmt.Errorf("do request failed: %w", err)
 	}
@@ -0,0 +1,155 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// ListDocuments 查询知识库的文档列表，支持按名称和状态过滤以及分页
+func (c *Client) ListDocuments(ctx context.Context, datasetID string, req *ListDocumentsRequest, opts ...dify.RequestOption) (*ListDocumentsResponse, error) {
+	if datasetID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+
+	query := url.Values{}
+	if req != nil {
+		if req.Keyword != "" {
+			query.Set("keyword", req.Keyword)
+		}
+		if req.Status != "" {
+			query.Set("status", req.Status)
+		}
+		if req.Page > 0 {
+			query.Set("page", strconv.Itoa(req.Page))
+		}
+		if req.Limit > 0 {
+			query.Set("limit", strconv.Itoa(req.Limit))
+		}
+	}
+	path := documentsPath(datasetID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var result ListDocumentsResponse
+	op := &dify.Operation{Name: "ListDocuments", DatasetID: datasetID}
+	if err := c.call(ctx, op, http.MethodGet, path, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetDocument 查询文档详情，mode 为空时返回全部字段
+func (c *Client) GetDocument(ctx context.Context, datasetID string, documentID string, mode MetadataMode, opts ...dify.RequestOption) (*Document, error) {
+	if err := checkDocument(datasetID, documentID); err != nil {
+		return nil, err
+	}
+
+	path := documentsPath(datasetID) + "/" + documentID
+	if mode != "" {
+		path += "?metadata=" + url.QueryEscape(string(mode))
+	}
+
+	var result Document
+	op := &dify.Operation{Name: "GetDocument", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodGet, path, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Documents 返回遍历知识库全部文档的迭代器，按需逐页请求
+//
+// req 中的 Page 为起始页，Limit 为每页数量，其余过滤条件对每一页生效。
+func (c *Client) Documents(ctx context.Context, datasetID string, req *ListDocumentsRequest, opts ...dify.RequestOption) *DocumentIterator {
+	return NewDocumentIterator(ctx, c, datasetID, req, opts...)
+}
+
+// NewDocumentIterator 基于任意 DocumentManager 创建文档迭代器，便于在测试中配合 Mock 使用
+func NewDocumentIterator(ctx context.Context, m DocumentManager, datasetID string, req *ListDocumentsRequest, opts ...dify.RequestOption) *DocumentIterator {
+	it := &DocumentIterator{ctx: ctx, m: m, datasetID: datasetID, opts: opts, more: true}
+	if req != nil {
+		it.req = *req
+	}
+	if it.req.Page <= 0 {
+		it.req.Page = 1
+	}
+	return it
+}
+
+// DocumentIterator 文档分页迭代器
+//
+//	it := kb.Documents(ctx, datasetID, &knowledge.ListDocumentsRequest{Limit: 100})
+//	for it.Next() {
+//		doc := it.Document()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type DocumentIterator struct {
+	ctx       context.Context
+	m         DocumentManager
+	datasetID string
+	req       ListDocumentsRequest
+	opts      []dify.RequestOption
+
+	page  []Document
+	index int
+	total int
+	more  bool
+	err   error
+}
+
+// Next 移动到下一个文档，没有更多文档或出错时返回 false
+func (it *DocumentIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if !it.more {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	return true
+}
+
+// Document 返回当前文档
+func (it *DocumentIterator) Document() Document {
+	return it.page[it.index]
+}
+
+// Total 返回服务端报告的文档总数，第一次调用 Next 之前为 0
+func (it *DocumentIterator) Total() int {
+	return it.total
+}
+
+// Err 返回遍历过程中遇到的错误
+func (it *DocumentIterator) Err() error {
+	return it.err
+}
+
+// fetch 请求下一页
+func (it *DocumentIterator) fetch() error {
+	resp, err := it.m.ListDocuments(it.ctx, it.datasetID, &it.req, it.opts...)
+	if err != nil {
+		return fmt.Errorf("list documents page %d failed: %w", it.req.Page, err)
+	}
+	it.page, it.index, it.total = resp.Data, 0, resp.Total
+	// 空页时停止，避免服务端 has_more 异常导致死循环
+	it.more = resp.HasMore && len(resp.Data) > 0
+	it.req.Page++
+	return nil
+}
+
+// documentsPath 返回知识库文档列表的路径
+func documentsPath(datasetID string) string {
+	return fmt.Sprintf("/datasets/%s/documents", datasetID)
+}
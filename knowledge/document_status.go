@@ -0,0 +1,189 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// DocumentAction 批量修改文档状态的操作
+type DocumentAction string
+
+const (
+	DocumentActionEnable    DocumentAction = "enable"     // 启用，文档重新参与检索
+	DocumentActionDisable   DocumentAction = "disable"    // 停用，文档不再参与检索
+	DocumentActionArchive   DocumentAction = "archive"    // 归档，文档不再参与检索且不能编辑
+	DocumentActionUnarchive DocumentAction = "un_archive" // 取消归档
+)
+
+// StatusFailure 单个文档或分段的状态修改失败
+type StatusFailure struct {
+	ID  string // 文档或分段ID
+	Err error  // 失败原因
+}
+
+// StatusUpdateError 批量修改状态时部分文档或分段失败
+type StatusUpdateError struct {
+	Action    string          // 执行的操作
+	Succeeded []string        // 修改成功的ID
+	Failed    []StatusFailure // 修改失败的ID及原因
+}
+
+func (e *StatusUpdateError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		parts = append(parts, f.ID+": "+f.Err.Error())
+	}
+	return fmt.Sprintf("%s failed for %d of %d item(s): %s", e.Action, len(e.Failed), len(e.Failed)+len(e.Succeeded), strings.Join(parts, "; "))
+}
+
+// Unwrap 返回每个失败项的错误，使 errors.As(err, &difyErr) 可以取到服务端错误
+func (e *StatusUpdateError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
+
+// UpdateDocumentsStatus 批量启用、停用、归档或取消归档文档
+//
+// Dify 在任一文档不满足条件（例如不存在、仍在索引中或已归档）时拒绝整个请求。此时会逐个文档重试以找出失败的文档，
+// 其余文档照常修改，并返回 *StatusUpdateError 列出失败的文档。知识库不存在、操作不受支持等与具体文档无关的错误，
+// 以及鉴权、限流、服务端错误和网络错误直接返回，不会重试。
+func (c *Client) UpdateDocumentsStatus(ctx context.Context, datasetID string, action DocumentAction, documentIDs []string, opts ...dify.RequestOption) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	switch action {
+	case DocumentActionEnable, DocumentActionDisable, DocumentActionArchive, DocumentActionUnarchive:
+	default:
+		return fmt.Errorf("%w: unsupported document action %q", ErrInvalidRequest, action)
+	}
+	if len(documentIDs) == 0 {
+		return fmt.Errorf("%w: document_ids is empty", ErrInvalidRequest)
+	}
+	for _, id := range documentIDs {
+		if id == "" {
+			return ErrInvalidDocumentID
+		}
+	}
+
+	err := c.updateDocumentsStatus(ctx, datasetID, action, documentIDs, opts)
+	if err == nil || !documentRejected(err) {
+		return err
+	}
+
+	result := &StatusUpdateError{Action: string(action)}
+	if len(documentIDs) == 1 {
+		result.Failed = []StatusFailure{{ID: documentIDs[0], Err: err}}
+		return result
+	}
+	for _, id := range documentIDs {
+		err := c.updateDocumentsStatus(ctx, datasetID, action, []string{id}, opts)
+		switch {
+		case err == nil:
+			result.Succeeded = append(result.Succeeded, id)
+		case documentRejected(err):
+			result.Failed = append(result.Failed, StatusFailure{ID: id, Err: err})
+		default:
+			return err
+		}
+	}
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	return result
+}
+
+// SetSegmentsEnabled 启用或停用文档中的分段，停用的分段不再参与检索
+//
+// 分段逐个修改，已处于目标状态的分段会跳过。部分分段失败时其余分段照常修改，
+// 并返回 *StatusUpdateError 列出失败的分段。鉴权、限流、服务端错误和网络错误直接返回。
+func (c *Client) SetSegmentsEnabled(ctx context.Context, datasetID string, documentID string, enabled bool, segmentIDs []string, opts ...dify.RequestOption) error {
+	if err := checkDocument(datasetID, documentID); err != nil {
+		return err
+	}
+	if len(segmentIDs) == 0 {
+		return fmt.Errorf("%w: segment_ids is empty", ErrInvalidRequest)
+	}
+	for _, id := range segmentIDs {
+		if id == "" {
+			return ErrInvalidParagraphID
+		}
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	result := &StatusUpdateError{Action: action}
+	for _, id := range segmentIDs {
+		err := c.setSegmentEnabled(ctx, datasetID, documentID, id, enabled, opts)
+		switch {
+		case err == nil:
+			result.Succeeded = append(result.Succeeded, id)
+		case rejected(err):
+			result.Failed = append(result.Failed, StatusFailure{ID: id, Err: err})
+		default:
+			return err
+		}
+	}
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	return result
+}
+
+// updateDocumentsStatus 发送一次批量修改文档状态请求
+func (c *Client) updateDocumentsStatus(ctx context.Context, datasetID string, action DocumentAction, documentIDs []string, opts []dify.RequestOption) error {
+	body := map[string][]string{"document_ids": documentIDs}
+	op := &dify.Operation{Name: "UpdateDocumentsStatus", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodPatch, documentsPath(datasetID)+"/status/"+string(action), body, nil, opts)
+}
+
+// setSegmentEnabled 修改单个分段的启用状态
+//
+// Dify 的分段更新接口要求携带内容，因此先查询分段，再连同原有内容提交。
+func (c *Client) setSegmentEnabled(ctx context.Context, datasetID, documentID, segmentID string, enabled bool, opts []dify.RequestOption) error {
+	seg, err := c.GetSegment(ctx, datasetID, documentID, segmentID, opts...)
+	if err != nil {
+		return err
+	}
+	if seg.Enabled == enabled {
+		return nil
+	}
+
+	req := &UpdateSegmentRequest{Content: seg.Content, Keywords: seg.Keywords, Enabled: &enabled}
+	if seg.Answer != nil {
+		req.Answer = *seg.Answer
+	}
+	_, err = c.UpdateSegment(ctx, datasetID, documentID, segmentID, req, opts...)
+	return err
+}
+
+// documentRejected 判断批量修改文档状态的请求是否因为某个具体文档被拒绝，只有这类错误值得逐个文档重试
+func documentRejected(err error) bool {
+	var difyErr *dify.DifyError
+	if !errors.As(err, &difyErr) {
+		return false
+	}
+	switch difyErr.Code {
+	case "document_not_found", "document_indexing", "archived_document_immutable":
+		return true
+	}
+	return false
+}
+
+// rejected 判断请求是否因为请求内容被服务端拒绝（400 或 404），这类错误只与具体的文档或分段有关
+func rejected(err error) bool {
+	var difyErr *dify.DifyError
+	if !errors.As(err, &difyErr) {
+		return false
+	}
+	return difyErr.Status == http.StatusBadRequest || difyErr.Status == http.StatusNotFound
+}
@@ -0,0 +1,71 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// 测试只有与具体文档有关的错误才会逐个文档重试
+func TestUpdateDocumentsStatusRetry(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		code     string
+		requests int  // 期望发出的请求数
+		partial  bool // 期望返回 *StatusUpdateError
+	}{
+		{name: "dataset not found", status: http.StatusNotFound, code: "dataset_not_found", requests: 1},
+		{name: "invalid action", status: http.StatusBadRequest, code: "invalid_action", requests: 1},
+		{name: "invalid param", status: http.StatusBadRequest, code: "invalid_param", requests: 1},
+		{name: "document not found", status: http.StatusNotFound, code: "document_not_found", requests: 3, partial: true},
+		{name: "document indexing", status: http.StatusBadRequest, code: "document_indexing", requests: 3, partial: true},
+		{name: "archived document", status: http.StatusForbidden, code: "archived_document_immutable", requests: 3, partial: true},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var req struct {
+					DocumentIDs []string `json:"document_ids"`
+				}
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &req)
+				w.Header().Set("Content-Type", "application/json")
+				// 批量请求和针对 bad 的请求失败，其余文档成功
+				if len(req.DocumentIDs) > 1 || req.DocumentIDs[0] == "bad" {
+					w.WriteHeader(tc.status)
+					json.NewEncoder(w).Encode(map[string]interface{}{"code": tc.code, "message": tc.name, "status": tc.status})
+					return
+				}
+				io.WriteString(w, `{"result":"success"}`)
+			}))
+			defer srv.Close()
+
+			c := NewClient("key", WithBaseURL(srv.URL))
+			err := c.UpdateDocumentsStatus(context.Background(), "ds", DocumentActionDisable, []string{"good", "bad"})
+			if requests != tc.requests {
+				t.Fatalf("sent %d requests, want %d", requests, tc.requests)
+			}
+			var difyErr *dify.DifyError
+			if !errors.As(err, &difyErr) || difyErr.Code != tc.code {
+				t.Fatalf("error = %v, want %s", err, tc.code)
+			}
+			var statusErr *StatusUpdateError
+			if errors.As(err, &statusErr) != tc.partial {
+				t.Fatalf("error = %#v, want partial = %v", err, tc.partial)
+			}
+			if tc.partial && (len(statusErr.Succeeded) != 1 || statusErr.Succeeded[0] != "good" || statusErr.Failed[0].ID != "bad") {
+				t.Fatalf("error = %+v", statusErr)
+			}
+		})
+	}
+}
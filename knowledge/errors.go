@@ -4,16 +4,20 @@ import "fmt"
 
 // 错误类型
 var (
-	ErrInvalidKnowledgeID = fmt.Errorf("invalid knowledge ID")
-	ErrInvalidDocumentID  = fmt.Errorf("invalid document ID")
-	ErrInvalidParagraphID = fmt.Errorf("invalid paragraph ID")
-	ErrInvalidRequest     = fmt.Errorf("invalid request")
-	ErrInvalidResponse    = fmt.Errorf("invalid response")
-	ErrNotFound           = fmt.Errorf("resource not found")
-	ErrUnauthorized       = fmt.Errorf("unauthorized")
-	ErrForbidden          = fmt.Errorf("forbidden")
-	ErrTooManyRequests    = fmt.Errorf("too many requests")
-	ErrInternalServer     = fmt.Errorf("internal server error")
+	ErrInvalidKnowledgeID  = fmt.Errorf("invalid knowledge ID")
+	ErrInvalidDocumentID   = fmt.Errorf("invalid document ID")
+	ErrInvalidParagraphID  = fmt.Errorf("invalid paragraph ID")
+	ErrInvalidChildChunkID = fmt.Errorf("invalid child chunk ID")
+	ErrInvalidMetadataID   = fmt.Errorf("invalid metadata ID")
+	ErrInvalidTagID        = fmt.Errorf("invalid tag ID")
+	ErrInvalidRequest      = fmt.Errorf("invalid request")
+	ErrInvalidResponse     = fmt.Errorf("invalid response")
+	ErrNotFound            = fmt.Errorf("resource not found")
+	ErrUnauthorized        = fmt.Errorf("unauthorized")
+	ErrForbidden           = fmt.Errorf("forbidden")
+	ErrTooManyRequests     = fmt.Errorf("too many requests")
+	ErrInternalServer      = fmt.Errorf("internal server error")
+	ErrIndexingFailed      = fmt.Errorf("indexing failed")
 )
 
 // APIError 表示 API 错误
@@ -0,0 +1,268 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// 文档索引状态
+const (
+	IndexingStatusWaiting   = "waiting"
+	IndexingStatusParsing   = "parsing"
+	IndexingStatusCleaning  = "cleaning"
+	IndexingStatusSplitting = "splitting"
+	IndexingStatusIndexing  = "indexing"
+	IndexingStatusCompleted = "completed"
+	IndexingStatusError     = "error"
+	IndexingStatusPaused    = "paused"
+	IndexingStatusStopped   = "stopped"
+)
+
+// IndexingProgress 一次轮询得到的批次索引进度
+type IndexingProgress struct {
+	Batch             string                   // 批次号
+	Documents         []DocumentIndexingStatus // 批次内每个文档的状态，包含各阶段时间
+	CompletedSegments int                      // 批次内已完成的分段数
+	TotalSegments     int                      // 批次内的总分段数
+	Finished          int                      // 已进入终态（completed/error/paused/stopped）的文档数
+	Percent           float64                  // 完成百分比，见 BatchIndexingStatus.Progress
+}
+
+// Done 批次内的文档是否全部进入终态
+func (p IndexingProgress) Done() bool {
+	return len(p.Documents) > 0 && p.Finished == len(p.Documents)
+}
+
+// IndexingError 批次中有文档未能完成索引
+type IndexingError struct {
+	Batch  string                   // 批次号
+	Failed []DocumentIndexingStatus // 状态为 error、paused 或 stopped 的文档
+}
+
+func (e *IndexingError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, status := range e.Failed {
+		part := status.ID + " " + status.IndexingStatus
+		if status.Error != nil && *status.Error != "" {
+			part += ": " + *status.Error
+		}
+		parts = append(parts, part)
+	}
+	return fmt.Sprintf("indexing failed for %d document(s) in batch %s: %s", len(e.Failed), e.Batch, strings.Join(parts, "; "))
+}
+
+// Unwrap 使 errors.Is(err, ErrIndexingFailed) 成立
+func (e *IndexingError) Unwrap() error {
+	return ErrIndexingFailed
+}
+
+// WaitOption WaitForIndexing 的配置选项
+type WaitOption func(*waitConfig)
+
+type waitConfig struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	progress    []func(IndexingProgress)
+	channels    []chan<- IndexingProgress
+	requestOpts []dify.RequestOption
+}
+
+// WithPollInterval 设置首次轮询间隔，默认 1 秒，之后每次翻倍直到最大间隔；小于等于 0 时使用默认值
+func WithPollInterval(interval time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.interval = interval
+	}
+}
+
+// WithMaxPollInterval 设置最大轮询间隔，默认 10 秒；小于首次轮询间隔时使用首次轮询间隔
+func WithMaxPollInterval(interval time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.maxInterval = interval
+	}
+}
+
+// WithProgress 每次轮询后以最新进度调用 fn
+func WithProgress(fn func(IndexingProgress)) WaitOption {
+	return func(c *waitConfig) {
+		c.progress = append(c.progress, fn)
+	}
+}
+
+// WithProgressChannel 每次轮询后将最新进度发送到 ch，发送会阻塞轮询直到被接收或 ctx 结束
+//
+// WaitForIndexing 不会关闭 ch，返回后不再发送。
+func WithProgressChannel(ch chan<- IndexingProgress) WaitOption {
+	return func(c *waitConfig) {
+		c.channels = append(c.channels, ch)
+	}
+}
+
+// WithWaitRequestOptions 设置每次查询索引状态时使用的单次请求选项
+func WithWaitRequestOptions(opts ...dify.RequestOption) WaitOption {
+	return func(c *waitConfig) {
+		c.requestOpts = append(c.requestOpts, opts...)
+	}
+}
+
+// WaitForIndexing 以退避间隔轮询批次的索引状态，直到批次内的文档全部进入终态
+//
+// 全部文档完成时返回最终状态和 nil；有文档处于 error、paused 或 stopped 时同时返回最终状态和 *IndexingError。
+// 批次中没有文档时返回 ErrNotFound，查询失败或 ctx 结束时立即返回错误。
+func (c *Client) WaitForIndexing(ctx context.Context, datasetID string, batch string, opts ...WaitOption) ([]DocumentIndexingStatus, error) {
+	cfg := waitConfig{interval: time.Second, maxInterval: 10 * time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = time.Second
+	}
+	if cfg.maxInterval < cfg.interval {
+		cfg.maxInterval = cfg.interval
+	}
+
+	interval := cfg.interval
+	for {
+		result, err := c.getBatchIndexingStatus(ctx, "WaitForIndexing", datasetID, batch, cfg.requestOpts)
+		if err != nil {
+			return nil, err
+		}
+		statuses := result.Data
+		if len(statuses) == 0 {
+			return nil, fmt.Errorf("%w: batch %s has no documents", ErrNotFound, batch)
+		}
+		progress := newIndexingProgress(result)
+		for _, fn := range cfg.progress {
+			fn(progress)
+		}
+		for _, ch := range cfg.channels {
+			select {
+			case ch <- progress:
+			case <-ctx.Done():
+				return statuses, ctx.Err()
+			}
+		}
+		if result.Done() {
+			if failed := result.Failed(); len(failed) > 0 {
+				return statuses, &IndexingError{Batch: batch, Failed: failed}
+			}
+			return statuses, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return statuses, ctx.Err()
+		case <-timer.C:
+		}
+		if interval *= 2; interval > cfg.maxInterval {
+			interval = cfg.maxInterval
+		}
+	}
+}
+
+// GetBatchIndexingStatus 查询批次内全部文档的索引状态
+func (c *Client) GetBatchIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*BatchIndexingStatus, error) {
+	return c.getBatchIndexingStatus(ctx, "GetBatchIndexingStatus", datasetID, batch, opts)
+}
+
+// getBatchIndexingStatus 查询批次状态，name 为上报给中间件的操作名
+func (c *Client) getBatchIndexingStatus(ctx context.Context, name, datasetID, batch string, opts []dify.RequestOption) (*BatchIndexingStatus, error) {
+	if datasetID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+	if batch == "" {
+		return nil, fmt.Errorf("%w: batch is empty", ErrInvalidRequest)
+	}
+
+	result := BatchIndexingStatus{Batch: batch}
+	op := &dify.Operation{Name: name, DatasetID: datasetID}
+	path := fmt.Sprintf("%s/%s/indexing-status", documentsPath(datasetID), batch)
+	if err := c.call(ctx, op, http.MethodGet, path, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BatchIndexingStatus 批次内全部文档的索引状态
+type BatchIndexingStatus struct {
+	Batch string                   `json:"-"`    // 批次号
+	Data  []DocumentIndexingStatus `json:"data"` // 每个文档的状态
+}
+
+// AllCompleted 批次内的文档是否全部完成索引
+func (b *BatchIndexingStatus) AllCompleted() bool {
+	for _, status := range b.Data {
+		if status.IndexingStatus != IndexingStatusCompleted {
+			return false
+		}
+	}
+	return len(b.Data) > 0
+}
+
+// Done 批次内的文档是否全部进入终态（completed/error/paused/stopped）
+func (b *BatchIndexingStatus) Done() bool {
+	for _, status := range b.Data {
+		if !isTerminalIndexingStatus(status.IndexingStatus) {
+			return false
+		}
+	}
+	return len(b.Data) > 0
+}
+
+// Failed 返回状态为 error、paused 或 stopped 的文档
+func (b *BatchIndexingStatus) Failed() []DocumentIndexingStatus {
+	var failed []DocumentIndexingStatus
+	for _, status := range b.Data {
+		if isTerminalIndexingStatus(status.IndexingStatus) && status.IndexingStatus != IndexingStatusCompleted {
+			failed = append(failed, status)
+		}
+	}
+	return failed
+}
+
+// Progress 按分段数计算的完成百分比（0-100），没有分段时按已完成的文档数计算
+func (b *BatchIndexingStatus) Progress() float64 {
+	if len(b.Data) == 0 {
+		return 0
+	}
+	completed, total, docs := 0, 0, 0
+	for _, status := range b.Data {
+		completed += status.CompletedSegments
+		total += status.TotalSegments
+		if status.IndexingStatus == IndexingStatusCompleted {
+			docs++
+		}
+	}
+	if total == 0 {
+		return float64(docs) * 100 / float64(len(b.Data))
+	}
+	return float64(completed) * 100 / float64(total)
+}
+
+// newIndexingProgress 汇总批次进度
+func newIndexingProgress(result *BatchIndexingStatus) IndexingProgress {
+	progress := IndexingProgress{Batch: result.Batch, Documents: result.Data, Percent: result.Progress()}
+	for _, status := range result.Data {
+		progress.CompletedSegments += status.CompletedSegments
+		progress.TotalSegments += status.TotalSegments
+		if isTerminalIndexingStatus(status.IndexingStatus) {
+			progress.Finished++
+		}
+	}
+	return progress
+}
+
+// isTerminalIndexingStatus 判断索引状态是否为终态
+func isTerminalIndexingStatus(status string) bool {
+	switch status {
+	case IndexingStatusCompleted, IndexingStatusError, IndexingStatusPaused, IndexingStatusStopped:
+		return true
+	}
+	return false
+}
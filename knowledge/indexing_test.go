@@ -0,0 +1,87 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试批次状态的汇总方法
+func TestBatchIndexingStatus(t *testing.T) {
+	message := "quota exceeded"
+	batch := &BatchIndexingStatus{Batch: "b1", Data: []DocumentIndexingStatus{
+		{ID: "d1", IndexingStatus: IndexingStatusCompleted, CompletedSegments: 4, TotalSegments: 4},
+		{ID: "d2", IndexingStatus: IndexingStatusIndexing, CompletedSegments: 2, TotalSegments: 4},
+		{ID: "d3", IndexingStatus: IndexingStatusError, Error: &message},
+	}}
+	if batch.AllCompleted() || batch.Done() {
+		t.Fatal("batch should not be done")
+	}
+	if got := batch.Progress(); got != 75 {
+		t.Fatalf("progress = %v, want 75", got)
+	}
+	if failed := batch.Failed(); len(failed) != 1 || failed[0].ID != "d3" {
+		t.Fatalf("failed = %+v", failed)
+	}
+
+	batch.Data[1].IndexingStatus = IndexingStatusCompleted
+	if !batch.Done() || batch.AllCompleted() {
+		t.Fatal("batch should be done but not all completed")
+	}
+	if (&BatchIndexingStatus{}).Progress() != 0 || (&BatchIndexingStatus{}).Done() {
+		t.Fatal("empty batch should report no progress")
+	}
+}
+
+// 测试批次中没有文档时 WaitForIndexing 立即返回 ErrNotFound，而不是一直轮询
+func TestWaitForIndexingEmptyBatch(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("key", WithBaseURL(srv.URL))
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.WaitForIndexing(context.Background(), "ds", "batch", WithPollInterval(time.Millisecond))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("error = %v, want ErrNotFound", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForIndexing kept polling an empty batch")
+	}
+	if n := atomic.LoadInt32(&polls); n != 1 {
+		t.Fatalf("polls = %d, want 1", n)
+	}
+}
+
+// 测试轮询间隔为 0 时使用默认间隔，而不是不停地查询
+func TestWaitForIndexingZeroInterval(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		w.Write([]byte(`{"data":[{"id":"d1","indexing_status":"indexing"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("key", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.WaitForIndexing(ctx, "ds", "batch", WithPollInterval(0), WithMaxPollInterval(0))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want deadline exceeded", err)
+	}
+	if n := atomic.LoadInt32(&polls); n != 1 {
+		t.Fatalf("polled %d times, want 1", n)
+	}
+}
@@ -0,0 +1,101 @@
+package knowledge
+
+import (
+	"context"
+	"io"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// DatasetManager 知识库管理
+type DatasetManager interface {
+	CreateKnowledge(ctx context.Context, req *CreateKnowledgeRequest, opts ...dify.RequestOption) (*Knowledge, error)
+	ListKnowledge(ctx context.Context, req *ListKnowledgeRequest, opts ...dify.RequestOption) (*ListKnowledgeResponse, error)
+	DeleteKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) error
+	GetKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) (*Knowledge, error)
+	UpdateKnowledge(ctx context.Context, knowledgeID string, req *UpdateKnowledgeRequest, opts ...dify.RequestOption) (*Knowledge, error)
+}
+
+// DocumentManager 文档管理
+type DocumentManager interface {
+	CreateDocumentByText(ctx context.Context, datasetID string, req *CreateDocumentByTextRequest, opts ...dify.RequestOption) (*Result, error)
+	CreateDocumentByFile(ctx context.Context, datasetID string, req *CreateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*Result, error)
+	GetDocumentIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*DocumentIndexingStatus, error)
+	GetBatchIndexingStatus(ctx context.Context, datasetID string, batch string, opts ...dify.RequestOption) (*BatchIndexingStatus, error)
+	UpdateDocumentByText(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByTextRequest, opts ...dify.RequestOption) (*Result, error)
+	UpdateDocumentByFile(ctx context.Context, datasetID string, documentID string, req *UpdateDocumentByFileRequest, file io.Reader, opts ...dify.RequestOption) (*Result, error)
+	DeleteDocument(ctx context.Context, datasetID string, documentID string, opts ...dify.RequestOption) error
+	ListDocuments(ctx context.Context, datasetID string, req *ListDocumentsRequest, opts ...dify.RequestOption) (*ListDocumentsResponse, error)
+	GetDocument(ctx context.Context, datasetID string, documentID string, mode MetadataMode, opts ...dify.RequestOption) (*Document, error)
+	UpdateDocumentsStatus(ctx context.Context, datasetID string, action DocumentAction, documentIDs []string, opts ...dify.RequestOption) error
+}
+
+// SegmentManager 文档分段管理
+type SegmentManager interface {
+	ListSegments(ctx context.Context, datasetID string, documentID string, req *ListSegmentsRequest, opts ...dify.RequestOption) (*ListSegmentsResponse, error)
+	CreateSegments(ctx context.Context, datasetID string, documentID string, req *CreateSegmentsRequest, opts ...dify.RequestOption) ([]Segment, error)
+	GetSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) (*Segment, error)
+	UpdateSegment(ctx context.Context, datasetID string, documentID string, segmentID string, req *UpdateSegmentRequest, opts ...dify.RequestOption) (*Segment, error)
+	DeleteSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) error
+	SetSegmentsEnabled(ctx context.Context, datasetID string, documentID string, enabled bool, segmentIDs []string, opts ...dify.RequestOption) error
+}
+
+// ChildChunkManager 父子分段模式下的子分段管理
+type ChildChunkManager interface {
+	ListChildChunks(ctx context.Context, datasetID string, documentID string, segmentID string, req *ListChildChunksRequest, opts ...dify.RequestOption) (*ListChildChunksResponse, error)
+	CreateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, content string, opts ...dify.RequestOption) (*ChildChunk, error)
+	UpdateChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, content string, opts ...dify.RequestOption) (*ChildChunk, error)
+	DeleteChildChunk(ctx context.Context, datasetID string, documentID string, segmentID string, childChunkID string, opts ...dify.RequestOption) error
+}
+
+// MetadataManager 知识库元数据字段和文档元数据管理
+type MetadataManager interface {
+	CreateMetadataField(ctx context.Context, datasetID string, name string, typ MetadataType, opts ...dify.RequestOption) (*MetadataField, error)
+	ListMetadataFields(ctx context.Context, datasetID string, opts ...dify.RequestOption) (*ListMetadataFieldsResponse, error)
+	RenameMetadataField(ctx context.Context, datasetID string, metadataID string, name string, opts ...dify.RequestOption) (*MetadataField, error)
+	DeleteMetadataField(ctx context.Context, datasetID string, metadataID string, opts ...dify.RequestOption) error
+	SetBuiltInMetadata(ctx context.Context, datasetID string, enabled bool, opts ...dify.RequestOption) error
+	UpdateDocumentsMetadata(ctx context.Context, datasetID string, updates []DocumentMetadataUpdate, opts ...dify.RequestOption) error
+}
+
+// TagManager 知识库标签管理
+type TagManager interface {
+	CreateKnowledgeTag(ctx context.Context, name string, opts ...dify.RequestOption) (*Tag, error)
+	ListKnowledgeTags(ctx context.Context, opts ...dify.RequestOption) ([]Tag, error)
+	RenameKnowledgeTag(ctx context.Context, tagID string, name string, opts ...dify.RequestOption) (*Tag, error)
+	DeleteKnowledgeTag(ctx context.Context, tagID string, opts ...dify.RequestOption) error
+	BindKnowledgeTags(ctx context.Context, datasetID string, tagIDs []string, opts ...dify.RequestOption) error
+	UnbindKnowledgeTag(ctx context.Context, datasetID string, tagID string, opts ...dify.RequestOption) error
+	GetKnowledgeTags(ctx context.Context, datasetID string, opts ...dify.RequestOption) ([]Tag, error)
+}
+
+// Retriever 知识库检索
+type Retriever interface {
+	Retrieve(ctx context.Context, datasetID string, req *RetrieveRequest, opts ...dify.RequestOption) (*RetrieveResponse, error)
+}
+
+// IndexingWaiter 等待文档索引完成
+type IndexingWaiter interface {
+	WaitForIndexing(ctx context.Context, datasetID string, batch string, opts ...WaitOption) ([]DocumentIndexingStatus, error)
+}
+
+// RawCaller 调用 SDK 尚未封装的知识库端点
+type RawCaller interface {
+	Do(ctx context.Context, method, path string, body, out interface{}, opts ...dify.RequestOption) error
+	DoStream(ctx context.Context, method, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error)
+}
+
+// API 知识库 API 的全部能力，由 *Client 实现
+type API interface {
+	DatasetManager
+	DocumentManager
+	SegmentManager
+	ChildChunkManager
+	MetadataManager
+	TagManager
+	Retriever
+	IndexingWaiter
+	RawCaller
+}
+
+var _ API = (*Client)(nil)
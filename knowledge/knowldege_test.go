@@ -1,32 +1,73 @@
-package knowledge
+package knowledge_test
 
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/difytest"
+	"github.com/hb1707/dify-go-sdk/knowledge"
 )
 
-var Key = "Your-API-Key"
+// newTestKnowledge 启动模拟服务并创建一个知识库
+func newTestKnowledge(t *testing.T) (*difytest.Server, *knowledge.Client, string) {
+	t.Helper()
+	srv := difytest.NewServer()
+	t.Cleanup(srv.Close)
+	client := srv.KnowledgeClient()
+	ds, err := client.CreateKnowledge(context.Background(), &knowledge.CreateKnowledgeRequest{Name: "测试知识库"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, client, ds.ID
+}
+
+// newTestDocument 在知识库中创建一个文本文档
+func newTestDocument(t *testing.T, client *knowledge.Client, datasetID, text string) *knowledge.Result {
+	t.Helper()
+	result, err := client.CreateDocumentByText(context.Background(), datasetID, &knowledge.CreateDocumentByTextRequest{
+		Name: "测试文档", Text: text, IndexingTechnique: "economy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
 
 // 测试创建知识库
 func TestCreateKnowledge(t *testing.T) {
-	client := NewClient(Key)
-	knowledge, err := client.CreateKnowledge(context.Background(), &CreateKnowledgeRequest{
+	srv := difytest.NewServer()
+	defer srv.Close()
+	client := srv.KnowledgeClient()
+	created, err := client.CreateKnowledge(context.Background(), &knowledge.CreateKnowledgeRequest{
 		Name:       "测试知识库2",
 		Permission: "all_team_members",
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("知识库: %v创建成功", knowledge.Name)
+	if created.ID == "" || created.Name != "测试知识库2" || created.Permission != "all_team_members" {
+		t.Fatalf("knowledge = %+v", created)
+	}
 }
 
 // 测试知识库列表
 func TestListKnowledge(t *testing.T) {
-	client := NewClient(Key)
-	knowledge, err := client.ListKnowledge(context.Background(), &ListKnowledgeRequest{
+	srv := difytest.NewServer()
+	defer srv.Close()
+	client := srv.KnowledgeClient()
+	for _, name := range []string{"2024 归档", "2025 产品", "2025 客服"} {
+		if _, err := client.CreateKnowledge(context.Background(), &knowledge.CreateKnowledgeRequest{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	created, err := client.ListKnowledge(context.Background(), &knowledge.ListKnowledgeRequest{
 		Page:      1,
 		Limit:     10,
 		Keyword:   "2025",
@@ -36,25 +77,28 @@ func TestListKnowledge(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("知识库: %v", knowledge)
+	if created.Total != 2 || len(created.Data) != 2 || created.Data[0].Name != "2025 客服" {
+		t.Fatalf("knowledge = %+v", created)
+	}
 }
 
 // 测试删除知识库
 func TestDeleteKnowledge(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "8f8dd638-459b-40af-ae20-0abd6f9b42d1"
+	_, client, datasetID := newTestKnowledge(t)
 	err := client.DeleteKnowledge(context.Background(), datasetID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("知识库: %v删除成功", datasetID)
+	var difyErr *dify.DifyError
+	if _, err := client.GetKnowledge(context.Background(), datasetID); !errors.As(err, &difyErr) || difyErr.Status != http.StatusNotFound {
+		t.Fatalf("error = %v, want 404", err)
+	}
 }
 
 // 测试通过文本创建文档
 func TestCreateDocumentByText(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
-	doc, err := client.CreateDocumentByText(context.Background(), datasetID, &CreateDocumentByTextRequest{
+	srv, client, datasetID := newTestKnowledge(t)
+	doc, err := client.CreateDocumentByText(context.Background(), datasetID, &knowledge.CreateDocumentByTextRequest{
 		Name:    "测试文档4",
 		Text:    "这是一个测试文档的内容。\n这是第二行内容。\n这是第三行内容。",
 		DocType: "personal_document",
@@ -68,10 +112,10 @@ func TestCreateDocumentByText(t *testing.T) {
 		},
 		IndexingTechnique: "high_quality",
 		DocForm:           "text_model",
-		ProcessRule: &ProcessRule{
+		ProcessRule: &knowledge.ProcessRule{
 			Mode:  "automatic",
 			Rules: map[string]interface{}{},
-			PreProcessingRules: []PreProcessRule{
+			PreProcessingRules: []knowledge.PreProcessRule{
 				{
 					ID:      "remove_extra_spaces",
 					Enabled: true,
@@ -81,21 +125,21 @@ func TestCreateDocumentByText(t *testing.T) {
 					Enabled: true,
 				},
 			},
-			Segmentation: &SegmentationRule{
+			Segmentation: &knowledge.SegmentationRule{
 				Separator:  "\n",
 				MaxTokens:  1000,
 				ParentMode: "full-doc",
-				SubchunkSegmentation: &SubchunkSegmentation{
+				SubchunkSegmentation: &knowledge.SubchunkSegmentation{
 					Separator:    "***",
 					MaxTokens:    500,
 					ChunkOverlap: 50,
 				},
 			},
 		},
-		RetrievalModel: &RetrievalModel{
+		RetrievalModel: &knowledge.RetrievalModel{
 			SearchMethod:    "hybrid_search",
 			RerankingEnable: true,
-			RerankingModel: &RerankModel{
+			RerankingModel: &knowledge.RerankModel{
 				ProviderName: "cohere",
 				ModelName:    "rerank-english-v2.0",
 			},
@@ -109,15 +153,19 @@ func TestCreateDocumentByText(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("创建文档成功: %+v\n", doc)
+	if doc.Document.ID == "" || doc.Document.Name != "测试文档4" || doc.Batch == "" {
+		t.Fatalf("result = %+v", doc)
+	}
+	if segments := srv.Segments(datasetID, doc.Document.ID); len(segments) != 3 {
+		t.Fatalf("segments = %+v", segments)
+	}
 }
 
 // 测试通过文本更新文档
 func TestUpdateDocumentByText(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
-	documentID := "c23180d5-a484-4df1-b6a7-d25664f3a44a"
-	doc, err := client.UpdateDocumentByText(context.Background(), datasetID, documentID, &UpdateDocumentByTextRequest{
+	srv, client, datasetID := newTestKnowledge(t)
+	documentID := newTestDocument(t, client, datasetID, "原始内容").Document.ID
+	doc, err := client.UpdateDocumentByText(context.Background(), datasetID, documentID, &knowledge.UpdateDocumentByTextRequest{
 		Name:    "更新后的文档名称",
 		Text:    "这是更新后的文档内容，哈哈哈哈哈哈哈哈哈哈",
 		DocType: "personal_document",
@@ -127,10 +175,10 @@ func TestUpdateDocumentByText(t *testing.T) {
 			"author":     "测试作者",
 			"created_at": time.Now().Format(time.RFC3339),
 		},
-		ProcessRule: &ProcessRule{
+		ProcessRule: &knowledge.ProcessRule{
 			Mode:  "automatic",
 			Rules: map[string]interface{}{},
-			PreProcessingRules: []PreProcessRule{
+			PreProcessingRules: []knowledge.PreProcessRule{
 				{
 					ID:      "remove_extra_spaces",
 					Enabled: true,
@@ -140,7 +188,7 @@ func TestUpdateDocumentByText(t *testing.T) {
 					Enabled: true,
 				},
 			},
-			Segmentation: &SegmentationRule{
+			Segmentation: &knowledge.SegmentationRule{
 				Separator:  "###",
 				MaxTokens:  500,
 				ParentMode: "full-doc",
@@ -150,63 +198,53 @@ func TestUpdateDocumentByText(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("更新文档成功: %+v\n", doc)
+	if doc.Document.ID != documentID || doc.Document.Name != "更新后的文档名称" {
+		t.Fatalf("result = %+v", doc)
+	}
+	segments := srv.Segments(datasetID, documentID)
+	if len(segments) != 1 || !strings.HasPrefix(segments[0].Content, "这是更新后的文档内容") {
+		t.Fatalf("segments = %+v", segments)
+	}
 }
 
 // 测试删除文档
 func TestDeleteDocument(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
-	documentID := "92f484ae-23c3-4eda-8573-907c4e8835b5"
+	srv, client, datasetID := newTestKnowledge(t)
+	documentID := newTestDocument(t, client, datasetID, "待删除的内容").Document.ID
 	err := client.DeleteDocument(context.Background(), datasetID, documentID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("删除文档成功: %v", documentID)
+	if docs := srv.Documents(datasetID); len(docs) != 0 {
+		t.Fatalf("documents = %+v", docs)
+	}
 }
 
 // 测试获取文档嵌入状态（进度）
 func TestGetDocumentIndexingStatus(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
-	batch := "20250323045136116479"
-	status, err := client.GetDocumentIndexingStatus(context.Background(), datasetID, batch)
+	_, client, datasetID := newTestKnowledge(t)
+	result := newTestDocument(t, client, datasetID, "第一段\n第二段")
+	status, err := client.GetDocumentIndexingStatus(context.Background(), datasetID, result.Batch)
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("文档索引状态:\n")
-	fmt.Printf("文档ID: %s\n", status.ID)
-	fmt.Printf("索引状态: %s\n", status.IndexingStatus)
-	fmt.Printf("处理开始时间: %.2f\n", status.ProcessingStartedAt)
-	fmt.Printf("解析完成时间: %.2f\n", status.ParsingCompletedAt)
-	fmt.Printf("清洗完成时间: %.2f\n", status.CleaningCompletedAt)
-	fmt.Printf("分段完成时间: %.2f\n", status.SplittingCompletedAt)
-	if status.CompletedAt != nil {
-		fmt.Printf("完成时间: %.2f\n", *status.CompletedAt)
-	}
-	if status.PausedAt != nil {
-		fmt.Printf("暂停时间: %.2f\n", *status.PausedAt)
-	}
-	if status.Error != nil {
-		fmt.Printf("错误: %s\n", *status.Error)
+	if status.ID != result.Document.ID || status.IndexingStatus != "completed" || status.CompletedAt == nil {
+		t.Fatalf("status = %+v", status)
 	}
-	if status.StoppedAt != nil {
-		fmt.Printf("停止时间: %.2f\n", *status.StoppedAt)
+	if status.CompletedSegments != 2 || status.TotalSegments != 2 || status.Error != nil {
+		t.Fatalf("status = %+v", status)
 	}
-	fmt.Printf("已完成段落数: %d\n", status.CompletedSegments)
-	fmt.Printf("总段落数: %d\n", status.TotalSegments)
 }
 
 // 测试通过文件创建文档
 func TestCreateDocumentByFile(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
+	srv, client, datasetID := newTestKnowledge(t)
 	// 创建文件内容
 	fileContent := "这是一个测试文档内容"
 	file := bytes.NewReader([]byte(fileContent))
 
 	// 构造请求参数
-	req := &CreateDocumentByFileRequest{
+	req := &knowledge.CreateDocumentByFileRequest{
 		Name:              "测试文档.txt",
 		IndexingTechnique: "high_quality",
 		DocForm:           "text_model",
@@ -214,15 +252,15 @@ func TestCreateDocumentByFile(t *testing.T) {
 		DocMetadata: map[string]interface{}{
 			"source": "test",
 		},
-		ProcessRule: &ProcessRule{
+		ProcessRule: &knowledge.ProcessRule{
 			Mode: "automatic",
-			PreProcessingRules: []PreProcessRule{
+			PreProcessingRules: []knowledge.PreProcessRule{
 				{
 					ID:      "remove_extra_spaces",
 					Enabled: true,
 				},
 			},
-			Segmentation: &SegmentationRule{
+			Segmentation: &knowledge.SegmentationRule{
 				Separator:  "\n",
 				MaxTokens:  1000,
 				ParentMode: "paragraph",
@@ -234,21 +272,26 @@ func TestCreateDocumentByFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("创建文档成功: %+v\n", doc)
+	if doc.Document.Name != "测试文档.txt" {
+		t.Fatalf("result = %+v", doc)
+	}
+	segments := srv.Segments(datasetID, doc.Document.ID)
+	if len(segments) != 1 || segments[0].Content != fileContent {
+		t.Fatalf("segments = %+v", segments)
+	}
 }
 
 // 测试通过文件更新文档
 func TestUpdateDocumentByFile(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
-	documentID := "c23180d5-a484-4df1-b6a7-d25664f3a44a"
+	srv, client, datasetID := newTestKnowledge(t)
+	documentID := newTestDocument(t, client, datasetID, "原始内容").Document.ID
 
 	// 创建文件内容
 	fileContent := "这是更新后的测试文档内容"
 	file := bytes.NewReader([]byte(fileContent))
 
 	// 构造请求参数
-	req := &UpdateDocumentByFileRequest{
+	req := &knowledge.UpdateDocumentByFileRequest{
 		Name:    "更新后的测试文档.txt",
 		DocType: "personal_document",
 		DocMetadata: map[string]interface{}{
@@ -257,9 +300,9 @@ func TestUpdateDocumentByFile(t *testing.T) {
 			"author":     "测试作者",
 			"created_at": time.Now().Format(time.RFC3339),
 		},
-		ProcessRule: &ProcessRule{
+		ProcessRule: &knowledge.ProcessRule{
 			Mode: "automatic",
-			PreProcessingRules: []PreProcessRule{
+			PreProcessingRules: []knowledge.PreProcessRule{
 				{
 					ID:      "remove_extra_spaces",
 					Enabled: true,
@@ -269,11 +312,11 @@ func TestUpdateDocumentByFile(t *testing.T) {
 					Enabled: true,
 				},
 			},
-			Segmentation: &SegmentationRule{
+			Segmentation: &knowledge.SegmentationRule{
 				Separator:  "\n",
 				MaxTokens:  1000,
 				ParentMode: "paragraph",
-				SubchunkSegmentation: &SubchunkSegmentation{
+				SubchunkSegmentation: &knowledge.SubchunkSegmentation{
 					Separator:    "***",
 					MaxTokens:    500,
 					ChunkOverlap: 50,
@@ -286,18 +329,24 @@ func TestUpdateDocumentByFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Printf("更新文档成功: %+v\n", doc)
+	if doc.Document.ID != documentID || doc.Document.Name != "更新后的测试文档.txt" {
+		t.Fatalf("result = %+v", doc)
+	}
+	segments := srv.Segments(datasetID, documentID)
+	if len(segments) != 1 || segments[0].Content != fileContent {
+		t.Fatalf("segments = %+v", segments)
+	}
 }
 
 // 测试检索知识库
 func TestRetrieve(t *testing.T) {
-	client := NewClient(Key)
-	datasetID := "9dc6342e-0c81-4f4e-9ef8-07d7c94ae0fe"
+	_, client, datasetID := newTestKnowledge(t)
+	documentID := newTestDocument(t, client, datasetID, "测试文档\n无关内容").Document.ID
 
 	// 构造请求参数
-	req := &RetrieveRequest{
+	req := &knowledge.RetrieveRequest{
 		Query: "测试文档",
-		RetrievalModel: &RetrievalModel{
+		RetrievalModel: &knowledge.RetrievalModel{
 			SearchMethod:          "hybrid_search",
 			RerankingEnable:       false,
 			TopK:                  3,
@@ -311,56 +360,14 @@ func TestRetrieve(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// 打印检索结果
-	fmt.Printf("查询内容: %s\n", resp.Query.Content)
-	fmt.Printf("\n检索结果:\n")
-	for i, record := range resp.Records {
-		fmt.Printf("\n记录 %d:\n", i+1)
-		segment := record.Segment
-		fmt.Printf("片段ID: %s\n", segment.ID)
-		fmt.Printf("位置: %d\n", segment.Position)
-		fmt.Printf("文档ID: %s\n", segment.DocumentID)
-		fmt.Printf("内容: %s\n", segment.Content)
-		if segment.Answer != nil {
-			fmt.Printf("答案: %s\n", *segment.Answer)
-		}
-		fmt.Printf("字数: %d\n", segment.WordCount)
-		fmt.Printf("Token数: %d\n", segment.Tokens)
-		fmt.Printf("关键词: %v\n", segment.Keywords)
-		fmt.Printf("索引节点ID: %s\n", segment.IndexNodeID)
-		fmt.Printf("索引节点哈希: %s\n", segment.IndexNodeHash)
-		fmt.Printf("命中次数: %d\n", segment.HitCount)
-		fmt.Printf("是否启用: %v\n", segment.Enabled)
-		if segment.DisabledAt != nil {
-			fmt.Printf("禁用时间: %d\n", *segment.DisabledAt)
-		}
-		if segment.DisabledBy != nil {
-			fmt.Printf("禁用者: %s\n", *segment.DisabledBy)
-		}
-		fmt.Printf("状态: %s\n", segment.Status)
-		fmt.Printf("创建者: %s\n", segment.CreatedBy)
-		fmt.Printf("创建时间: %d\n", segment.CreatedAt)
-		fmt.Printf("索引时间: %d\n", segment.IndexingAt)
-		fmt.Printf("完成时间: %d\n", segment.CompletedAt)
-		if segment.Error != nil {
-			fmt.Printf("错误: %s\n", *segment.Error)
-		}
-		if segment.StoppedAt != nil {
-			fmt.Printf("停止时间: %d\n", *segment.StoppedAt)
-		}
-		if segment.Document != nil {
-			fmt.Printf("文档信息:\n")
-			fmt.Printf("  ID: %s\n", segment.Document.ID)
-			fmt.Printf("  数据源类型: %s\n", segment.Document.DataSourceType)
-			fmt.Printf("  名称: %s\n", segment.Document.Name)
-			if segment.Document.DocType != nil {
-				fmt.Printf("  类型: %s\n", *segment.Document.DocType)
-			}
-		}
-		fmt.Printf("相关度分数: %.10f\n", record.Score)
-		if record.TsnePosition != nil {
-			fmt.Printf("TSNE位置: %.10f\n", *record.TsnePosition)
-		}
+	if resp.Query.Content != "测试文档" || len(resp.Records) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	record := resp.Records[0]
+	if record.Segment.DocumentID != documentID || record.Segment.Content != "测试文档" || record.Score < 0.7 {
+		t.Fatalf("record = %+v", record)
+	}
+	if record.Segment.Document == nil || record.Segment.Document.ID != documentID {
+		t.Fatalf("segment document = %+v", record.Segment.Document)
 	}
 }
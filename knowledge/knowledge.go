@@ -1,15 +1,21 @@
 package knowledge
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
+
+	"github.com/hb1707/dify-go-sdk/dify"
 )
 
-// Client 实现 Client 接口
+// Client 实现 API 接口
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
-	apiKey     string
+	httpClient  *http.Client
+	baseURL     string
+	apiKey      string
+	breaker     *dify.CircuitBreaker
+	middlewares []dify.Middleware
+	logger      *slog.Logger
 }
 
 // NewClient 创建新的知识库客户端
@@ -48,3 +54,55 @@ func WithBaseURL(baseURL string) Option {
 		c.baseURL = baseURL
 	}
 }
+
+// WithCircuitBreaker 设置熔断器，知识库请求统一按 dify.FamilyKnowledge 分组统计
+func WithCircuitBreaker(breaker *dify.CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithMiddleware 添加请求中间件，可以多次调用，先添加的位于外层
+func WithMiddleware(middlewares ...dify.Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithMetrics 设置指标收集，相当于添加 dify.MetricsMiddleware(metrics)
+func WithMetrics(metrics dify.Metrics) Option {
+	return WithMiddleware(dify.MetricsMiddleware(metrics))
+}
+
+// WithLogger 设置结构化日志，相当于添加 dify.LoggingMiddleware(logger, opts...)
+func WithLogger(logger *slog.Logger, opts ...dify.LogOption) Option {
+	return func(c *Client) {
+		c.logger = logger
+		c.middlewares = append(c.middlewares, dify.LoggingMiddleware(logger, opts...))
+	}
+}
+
+// do 发送请求，依次经过单次请求选项、中间件和熔断器
+func (c *Client) do(op *dify.Operation, req *http.Request, opts ...dify.RequestOption) (*http.Response, error) {
+	op.Family = dify.FamilyKnowledge
+
+	middlewares := c.middlewares
+	if len(opts) > 0 {
+		middlewares = append([]dify.Middleware{requestOptionsMiddleware(c.baseURL, opts)}, c.middlewares...)
+	}
+
+	handler := dify.Chain(func(op *dify.Operation, req *http.Request) (*http.Response, error) {
+		if c.breaker == nil {
+			return c.httpClient.Do(req)
+		}
+
+		done, err := c.breaker.Allow(dify.FamilyKnowledge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(req)
+		done(req.Context(), resp, err)
+		return resp, err
+	}, middlewares...)
+	return handler(op, req)
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/hb1707/dify-go-sdk/dify"
 )
 
 // CreateKnowledge 创建知识库
-func (c *Client) CreateKnowledge(ctx context.Context, req *CreateKnowledgeRequest) (*Knowledge, error) {
+func (c *Client) CreateKnowledge(ctx context.Context, req *CreateKnowledgeRequest, opts ...dify.RequestOption) (*Knowledge, error) {
 	url := fmt.Sprintf("%s/datasets", c.baseURL)
 	jsonData, err := json.Marshal(req)
 	if err != nil {
@@ -25,7 +27,7 @@ func (c *Client) CreateKnowledge(ctx context.Context, req *CreateKnowledgeReques
 	httpReq.Header.Set("Authorization", c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "CreateKnowledge"}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("do request failed: %w", err)
 	}
@@ -44,62 +46,22 @@ func (c *Client) CreateKnowledge(ctx context.Context, req *CreateKnowledgeReques
 	return &knowledge, nil
 }
 
-// ListKnowledge 列出知识库
-func (c *Client) ListKnowledge(ctx context.Context, req *ListKnowledgeRequest) (*ListKnowledgeResponse, error) {
-	url := fmt.Sprintf("%s/datasets", c.baseURL)
-
-	// 构建查询参数
-	query := make(map[string]interface{})
-	if req.Page > 0 {
-		query["page"] = req.Page
-	}
-	if req.Limit > 0 {
-		query["limit"] = req.Limit
-	}
-	if req.Keyword != "" {
-		query["keyword"] = req.Keyword
-	}
-	if req.SortBy != "" {
-		query["sort_by"] = req.SortBy
-	}
-	if req.SortOrder != "" {
-		query["sort_order"] = req.SortOrder
-	}
-
-	jsonData, err := json.Marshal(query)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request failed: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", c.apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("do request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+// ListKnowledge 列出知识库，查询条件以 URL 参数发送
+func (c *Client) ListKnowledge(ctx context.Context, req *ListKnowledgeRequest, opts ...dify.RequestOption) (*ListKnowledgeResponse, error) {
+	path := "/datasets"
+	if query := listKnowledgeQuery(req); query != "" {
+		path += "?" + query
 	}
 
 	var response ListKnowledgeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("decode response failed: %w", err)
+	if err := c.call(ctx, &dify.Operation{Name: "ListKnowledge"}, http.MethodGet, path, nil, &response, opts); err != nil {
+		return nil, err
 	}
-
 	return &response, nil
 }
 
 // DeleteKnowledge 删除知识库
-func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) error {
+func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) error {
 	url := fmt.Sprintf("%s/datasets/%s", c.baseURL, knowledgeID)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
@@ -108,7 +70,7 @@ func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) error
 
 	httpReq.Header.Set("Authorization", c.apiKey)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "DeleteKnowledge", DatasetID: knowledgeID}, httpReq, opts...)
 	if err != nil {
 		return fmt.Errorf("do request failed: %w", err)
 	}
@@ -122,8 +84,45 @@ func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) error
 	return nil
 }
 
+// GetKnowledge 查询知识库详情，包含 Embedding 模型、默认检索参数和成员权限
+func (c *Client) GetKnowledge(ctx context.Context, knowledgeID string, opts ...dify.RequestOption) (*Knowledge, error) {
+	if knowledgeID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+
+	var result Knowledge
+	op := &dify.Operation{Name: "GetKnowledge", DatasetID: knowledgeID}
+	if err := c.call(ctx, op, http.MethodGet, "/datasets/"+knowledgeID, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateKnowledge 更新知识库的名称、描述、索引模式、权限、Embedding 模型和默认检索参数
+func (c *Client) UpdateKnowledge(ctx context.Context, knowledgeID string, req *UpdateKnowledgeRequest, opts ...dify.RequestOption) (*Knowledge, error) {
+	if knowledgeID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+	if req == nil {
+		return nil, fmt.Errorf("%w: request is nil", ErrInvalidRequest)
+	}
+	if req.Name != nil && *req.Name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidRequest)
+	}
+	if req.Permission != nil && *req.Permission == PermissionPartialMembers && len(req.PartialMemberList) == 0 {
+		return nil, fmt.Errorf("%w: partial_member_list is required for partial_members permission", ErrInvalidRequest)
+	}
+
+	var result Knowledge
+	op := &dify.Operation{Name: "UpdateKnowledge", DatasetID: knowledgeID}
+	if err := c.call(ctx, op, http.MethodPatch, "/datasets/"+knowledgeID, req, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // Retrieve 检索知识库
-func (c *Client) Retrieve(ctx context.Context, datasetID string, req *RetrieveRequest) (*RetrieveResponse, error) {
+func (c *Client) Retrieve(ctx context.Context, datasetID string, req *RetrieveRequest, opts ...dify.RequestOption) (*RetrieveResponse, error) {
 	url := fmt.Sprintf("%s/datasets/%s/retrieve", c.baseURL, datasetID)
 
 	jsonData, err := json.Marshal(req)
@@ -139,7 +138,7 @@ func (c *Client) Retrieve(ctx context.Context, datasetID string, req *RetrieveRe
 	httpReq.Header.Set("Authorization", c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(&dify.Operation{Name: "Retrieve", DatasetID: datasetID}, httpReq, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
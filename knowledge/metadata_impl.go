@@ -0,0 +1,123 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// CreateMetadataField 在知识库中新增元数据字段
+func (c *Client) CreateMetadataField(ctx context.Context, datasetID string, name string, typ MetadataType, opts ...dify.RequestOption) (*MetadataField, error) {
+	if datasetID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidRequest)
+	}
+	switch typ {
+	case MetadataTypeString, MetadataTypeNumber, MetadataTypeTime:
+	default:
+		return nil, fmt.Errorf("%w: unsupported metadata type %q", ErrInvalidRequest, typ)
+	}
+
+	body := map[string]interface{}{"name": name, "type": typ}
+	var result MetadataField
+	op := &dify.Operation{Name: "CreateMetadataField", DatasetID: datasetID}
+	if err := c.call(ctx, op, http.MethodPost, metadataPath(datasetID), body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListMetadataFields 查询知识库的元数据字段以及内置字段是否启用
+func (c *Client) ListMetadataFields(ctx context.Context, datasetID string, opts ...dify.RequestOption) (*ListMetadataFieldsResponse, error) {
+	if datasetID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+
+	var result ListMetadataFieldsResponse
+	op := &dify.Operation{Name: "ListMetadataFields", DatasetID: datasetID}
+	if err := c.call(ctx, op, http.MethodGet, metadataPath(datasetID), nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RenameMetadataField 重命名元数据字段，字段类型不能修改
+func (c *Client) RenameMetadataField(ctx context.Context, datasetID string, metadataID string, name string, opts ...dify.RequestOption) (*MetadataField, error) {
+	if err := checkMetadata(datasetID, metadataID); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidRequest)
+	}
+
+	body := map[string]string{"name": name}
+	var result MetadataField
+	op := &dify.Operation{Name: "RenameMetadataField", DatasetID: datasetID}
+	if err := c.call(ctx, op, http.MethodPatch, metadataPath(datasetID)+"/"+metadataID, body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteMetadataField 删除元数据字段，文档上对应的值一并删除
+func (c *Client) DeleteMetadataField(ctx context.Context, datasetID string, metadataID string, opts ...dify.RequestOption) error {
+	if err := checkMetadata(datasetID, metadataID); err != nil {
+		return err
+	}
+
+	op := &dify.Operation{Name: "DeleteMetadataField", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodDelete, metadataPath(datasetID)+"/"+metadataID, nil, nil, opts)
+}
+
+// SetBuiltInMetadata 启用或停用内置元数据字段（document_name、uploader、upload_date、last_update_date、source）
+func (c *Client) SetBuiltInMetadata(ctx context.Context, datasetID string, enabled bool, opts ...dify.RequestOption) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	op := &dify.Operation{Name: "SetBuiltInMetadata", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodPost, metadataPath(datasetID)+"/built-in/"+action, nil, nil, opts)
+}
+
+// UpdateDocumentsMetadata 批量设置文档的元数据值，每个文档的现有元数据会被 MetadataList 整体替换
+func (c *Client) UpdateDocumentsMetadata(ctx context.Context, datasetID string, updates []DocumentMetadataUpdate, opts ...dify.RequestOption) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	if len(updates) == 0 {
+		return fmt.Errorf("%w: updates is empty", ErrInvalidRequest)
+	}
+	for _, update := range updates {
+		if update.DocumentID == "" {
+			return ErrInvalidDocumentID
+		}
+	}
+
+	body := map[string][]DocumentMetadataUpdate{"operation_data": updates}
+	op := &dify.Operation{Name: "UpdateDocumentsMetadata", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodPost, documentsPath(datasetID)+"/metadata", body, nil, opts)
+}
+
+// metadataPath 返回知识库元数据字段的路径
+func metadataPath(datasetID string) string {
+	return fmt.Sprintf("/datasets/%s/metadata", datasetID)
+}
+
+// checkMetadata 检查知识库和元数据字段ID
+func checkMetadata(datasetID, metadataID string) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	if metadataID == "" {
+		return ErrInvalidMetadataID
+	}
+	return nil
+}
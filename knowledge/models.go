@@ -1,18 +1,23 @@
 package knowledge
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // Knowledge 知识库
 type Knowledge struct {
-	ID                   string   `json:"id"`
-	Name                 string   `json:"name"`
-	Description          string   `json:"description"`
-	Tags                 []string `json:"tags"`
-	IndexingTechnique    string   `json:"indexing_technique"`
-	Permission           string   `json:"permission"`
-	Provider             string   `json:"provider"`
-	CreatedAt            int64    `json:"created_at"`
-	UpdatedAt            int64    `json:"updated_at"`
-	DocumentCount        int      `json:"document_count"`
-	EmbeddingModel       string   `json:"embedding_model"`
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	Tags                 []Tag  `json:"tags"`
+	IndexingTechnique    string `json:"indexing_technique"`
+	Permission           string `json:"permission"`
+	Provider             string `json:"provider"`
+	CreatedAt            int64  `json:"created_at"`
+	UpdatedAt            int64  `json:"updated_at"`
+	DocumentCount        int    `json:"document_count"`
+	EmbeddingModel       string `json:"embedding_model"`
 	EmbeddingModelConfig struct {
 		Provider string `json:"provider"`
 		Model    string `json:"model"`
@@ -23,6 +28,48 @@ type Knowledge struct {
 		Model    string `json:"model"`
 	} `json:"retrieval_model_config"`
 	Status string `json:"status"`
+
+	DataSourceType         string          `json:"data_source_type,omitempty"`     // 数据源类型
+	DocForm                string          `json:"doc_form,omitempty"`             // 文档形式
+	AppCount               int             `json:"app_count,omitempty"`            // 关联的应用数量
+	WordCount              int             `json:"word_count,omitempty"`           // 字数统计
+	CreatedBy              string          `json:"created_by,omitempty"`           // 创建者
+	UpdatedBy              string          `json:"updated_by,omitempty"`           // 更新者
+	EmbeddingModelProvider string          `json:"embedding_model_provider"`       // Embedding模型供应商
+	EmbeddingAvailable     bool            `json:"embedding_available"`            // Embedding模型是否可用
+	RetrievalModelDict     *RetrievalModel `json:"retrieval_model_dict,omitempty"` // 默认检索参数
+	PartialMemberList      []string        `json:"partial_member_list,omitempty"`  // permission 为 partial_members 时可访问的成员ID
+}
+
+// 知识库权限
+const (
+	PermissionOnlyMe         = "only_me"
+	PermissionAllTeamMembers = "all_team_members"
+	PermissionPartialMembers = "partial_members"
+)
+
+// UpdateKnowledgeRequest 更新知识库请求，nil 字段保持不变，指向空字符串的字段会被清空（如描述）
+//
+//	req := &knowledge.UpdateKnowledgeRequest{Description: knowledge.String("")}
+type UpdateKnowledgeRequest struct {
+	Name                   *string         `json:"name,omitempty"`                     // 知识库名称，不能为空
+	Description            *string         `json:"description,omitempty"`              // 知识库描述
+	IndexingTechnique      *string         `json:"indexing_technique,omitempty"`       // 索引模式：high_quality/economy
+	Permission             *string         `json:"permission,omitempty"`               // 权限：only_me/all_team_members/partial_members
+	PartialMemberList      []PartialMember `json:"partial_member_list,omitempty"`      // 可访问的成员（permission 为 partial_members 时必填）
+	EmbeddingModelProvider *string         `json:"embedding_model_provider,omitempty"` // Embedding模型供应商
+	EmbeddingModel         *string         `json:"embedding_model,omitempty"`          // Embedding模型名称
+	RetrievalModel         *RetrievalModel `json:"retrieval_model,omitempty"`          // 默认检索参数
+}
+
+// String 返回 v 的指针，用于填写 UpdateKnowledgeRequest 等请求中的可选字段
+func String(v string) *string {
+	return &v
+}
+
+// PartialMember 可访问知识库的团队成员
+type PartialMember struct {
+	UserID string `json:"user_id"` // 成员ID
 }
 
 // CreateKnowledgeRequest 创建知识库请求
@@ -38,11 +85,42 @@ type CreateKnowledgeRequest struct {
 
 // ListKnowledgeRequest 列出知识库请求
 type ListKnowledgeRequest struct {
-	Page      int    `json:"page,omitempty"`
-	Limit     int    `json:"limit,omitempty"`
-	Keyword   string `json:"keyword,omitempty"`
-	SortBy    string `json:"sort_by,omitempty"`
-	SortOrder string `json:"sort_order,omitempty"`
+	Page       int      `json:"page,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	Keyword    string   `json:"keyword,omitempty"`
+	SortBy     string   `json:"sort_by,omitempty"`
+	SortOrder  string   `json:"sort_order,omitempty"`
+	TagIDs     []string `json:"tag_ids,omitempty"`     // 只返回绑定了其中任一标签的知识库
+	IncludeAll bool     `json:"include_all,omitempty"` // 返回工作空间的全部知识库（仅对所有者生效）
+}
+
+// Tag 知识库标签
+type Tag struct {
+	ID           string `json:"id"`                      // 标签ID
+	Name         string `json:"name"`                    // 标签名称
+	Type         string `json:"type,omitempty"`          // 标签类型，知识库标签为 knowledge
+	BindingCount int    `json:"binding_count,omitempty"` // 绑定的知识库数量
+}
+
+// UnmarshalJSON 兼容 binding_count 为字符串的响应
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	type plain Tag
+	var raw struct {
+		plain
+		BindingCount json.Number `json:"binding_count"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = Tag(raw.plain)
+	if raw.BindingCount != "" {
+		count, err := raw.BindingCount.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid binding_count %q: %w", raw.BindingCount, err)
+		}
+		t.BindingCount = int(count)
+	}
+	return nil
 }
 
 // ListKnowledgeResponse 列出知识库响应
@@ -77,8 +155,81 @@ type Document struct {
 	WordCount            int                    `json:"word_count"`              // 字数统计
 	HitCount             int                    `json:"hit_count"`               // 命中次数
 	DocForm              string                 `json:"doc_form"`                // 文档形式
+	DocLanguage          string                 `json:"doc_language,omitempty"`  // 文档语言
+	DocMetadata          []DocumentMetadata     `json:"doc_metadata,omitempty"`  // 文档元数据
+	SegmentCount         int                    `json:"segment_count,omitempty"` // 分段数量
+
+	DatasetProcessRule  *ProcessRule `json:"dataset_process_rule,omitempty"`  // 知识库默认处理规则（详情接口返回）
+	DocumentProcessRule *ProcessRule `json:"document_process_rule,omitempty"` // 文档处理规则（详情接口返回）
+
+	CompletedAt     *int64   `json:"completed_at,omitempty"`     // 索引完成时间
+	UpdatedAt       int64    `json:"updated_at,omitempty"`       // 更新时间
+	IndexingLatency *float64 `json:"indexing_latency,omitempty"` // 索引耗时（秒）
+}
+
+// DocumentMetadata 文档的元数据字段值
+type DocumentMetadata struct {
+	ID    string       `json:"id"`             // 元数据字段ID
+	Name  string       `json:"name"`           // 字段名称
+	Type  MetadataType `json:"type,omitempty"` // 字段类型
+	Value interface{}  `json:"value"`          // 字段值，time 类型为 Unix 时间戳
 }
 
+// MetadataType 元数据字段类型
+type MetadataType string
+
+const (
+	MetadataTypeString MetadataType = "string"
+	MetadataTypeNumber MetadataType = "number"
+	MetadataTypeTime   MetadataType = "time"
+)
+
+// MetadataField 知识库的元数据字段
+type MetadataField struct {
+	ID    string       `json:"id"`              // 字段ID
+	Name  string       `json:"name"`            // 字段名称
+	Type  MetadataType `json:"type"`            // 字段类型
+	Count int          `json:"count,omitempty"` // 使用该字段的文档数（列表接口返回）
+}
+
+// ListMetadataFieldsResponse 查询元数据字段响应
+type ListMetadataFieldsResponse struct {
+	DocMetadata         []MetadataField `json:"doc_metadata"`           // 自定义元数据字段
+	BuiltInFieldEnabled bool            `json:"built_in_field_enabled"` // 是否启用内置元数据字段
+}
+
+// DocumentMetadataUpdate 一个文档的元数据值，会替换文档现有的全部元数据
+type DocumentMetadataUpdate struct {
+	DocumentID   string             `json:"document_id"`   // 文档ID
+	MetadataList []DocumentMetadata `json:"metadata_list"` // 元数据值，ID 和 Name 对应知识库的元数据字段
+}
+
+// ListDocumentsRequest 查询知识库文档列表请求
+type ListDocumentsRequest struct {
+	Keyword string // 按名称搜索（选填）
+	Status  string // 按显示状态过滤（选填）：queuing/indexing/paused/error/available/disabled/archived
+	Page    int    // 页码（选填，默认 1）
+	Limit   int    // 每页数量（选填，默认 20，最大 100）
+}
+
+// ListDocumentsResponse 查询知识库文档列表响应
+type ListDocumentsResponse struct {
+	Data    []Document `json:"data"`
+	HasMore bool       `json:"has_more"`
+	Limit   int        `json:"limit"`
+	Total   int        `json:"total"`
+	Page    int        `json:"page"`
+}
+
+// MetadataMode 查询文档详情时返回的元数据范围
+type MetadataMode string
+
+const (
+	MetadataAll     MetadataMode = "all"     // 返回全部字段（默认）
+	MetadataOnly    MetadataMode = "only"    // 仅返回 ID、文档类型和元数据
+	MetadataWithout MetadataMode = "without" // 返回除元数据以外的全部字段
+)
+
 // Metadata 元数据
 type Metadata struct {
 	Source     string                 `json:"source,omitempty"`
@@ -149,14 +300,84 @@ type SubchunkSegmentation struct {
 
 // RetrievalModel 检索模式
 type RetrievalModel struct {
-	SearchMethod          string       `json:"search_method"`           // 检索方法：hybrid_search/semantic_search/full_text_search
-	RerankingEnable       bool         `json:"reranking_enable"`        // 是否开启rerank
-	RerankingModel        *RerankModel `json:"reranking_model"`         // Rerank模型配置
-	TopK                  int          `json:"top_k"`                   // 召回条数
-	ScoreThresholdEnabled bool         `json:"score_threshold_enabled"` // 是否开启召回分数限制
-	ScoreThreshold        float64      `json:"score_threshold"`         // 召回分数限制
+	SearchMethod          string       `json:"search_method"`            // 检索方法：hybrid_search/semantic_search/full_text_search/keyword_search
+	RerankingEnable       bool         `json:"reranking_enable"`         // 是否开启rerank
+	RerankingMode         string       `json:"reranking_mode,omitempty"` // 混合检索的重排方式：reranking_model/weighted_score
+	RerankingModel        *RerankModel `json:"reranking_model"`          // Rerank模型配置
+	Weights               *Weights     `json:"weights,omitempty"`        // 混合检索的关键词和向量权重（reranking_mode 为 weighted_score 时生效）
+	TopK                  int          `json:"top_k"`                    // 召回条数
+	ScoreThresholdEnabled bool         `json:"score_threshold_enabled"`  // 是否开启召回分数限制
+	ScoreThreshold        float64      `json:"score_threshold"`          // 召回分数限制
+
+	MetadataFilteringConditions *MetadataFilter `json:"metadata_filtering_conditions,omitempty"` // 元数据过滤条件
 }
 
+// 检索方法
+const (
+	SearchMethodSemantic = "semantic_search"
+	SearchMethodFullText = "full_text_search"
+	SearchMethodHybrid   = "hybrid_search"
+	SearchMethodKeyword  = "keyword_search"
+)
+
+// 混合检索的重排方式
+const (
+	RerankingModeModel    = "reranking_model"
+	RerankingModeWeighted = "weighted_score"
+)
+
+// Weights 混合检索的权重，关键词权重与向量权重之和为 1
+type Weights struct {
+	WeightType     string          `json:"weight_type,omitempty"` // 权重类型，默认 customized
+	KeywordSetting *KeywordSetting `json:"keyword_setting"`       // 关键词检索权重
+	VectorSetting  *VectorSetting  `json:"vector_setting"`        // 向量检索权重
+}
+
+// KeywordSetting 关键词检索权重
+type KeywordSetting struct {
+	KeywordWeight float64 `json:"keyword_weight"`
+}
+
+// VectorSetting 向量检索权重
+type VectorSetting struct {
+	VectorWeight          float64 `json:"vector_weight"`
+	EmbeddingModelName    string  `json:"embedding_model_name,omitempty"`
+	EmbeddingProviderName string  `json:"embedding_provider_name,omitempty"`
+}
+
+// MetadataFilter 元数据过滤条件，Conditions 按 LogicalOperator 组合
+type MetadataFilter struct {
+	LogicalOperator string              `json:"logical_operator"` // and/or
+	Conditions      []MetadataCondition `json:"conditions"`       // 过滤条件
+}
+
+// MetadataCondition 单个元数据过滤条件
+type MetadataCondition struct {
+	Name               string      `json:"name"`                // 元数据字段名称
+	ComparisonOperator string      `json:"comparison_operator"` // 比较运算符，见 Operator 常量
+	Value              interface{} `json:"value,omitempty"`     // 比较值，empty/not empty 时为空
+}
+
+// 元数据比较运算符
+const (
+	OperatorContains    = "contains"
+	OperatorNotContains = "not contains"
+	OperatorStartWith   = "start with"
+	OperatorEndWith     = "end with"
+	OperatorIs          = "is"
+	OperatorIsNot       = "is not"
+	OperatorEmpty       = "empty"
+	OperatorNotEmpty    = "not empty"
+	OperatorEqual       = "="
+	OperatorNotEqual    = "≠"
+	OperatorGreater     = ">"
+	OperatorLess        = "<"
+	OperatorGreaterEq   = "≥"
+	OperatorLessEq      = "≤"
+	OperatorBefore      = "before"
+	OperatorAfter       = "after"
+)
+
 // RerankModel Rerank模型配置
 type RerankModel struct {
 	ProviderName string `json:"reranking_provider_name"` // Rerank模型的提供商
@@ -212,33 +433,127 @@ type RetrieveResponse struct {
 
 // Record 检索结果记录
 type Record struct {
-	Segment      Segment  `json:"segment"`       // 文档片段
-	Score        float64  `json:"score"`         // 相关度分数
-	TsnePosition *float64 `json:"tsne_position"` // TSNE 位置（可选）
+	Segment      Segment               `json:"segment"`                // 文档片段
+	ChildChunks  []RetrievedChildChunk `json:"child_chunks,omitempty"` // 父子分段模式下命中的子分段
+	Score        float64               `json:"score"`                  // 相关度分数
+	TsnePosition *float64              `json:"tsne_position"`          // TSNE 位置（可选）
+}
+
+// RetrievedChildChunk 检索命中的子分段
+type RetrievedChildChunk struct {
+	ID       string  `json:"id"`       // 子分段ID
+	Content  string  `json:"content"`  // 内容
+	Position int     `json:"position"` // 位置
+	Score    float64 `json:"score"`    // 相关度分数
 }
 
 // Segment 检索结果片段
 type Segment struct {
-	ID            string    `json:"id"`              // 片段ID
-	Position      int       `json:"position"`        // 位置
-	DocumentID    string    `json:"document_id"`     // 文档ID
-	Content       string    `json:"content"`         // 内容
-	Answer        *string   `json:"answer"`          // 答案（可选）
-	WordCount     int       `json:"word_count"`      // 字数统计
-	Tokens        int       `json:"tokens"`          // token数量
-	Keywords      []string  `json:"keywords"`        // 关键词列表
-	IndexNodeID   string    `json:"index_node_id"`   // 索引节点ID
-	IndexNodeHash string    `json:"index_node_hash"` // 索引节点哈希值
-	HitCount      int       `json:"hit_count"`       // 命中次数
-	Enabled       bool      `json:"enabled"`         // 是否启用
-	DisabledAt    *int64    `json:"disabled_at"`     // 禁用时间
-	DisabledBy    *string   `json:"disabled_by"`     // 禁用者
-	Status        string    `json:"status"`          // 状态
-	CreatedBy     string    `json:"created_by"`      // 创建者
-	CreatedAt     int64     `json:"created_at"`      // 创建时间
-	IndexingAt    int64     `json:"indexing_at"`     // 索引时间
-	CompletedAt   int64     `json:"completed_at"`    // 完成时间
-	Error         *string   `json:"error"`           // 错误信息
-	StoppedAt     *int64    `json:"stopped_at"`      // 停止时间
-	Document      *Document `json:"document"`        // 文档信息
+	ID            string       `json:"id"`                     // 片段ID
+	Position      int          `json:"position"`               // 位置
+	DocumentID    string       `json:"document_id"`            // 文档ID
+	Content       string       `json:"content"`                // 内容
+	Answer        *string      `json:"answer"`                 // 答案（可选）
+	WordCount     int          `json:"word_count"`             // 字数统计
+	Tokens        int          `json:"tokens"`                 // token数量
+	Keywords      []string     `json:"keywords"`               // 关键词列表
+	IndexNodeID   string       `json:"index_node_id"`          // 索引节点ID
+	IndexNodeHash string       `json:"index_node_hash"`        // 索引节点哈希值
+	HitCount      int          `json:"hit_count"`              // 命中次数
+	Enabled       bool         `json:"enabled"`                // 是否启用
+	DisabledAt    *int64       `json:"disabled_at"`            // 禁用时间
+	DisabledBy    *string      `json:"disabled_by"`            // 禁用者
+	Status        string       `json:"status"`                 // 状态
+	CreatedBy     string       `json:"created_by"`             // 创建者
+	CreatedAt     int64        `json:"created_at"`             // 创建时间
+	IndexingAt    int64        `json:"indexing_at"`            // 索引时间
+	CompletedAt   int64        `json:"completed_at"`           // 完成时间
+	Error         *string      `json:"error"`                  // 错误信息
+	StoppedAt     *int64       `json:"stopped_at"`             // 停止时间
+	Document      *Document    `json:"document"`               // 文档信息
+	ChildChunks   []ChildChunk `json:"child_chunks,omitempty"` // 子分段，仅父子分段模式
+}
+
+// ListSegmentsRequest 查询文档分段列表请求
+type ListSegmentsRequest struct {
+	Keyword string // 搜索关键词（选填）
+	Status  string // 分段状态，如 completed、indexing、error（选填）
+	Page    int    // 页码（选填）
+	Limit   int    // 每页数量（选填）
+}
+
+// ListSegmentsResponse 查询文档分段列表响应
+type ListSegmentsResponse struct {
+	Data    []Segment `json:"data"`     // 分段列表
+	DocForm string    `json:"doc_form"` // 文档形式
+	HasMore bool      `json:"has_more"` // 是否还有更多
+	Limit   int       `json:"limit"`    // 每页数量
+	Total   int       `json:"total"`    // 总数
+	Page    int       `json:"page"`     // 当前页码
+}
+
+// SegmentInput 新增分段的内容
+type SegmentInput struct {
+	Content  string   `json:"content"`            // 文本内容或问题内容
+	Answer   string   `json:"answer,omitempty"`   // 答案内容，Q&A 模式下必填
+	Keywords []string `json:"keywords,omitempty"` // 关键词（选填）
+}
+
+// CreateSegmentsRequest 新增分段请求
+type CreateSegmentsRequest struct {
+	Segments []SegmentInput `json:"segments"` // 分段列表
+}
+
+// UpdateSegmentRequest 更新分段请求
+type UpdateSegmentRequest struct {
+	Content               string   `json:"content"`                           // 文本内容或问题内容
+	Answer                string   `json:"answer,omitempty"`                  // 答案内容，Q&A 模式下必填
+	Keywords              []string `json:"keywords,omitempty"`                // 关键词（选填）
+	Enabled               *bool    `json:"enabled,omitempty"`                 // 是否启用（选填）
+	RegenerateChildChunks bool     `json:"regenerate_child_chunks,omitempty"` // 是否重新生成子分段（选填）
+}
+
+// segmentsResponse 新增分段响应
+type segmentsResponse struct {
+	Data    []Segment `json:"data"`
+	DocForm string    `json:"doc_form"`
+}
+
+// segmentResponse 查询和更新分段响应
+type segmentResponse struct {
+	Data    Segment `json:"data"`
+	DocForm string  `json:"doc_form"`
+}
+
+// ChildChunk 父子分段模式下的子分段
+type ChildChunk struct {
+	ID        string `json:"id"`         // 子分段ID
+	SegmentID string `json:"segment_id"` // 父分段ID
+	Content   string `json:"content"`    // 内容
+	Position  int    `json:"position"`   // 位置
+	WordCount int    `json:"word_count"` // 字数统计
+	Type      string `json:"type"`       // 类型：automatic/customized
+	CreatedAt int64  `json:"created_at"` // 创建时间
+	UpdatedAt int64  `json:"updated_at"` // 更新时间
+}
+
+// ListChildChunksRequest 查询子分段列表请求
+type ListChildChunksRequest struct {
+	Keyword string // 搜索关键词（选填）
+	Page    int    // 页码（选填）
+	Limit   int    // 每页数量（选填）
+}
+
+// ListChildChunksResponse 查询子分段列表响应
+type ListChildChunksResponse struct {
+	Data       []ChildChunk `json:"data"`        // 子分段列表
+	Total      int          `json:"total"`       // 总数
+	TotalPages int          `json:"total_pages"` // 总页数
+	Page       int          `json:"page"`        // 当前页码
+	Limit      int          `json:"limit"`       // 每页数量
+}
+
+// childChunkResponse 创建和更新子分段响应
+type childChunkResponse struct {
+	Data ChildChunk `json:"data"`
 }
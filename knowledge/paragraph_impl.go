@@ -1 +1,131 @@
 package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// ListSegments 查询文档分段，支持按关键词和状态过滤以及分页
+func (c *Client) ListSegments(ctx context.Context, datasetID string, documentID string, req *ListSegmentsRequest, opts ...dify.RequestOption) (*ListSegmentsResponse, error) {
+	if err := checkDocument(datasetID, documentID); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if req != nil {
+		if req.Keyword != "" {
+			query.Set("keyword", req.Keyword)
+		}
+		if req.Status != "" {
+			query.Set("status", req.Status)
+		}
+		if req.Page > 0 {
+			query.Set("page", strconv.Itoa(req.Page))
+		}
+		if req.Limit > 0 {
+			query.Set("limit", strconv.Itoa(req.Limit))
+		}
+	}
+	path := segmentsPath(datasetID, documentID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var result ListSegmentsResponse
+	op := &dify.Operation{Name: "ListSegments", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodGet, path, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateSegments 向文档新增分段，Q&A 模式的文档需要同时提供答案
+func (c *Client) CreateSegments(ctx context.Context, datasetID string, documentID string, req *CreateSegmentsRequest, opts ...dify.RequestOption) ([]Segment, error) {
+	if err := checkDocument(datasetID, documentID); err != nil {
+		return nil, err
+	}
+	if req == nil || len(req.Segments) == 0 {
+		return nil, fmt.Errorf("%w: segments is empty", ErrInvalidRequest)
+	}
+
+	var result segmentsResponse
+	op := &dify.Operation{Name: "CreateSegments", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodPost, segmentsPath(datasetID, documentID), req, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetSegment 查询单个分段
+func (c *Client) GetSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) (*Segment, error) {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return nil, err
+	}
+
+	var result segmentResponse
+	op := &dify.Operation{Name: "GetSegment", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodGet, segmentsPath(datasetID, documentID)+"/"+segmentID, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// UpdateSegment 更新分段的内容、答案、关键词和启用状态
+func (c *Client) UpdateSegment(ctx context.Context, datasetID string, documentID string, segmentID string, req *UpdateSegmentRequest, opts ...dify.RequestOption) (*Segment, error) {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, fmt.Errorf("%w: request is nil", ErrInvalidRequest)
+	}
+
+	body := map[string]*UpdateSegmentRequest{"segment": req}
+	var result segmentResponse
+	op := &dify.Operation{Name: "UpdateSegment", DatasetID: datasetID, DocumentID: documentID}
+	if err := c.call(ctx, op, http.MethodPost, segmentsPath(datasetID, documentID)+"/"+segmentID, body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// DeleteSegment 删除分段
+func (c *Client) DeleteSegment(ctx context.Context, datasetID string, documentID string, segmentID string, opts ...dify.RequestOption) error {
+	if err := checkSegment(datasetID, documentID, segmentID); err != nil {
+		return err
+	}
+
+	op := &dify.Operation{Name: "DeleteSegment", DatasetID: datasetID, DocumentID: documentID}
+	return c.call(ctx, op, http.MethodDelete, segmentsPath(datasetID, documentID)+"/"+segmentID, nil, nil, opts)
+}
+
+// segmentsPath 返回文档分段的路径
+func segmentsPath(datasetID, documentID string) string {
+	return fmt.Sprintf("/datasets/%s/documents/%s/segments", datasetID, documentID)
+}
+
+// checkDocument 检查知识库和文档ID
+func checkDocument(datasetID, documentID string) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	if documentID == "" {
+		return ErrInvalidDocumentID
+	}
+	return nil
+}
+
+// checkSegment 检查知识库、文档和分段ID
+func checkSegment(datasetID, documentID, segmentID string) error {
+	if err := checkDocument(datasetID, documentID); err != nil {
+		return err
+	}
+	if segmentID == "" {
+		return ErrInvalidParagraphID
+	}
+	return nil
+}
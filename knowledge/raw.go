@@ -0,0 +1,95 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+	"github.com/hb1707/dify-go-sdk/internal/transport"
+)
+
+// Do 调用 SDK 尚未封装的知识库端点，复用鉴权、中间件、熔断和错误解析
+//
+// path 为相对于基础 URL 的路径，可以包含查询参数，如 "/datasets/xxx/documents?page=2"。
+// body、out 和 Content-Type 的规则与 dify.Client.Do 相同，非 2xx 响应返回 *dify.DifyError。
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}, opts ...dify.RequestOption) error {
+	return c.call(ctx, rawOperation("Do", path), method, path, body, out, opts)
+}
+
+// DoStream 以流式模式调用 SDK 尚未封装的知识库端点，返回原始 SSE 事件流，使用完毕后需要调用 Close
+func (c *Client) DoStream(ctx context.Context, method, path string, body interface{}, opts ...dify.RequestOption) (*dify.RawStream, error) {
+	op := rawOperation("DoStream", path)
+	op.Stream = true
+	resp, err := c.raw(ctx, op, method, path, body, opts)
+	if err != nil {
+		return nil, err
+	}
+	return openRawStream(op, resp)
+}
+
+// call 发送请求并将响应 JSON 解码到 out，非 2xx 响应返回 *dify.DifyError
+func (c *Client) call(ctx context.Context, op *dify.Operation, method, path string, body, out interface{}, opts []dify.RequestOption) error {
+	resp, err := c.raw(ctx, op, method, path, body, opts)
+	if err != nil {
+		return err
+	}
+	return transport.DecodeResponse(resp, out)
+}
+
+// raw 构造并发送原始请求
+func (c *Client) raw(ctx context.Context, op *dify.Operation, method, path string, body interface{}, opts []dify.RequestOption) (*http.Response, error) {
+	data, contentType, err := transport.EncodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Authorization", c.apiKey)
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if op.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.do(op, httpReq, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("do request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// rawOperation 从 /datasets/{dataset_id}/documents/{document_id} 形式的路径中提取知识库和文档ID
+func rawOperation(name, path string) *dify.Operation {
+	op := &dify.Operation{Name: name}
+	path, _, _ = strings.Cut(path, "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "datasets" {
+		op.DatasetID = parts[1]
+	}
+	if len(parts) >= 4 && parts[2] == "documents" {
+		op.DocumentID = parts[3]
+	}
+	return op
+}
+
+// requestOptionsMiddleware 复用 dify 包应用单次请求选项的中间件
+func requestOptionsMiddleware(baseURL string, opts []dify.RequestOption) dify.Middleware {
+	return transport.RequestOptionsMiddleware.(func(string, ...dify.RequestOption) dify.Middleware)(baseURL, opts...)
+}
+
+// openRawStream 复用 dify 包的原始事件流实现
+func openRawStream(op *dify.Operation, resp *http.Response) (*dify.RawStream, error) {
+	return transport.OpenRawStream.(func(*dify.Operation, *http.Response) (*dify.RawStream, error))(op, resp)
+}
@@ -0,0 +1,259 @@
+package knowledge
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// RetrieveBuilder 构造检索请求，支持元数据过滤、混合检索权重和重排设置
+//
+//	req, err := knowledge.NewRetrieveRequest("退款政策").
+//		HybridSearch(0.3, 0.7).
+//		TopK(5).
+//		ScoreThreshold(0.5).
+//		MatchAll(knowledge.Is("tenant", "acme"), knowledge.After("published", since)).
+//		Build()
+type RetrieveBuilder struct {
+	query string
+	model RetrievalModel
+	set   bool
+	errs  []error
+}
+
+// NewRetrieveRequest 创建检索请求构造器
+//
+// 未调用任何设置方法时使用知识库的默认检索参数；设置了任一参数后，
+// 未设置的检索方法默认为 semantic_search，召回条数默认为 3。
+func NewRetrieveRequest(query string) *RetrieveBuilder {
+	return &RetrieveBuilder{query: query}
+}
+
+// SemanticSearch 使用向量检索
+func (b *RetrieveBuilder) SemanticSearch() *RetrieveBuilder {
+	return b.search(SearchMethodSemantic)
+}
+
+// FullTextSearch 使用全文检索
+func (b *RetrieveBuilder) FullTextSearch() *RetrieveBuilder {
+	return b.search(SearchMethodFullText)
+}
+
+// KeywordSearch 使用关键词检索（economy 索引模式）
+func (b *RetrieveBuilder) KeywordSearch() *RetrieveBuilder {
+	return b.search(SearchMethodKeyword)
+}
+
+// HybridSearch 使用混合检索，按关键词和向量权重加权重排，两个权重之和需要为 1
+func (b *RetrieveBuilder) HybridSearch(keywordWeight, vectorWeight float64) *RetrieveBuilder {
+	if keywordWeight < 0 || vectorWeight < 0 || math.Abs(keywordWeight+vectorWeight-1) > 1e-6 {
+		b.errs = append(b.errs, fmt.Errorf("hybrid weights must be non-negative and sum to 1, got %v and %v", keywordWeight, vectorWeight))
+	}
+	b.search(SearchMethodHybrid)
+	b.model.RerankingEnable = false
+	b.model.RerankingMode = RerankingModeWeighted
+	b.model.RerankingModel = nil
+	b.model.Weights = &Weights{
+		WeightType:     "customized",
+		KeywordSetting: &KeywordSetting{KeywordWeight: keywordWeight},
+		VectorSetting:  &VectorSetting{VectorWeight: vectorWeight},
+	}
+	return b
+}
+
+// EmbeddingModel 设置混合检索向量权重对应的 Embedding 模型，需要在 HybridSearch 之后调用
+func (b *RetrieveBuilder) EmbeddingModel(provider, model string) *RetrieveBuilder {
+	if b.model.Weights == nil {
+		b.errs = append(b.errs, errors.New("EmbeddingModel requires HybridSearch"))
+		return b
+	}
+	b.model.Weights.VectorSetting.EmbeddingProviderName = provider
+	b.model.Weights.VectorSetting.EmbeddingModelName = model
+	return b
+}
+
+// Rerank 使用 Rerank 模型重排，混合检索时替换权重重排
+func (b *RetrieveBuilder) Rerank(provider, model string) *RetrieveBuilder {
+	if provider == "" || model == "" {
+		b.errs = append(b.errs, errors.New("rerank provider and model are required"))
+	}
+	b.set = true
+	b.model.RerankingEnable = true
+	b.model.RerankingModel = &RerankModel{ProviderName: provider, ModelName: model}
+	if b.model.SearchMethod == SearchMethodHybrid {
+		b.model.RerankingMode = RerankingModeModel
+		b.model.Weights = nil
+	}
+	return b
+}
+
+// TopK 设置召回条数
+func (b *RetrieveBuilder) TopK(k int) *RetrieveBuilder {
+	if k <= 0 {
+		b.errs = append(b.errs, fmt.Errorf("top_k must be positive, got %d", k))
+	}
+	b.set = true
+	b.model.TopK = k
+	return b
+}
+
+// ScoreThreshold 启用召回分数限制，只返回分数不低于 threshold 的结果
+func (b *RetrieveBuilder) ScoreThreshold(threshold float64) *RetrieveBuilder {
+	if threshold < 0 || threshold > 1 {
+		b.errs = append(b.errs, fmt.Errorf("score threshold must be between 0 and 1, got %v", threshold))
+	}
+	b.set = true
+	b.model.ScoreThresholdEnabled = true
+	b.model.ScoreThreshold = threshold
+	return b
+}
+
+// MatchAll 只检索满足全部条件的文档
+func (b *RetrieveBuilder) MatchAll(conditions ...MetadataCondition) *RetrieveBuilder {
+	return b.filter("and", conditions)
+}
+
+// MatchAny 只检索满足任一条件的文档
+func (b *RetrieveBuilder) MatchAny(conditions ...MetadataCondition) *RetrieveBuilder {
+	return b.filter("or", conditions)
+}
+
+// Build 返回检索请求，参数不合法时返回 ErrInvalidRequest
+func (b *RetrieveBuilder) Build() (*RetrieveRequest, error) {
+	errs := b.errs
+	if b.query == "" {
+		errs = append(errs, errors.New("query is empty"))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, errors.Join(errs...))
+	}
+
+	req := &RetrieveRequest{Query: b.query}
+	if b.set {
+		model := b.model
+		if model.SearchMethod == "" {
+			model.SearchMethod = SearchMethodSemantic
+		}
+		if model.TopK == 0 {
+			model.TopK = 3
+		}
+		req.RetrievalModel = &model
+	}
+	return req, nil
+}
+
+// search 设置检索方法
+func (b *RetrieveBuilder) search(method string) *RetrieveBuilder {
+	b.set = true
+	b.model.SearchMethod = method
+	if method != SearchMethodHybrid {
+		b.model.RerankingMode = ""
+		b.model.Weights = nil
+	}
+	return b
+}
+
+// filter 设置元数据过滤条件，MatchAll 和 MatchAny 只能使用其中一个
+func (b *RetrieveBuilder) filter(operator string, conditions []MetadataCondition) *RetrieveBuilder {
+	b.set = true
+	if f := b.model.MetadataFilteringConditions; f != nil && f.LogicalOperator != operator {
+		b.errs = append(b.errs, errors.New("MatchAll and MatchAny cannot be combined"))
+		return b
+	}
+	if len(conditions) == 0 {
+		b.errs = append(b.errs, errors.New("metadata filter requires at least one condition"))
+		return b
+	}
+	for _, c := range conditions {
+		if c.Name == "" || c.ComparisonOperator == "" {
+			b.errs = append(b.errs, fmt.Errorf("invalid metadata condition %+v", c))
+		}
+	}
+	if b.model.MetadataFilteringConditions == nil {
+		b.model.MetadataFilteringConditions = &MetadataFilter{LogicalOperator: operator}
+	}
+	f := b.model.MetadataFilteringConditions
+	f.Conditions = append(f.Conditions, conditions...)
+	return b
+}
+
+// Is 字段值等于 value
+func Is(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorIs, Value: value}
+}
+
+// IsNot 字段值不等于 value
+func IsNot(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorIsNot, Value: value}
+}
+
+// Contains 字段值包含 value
+func Contains(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorContains, Value: value}
+}
+
+// NotContains 字段值不包含 value
+func NotContains(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorNotContains, Value: value}
+}
+
+// StartsWith 字段值以 value 开头
+func StartsWith(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorStartWith, Value: value}
+}
+
+// EndsWith 字段值以 value 结尾
+func EndsWith(name, value string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorEndWith, Value: value}
+}
+
+// IsEmpty 字段未设置值
+func IsEmpty(name string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorEmpty}
+}
+
+// IsNotEmpty 字段已设置值
+func IsNotEmpty(name string) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorNotEmpty}
+}
+
+// Equal 数值字段等于 value
+func Equal(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorEqual, Value: value}
+}
+
+// NotEqual 数值字段不等于 value
+func NotEqual(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorNotEqual, Value: value}
+}
+
+// GreaterThan 数值字段大于 value
+func GreaterThan(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorGreater, Value: value}
+}
+
+// LessThan 数值字段小于 value
+func LessThan(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorLess, Value: value}
+}
+
+// GreaterOrEqual 数值字段大于等于 value
+func GreaterOrEqual(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorGreaterEq, Value: value}
+}
+
+// LessOrEqual 数值字段小于等于 value
+func LessOrEqual(name string, value float64) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorLessEq, Value: value}
+}
+
+// Before 时间字段早于 t
+func Before(name string, t time.Time) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorBefore, Value: t.Unix()}
+}
+
+// After 时间字段晚于 t
+func After(name string, t time.Time) MetadataCondition {
+	return MetadataCondition{Name: name, ComparisonOperator: OperatorAfter, Value: t.Unix()}
+}
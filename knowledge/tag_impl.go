@@ -0,0 +1,138 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hb1707/dify-go-sdk/dify"
+)
+
+// CreateKnowledgeTag 创建知识库标签
+func (c *Client) CreateKnowledgeTag(ctx context.Context, name string, opts ...dify.RequestOption) (*Tag, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidRequest)
+	}
+
+	var result Tag
+	body := map[string]string{"name": name}
+	if err := c.call(ctx, &dify.Operation{Name: "CreateKnowledgeTag"}, http.MethodPost, tagsPath, body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListKnowledgeTags 查询全部知识库标签及其绑定数量
+func (c *Client) ListKnowledgeTags(ctx context.Context, opts ...dify.RequestOption) ([]Tag, error) {
+	var result []Tag
+	if err := c.call(ctx, &dify.Operation{Name: "ListKnowledgeTags"}, http.MethodGet, tagsPath, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RenameKnowledgeTag 重命名知识库标签
+func (c *Client) RenameKnowledgeTag(ctx context.Context, tagID string, name string, opts ...dify.RequestOption) (*Tag, error) {
+	if tagID == "" {
+		return nil, ErrInvalidTagID
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidRequest)
+	}
+
+	var result Tag
+	body := map[string]string{"tag_id": tagID, "name": name}
+	if err := c.call(ctx, &dify.Operation{Name: "RenameKnowledgeTag"}, http.MethodPatch, tagsPath, body, &result, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteKnowledgeTag 删除知识库标签，已有的绑定一并删除
+func (c *Client) DeleteKnowledgeTag(ctx context.Context, tagID string, opts ...dify.RequestOption) error {
+	if tagID == "" {
+		return ErrInvalidTagID
+	}
+
+	body := map[string]string{"tag_id": tagID}
+	return c.call(ctx, &dify.Operation{Name: "DeleteKnowledgeTag"}, http.MethodDelete, tagsPath, body, nil, opts)
+}
+
+// BindKnowledgeTags 为知识库绑定一个或多个标签
+func (c *Client) BindKnowledgeTags(ctx context.Context, datasetID string, tagIDs []string, opts ...dify.RequestOption) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	if len(tagIDs) == 0 {
+		return fmt.Errorf("%w: tag_ids is empty", ErrInvalidRequest)
+	}
+
+	body := map[string]interface{}{"tag_ids": tagIDs, "target_id": datasetID}
+	op := &dify.Operation{Name: "BindKnowledgeTags", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodPost, tagsPath+"/binding", body, nil, opts)
+}
+
+// UnbindKnowledgeTag 解除知识库与标签的绑定
+func (c *Client) UnbindKnowledgeTag(ctx context.Context, datasetID string, tagID string, opts ...dify.RequestOption) error {
+	if datasetID == "" {
+		return ErrInvalidKnowledgeID
+	}
+	if tagID == "" {
+		return ErrInvalidTagID
+	}
+
+	body := map[string]string{"tag_id": tagID, "target_id": datasetID}
+	op := &dify.Operation{Name: "UnbindKnowledgeTag", DatasetID: datasetID}
+	return c.call(ctx, op, http.MethodPost, tagsPath+"/unbinding", body, nil, opts)
+}
+
+// GetKnowledgeTags 查询知识库绑定的标签
+func (c *Client) GetKnowledgeTags(ctx context.Context, datasetID string, opts ...dify.RequestOption) ([]Tag, error) {
+	if datasetID == "" {
+		return nil, ErrInvalidKnowledgeID
+	}
+
+	var result struct {
+		Data []Tag `json:"data"`
+	}
+	op := &dify.Operation{Name: "GetKnowledgeTags", DatasetID: datasetID}
+	if err := c.call(ctx, op, http.MethodGet, "/datasets/"+datasetID+"/tags", nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// tagsPath 知识库标签的路径
+const tagsPath = "/datasets/tags"
+
+// listKnowledgeQuery 将列表请求编码为 URL 参数，tag_ids 可以重复出现
+func listKnowledgeQuery(req *ListKnowledgeRequest) string {
+	if req == nil {
+		return ""
+	}
+	query := url.Values{}
+	if req.Page > 0 {
+		query.Set("page", strconv.Itoa(req.Page))
+	}
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+	if req.Keyword != "" {
+		query.Set("keyword", req.Keyword)
+	}
+	if req.SortBy != "" {
+		query.Set("sort_by", req.SortBy)
+	}
+	if req.SortOrder != "" {
+		query.Set("sort_order", req.SortOrder)
+	}
+	for _, id := range req.TagIDs {
+		query.Add("tag_ids", id)
+	}
+	if req.IncludeAll {
+		query.Set("include_all", "true")
+	}
+	return query.Encode()
+}
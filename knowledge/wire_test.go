@@ -0,0 +1,490 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// wireCase 一次调用期望发出的请求，按 Dify API 文档中的格式填写
+type wireCase struct {
+	name     string
+	call     func(ctx context.Context, c *Client) error
+	method   string
+	path     string
+	query    url.Values // 为 nil 时要求没有查询参数
+	body     string     // 期望的 JSON 请求体，为空时要求没有请求体
+	response string     // 返回给客户端的响应体，为空时返回 {}
+}
+
+// runWireCases 逐个执行调用，检查请求方法、路径、查询参数和 JSON 请求体
+func runWireCases(t *testing.T, cases []wireCase) {
+	t.Helper()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				method, path, contentType string
+				query                     url.Values
+				body                      []byte
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path, query, contentType = r.Method, r.URL.Path, r.URL.Query(), r.Header.Get("Content-Type")
+				body, _ = io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				if tc.response == "" {
+					io.WriteString(w, "{}")
+					return
+				}
+				io.WriteString(w, tc.response)
+			}))
+			defer srv.Close()
+
+			c := NewClient("key", WithBaseURL(srv.URL))
+			if err := tc.call(context.Background(), c); err != nil {
+				t.Fatal(err)
+			}
+			if method != tc.method || path != tc.path {
+				t.Fatalf("request = %s %s, want %s %s", method, path, tc.method, tc.path)
+			}
+			if len(query) > 0 || len(tc.query) > 0 {
+				if !reflect.DeepEqual(query, tc.query) {
+					t.Fatalf("query = %v, want %v", query, tc.query)
+				}
+			}
+			if tc.body == "" {
+				if len(body) > 0 {
+					t.Fatalf("body = %s, want none", body)
+				}
+				return
+			}
+			if contentType != "application/json" {
+				t.Fatalf("Content-Type = %q, want application/json", contentType)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("body %s: %v", body, err)
+			}
+			if err := json.Unmarshal([]byte(tc.body), &want); err != nil {
+				t.Fatalf("expected body %s: %v", tc.body, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("body = %s, want %s", body, tc.body)
+			}
+		})
+	}
+}
+
+// 测试分段接口的请求格式
+func TestSegmentsWire(t *testing.T) {
+	enabled := false
+	runWireCases(t, []wireCase{
+		{
+			name: "list",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListSegments(ctx, "ds", "doc", &ListSegmentsRequest{Keyword: "go", Status: "completed", Page: 2, Limit: 10})
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/documents/doc/segments",
+			query:  url.Values{"keyword": {"go"}, "status": {"completed"}, "page": {"2"}, "limit": {"10"}},
+		},
+		{
+			name: "create",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.CreateSegments(ctx, "ds", "doc", &CreateSegmentsRequest{Segments: []SegmentInput{
+					{Content: "q", Answer: "a", Keywords: []string{"k"}},
+				}})
+				return err
+			},
+			method:   http.MethodPost,
+			path:     "/datasets/ds/documents/doc/segments",
+			body:     `{"segments":[{"content":"q","answer":"a","keywords":["k"]}]}`,
+			response: `{"data":[{"id":"seg"}],"doc_form":"qa_model"}`,
+		},
+		{
+			name: "get",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.GetSegment(ctx, "ds", "doc", "seg")
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/documents/doc/segments/seg",
+		},
+		{
+			name: "update",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.UpdateSegment(ctx, "ds", "doc", "seg", &UpdateSegmentRequest{
+					Content: "q", Keywords: []string{"k"}, Enabled: &enabled, RegenerateChildChunks: true,
+				})
+				return err
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/documents/doc/segments/seg",
+			body:   `{"segment":{"content":"q","keywords":["k"],"enabled":false,"regenerate_child_chunks":true}}`,
+		},
+		{
+			name: "delete",
+			call: func(ctx context.Context, c *Client) error {
+				return c.DeleteSegment(ctx, "ds", "doc", "seg")
+			},
+			method: http.MethodDelete,
+			path:   "/datasets/ds/documents/doc/segments/seg",
+		},
+	})
+}
+
+// 测试子分段接口的请求格式
+func TestChildChunksWire(t *testing.T) {
+	const base = "/datasets/ds/documents/doc/segments/seg/child_chunks"
+	runWireCases(t, []wireCase{
+		{
+			name: "list",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListChildChunks(ctx, "ds", "doc", "seg", &ListChildChunksRequest{Keyword: "go", Page: 1, Limit: 20})
+				return err
+			},
+			method: http.MethodGet,
+			path:   base,
+			query:  url.Values{"keyword": {"go"}, "page": {"1"}, "limit": {"20"}},
+		},
+		{
+			name: "create",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.CreateChildChunk(ctx, "ds", "doc", "seg", "child")
+				return err
+			},
+			method: http.MethodPost,
+			path:   base,
+			body:   `{"content":"child"}`,
+		},
+		{
+			name: "update",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.UpdateChildChunk(ctx, "ds", "doc", "seg", "chunk", "edited")
+				return err
+			},
+			method: http.MethodPatch,
+			path:   base + "/chunk",
+			body:   `{"content":"edited"}`,
+		},
+		{
+			name: "delete",
+			call: func(ctx context.Context, c *Client) error {
+				return c.DeleteChildChunk(ctx, "ds", "doc", "seg", "chunk")
+			},
+			method: http.MethodDelete,
+			path:   base + "/chunk",
+		},
+	})
+}
+
+// 测试文档列表和详情接口的请求格式
+func TestDocumentsWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "list",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListDocuments(ctx, "ds", &ListDocumentsRequest{Keyword: "faq", Status: "available", Page: 3, Limit: 50})
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/documents",
+			query:  url.Values{"keyword": {"faq"}, "status": {"available"}, "page": {"3"}, "limit": {"50"}},
+		},
+		{
+			name: "get",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.GetDocument(ctx, "ds", "doc", MetadataOnly)
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/documents/doc",
+			query:  url.Values{"metadata": {"only"}},
+		},
+	})
+}
+
+// 测试知识库详情和更新接口的请求格式，空字符串字段需要出现在请求体中
+func TestKnowledgeSettingsWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "get",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.GetKnowledge(ctx, "ds")
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds",
+		},
+		{
+			name: "update",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.UpdateKnowledge(ctx, "ds", &UpdateKnowledgeRequest{
+					Description:       String(""),
+					Permission:        String(PermissionPartialMembers),
+					PartialMemberList: []PartialMember{{UserID: "u1"}},
+					EmbeddingModel:    String("text-embedding-3-small"),
+					RetrievalModel:    &RetrievalModel{SearchMethod: SearchMethodSemantic, TopK: 2},
+				})
+				return err
+			},
+			method: http.MethodPatch,
+			path:   "/datasets/ds",
+			body: `{"description":"","permission":"partial_members","partial_member_list":[{"user_id":"u1"}],
+				"embedding_model":"text-embedding-3-small",
+				"retrieval_model":{"search_method":"semantic_search","reranking_enable":false,"reranking_model":null,
+					"top_k":2,"score_threshold_enabled":false,"score_threshold":0}}`,
+		},
+	})
+}
+
+// 测试元数据接口的请求格式
+func TestMetadataWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "create",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.CreateMetadataField(ctx, "ds", "tenant", MetadataTypeString)
+				return err
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/metadata",
+			body:   `{"type":"string","name":"tenant"}`,
+		},
+		{
+			name: "list",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListMetadataFields(ctx, "ds")
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/metadata",
+		},
+		{
+			name: "rename",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.RenameMetadataField(ctx, "ds", "meta", "customer")
+				return err
+			},
+			method: http.MethodPatch,
+			path:   "/datasets/ds/metadata/meta",
+			body:   `{"name":"customer"}`,
+		},
+		{
+			name: "delete",
+			call: func(ctx context.Context, c *Client) error {
+				return c.DeleteMetadataField(ctx, "ds", "meta")
+			},
+			method: http.MethodDelete,
+			path:   "/datasets/ds/metadata/meta",
+		},
+		{
+			name: "built-in",
+			call: func(ctx context.Context, c *Client) error {
+				return c.SetBuiltInMetadata(ctx, "ds", false)
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/metadata/built-in/disable",
+		},
+		{
+			name: "document metadata",
+			call: func(ctx context.Context, c *Client) error {
+				return c.UpdateDocumentsMetadata(ctx, "ds", []DocumentMetadataUpdate{{
+					DocumentID:   "doc",
+					MetadataList: []DocumentMetadata{{ID: "meta", Name: "tenant", Value: "acme"}},
+				}})
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/documents/metadata",
+			body:   `{"operation_data":[{"document_id":"doc","metadata_list":[{"id":"meta","name":"tenant","value":"acme"}]}]}`,
+		},
+	})
+}
+
+// 测试检索接口的请求格式，包括混合检索权重和元数据过滤条件
+func TestRetrieveWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "hybrid with metadata filter",
+			call: func(ctx context.Context, c *Client) error {
+				req, err := NewRetrieveRequest("refund").
+					HybridSearch(0.3, 0.7).
+					TopK(5).
+					ScoreThreshold(0.5).
+					MatchAll(Is("tenant", "acme"), GreaterThan("version", 2)).
+					Build()
+				if err != nil {
+					return err
+				}
+				_, err = c.Retrieve(ctx, "ds", req)
+				return err
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/retrieve",
+			body: `{"query":"refund","retrieval_model":{"search_method":"hybrid_search","reranking_enable":false,
+				"reranking_mode":"weighted_score","reranking_model":null,
+				"weights":{"weight_type":"customized","keyword_setting":{"keyword_weight":0.3},"vector_setting":{"vector_weight":0.7}},
+				"top_k":5,"score_threshold_enabled":true,"score_threshold":0.5,
+				"metadata_filtering_conditions":{"logical_operator":"and","conditions":[
+					{"name":"tenant","comparison_operator":"is","value":"acme"},
+					{"name":"version","comparison_operator":">","value":2}]}}}`,
+			response: `{"query":{"content":"refund"},"records":[]}`,
+		},
+		{
+			name: "default model",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.Retrieve(ctx, "ds", &RetrieveRequest{Query: "refund"})
+				return err
+			},
+			method: http.MethodPost,
+			path:   "/datasets/ds/retrieve",
+			body:   `{"query":"refund"}`,
+		},
+	})
+}
+
+// 测试标签接口的请求格式
+func TestTagsWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "create",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.CreateKnowledgeTag(ctx, "team")
+				return err
+			},
+			method: http.MethodPost,
+			path:   "/datasets/tags",
+			body:   `{"name":"team"}`,
+		},
+		{
+			name: "list",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListKnowledgeTags(ctx)
+				return err
+			},
+			method:   http.MethodGet,
+			path:     "/datasets/tags",
+			response: `[]`,
+		},
+		{
+			name: "rename",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.RenameKnowledgeTag(ctx, "tag", "public")
+				return err
+			},
+			method: http.MethodPatch,
+			path:   "/datasets/tags",
+			body:   `{"tag_id":"tag","name":"public"}`,
+		},
+		{
+			name: "delete",
+			call: func(ctx context.Context, c *Client) error {
+				return c.DeleteKnowledgeTag(ctx, "tag")
+			},
+			method: http.MethodDelete,
+			path:   "/datasets/tags",
+			body:   `{"tag_id":"tag"}`,
+		},
+		{
+			name: "bind",
+			call: func(ctx context.Context, c *Client) error {
+				return c.BindKnowledgeTags(ctx, "ds", []string{"t1", "t2"})
+			},
+			method: http.MethodPost,
+			path:   "/datasets/tags/binding",
+			body:   `{"tag_ids":["t1","t2"],"target_id":"ds"}`,
+		},
+		{
+			name: "unbind",
+			call: func(ctx context.Context, c *Client) error {
+				return c.UnbindKnowledgeTag(ctx, "ds", "t1")
+			},
+			method: http.MethodPost,
+			path:   "/datasets/tags/unbinding",
+			body:   `{"tag_id":"t1","target_id":"ds"}`,
+		},
+		{
+			name: "dataset tags",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.GetKnowledgeTags(ctx, "ds")
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets/ds/tags",
+		},
+		{
+			name: "list knowledge by tags",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ListKnowledge(ctx, &ListKnowledgeRequest{Page: 1, TagIDs: []string{"t1", "t2"}, IncludeAll: true})
+				return err
+			},
+			method: http.MethodGet,
+			path:   "/datasets",
+			query:  url.Values{"page": {"1"}, "tag_ids": {"t1", "t2"}, "include_all": {"true"}},
+		},
+	})
+}
+
+// 测试文档状态和分段启用接口的请求格式
+func TestDocumentStatusWire(t *testing.T) {
+	runWireCases(t, []wireCase{
+		{
+			name: "documents",
+			call: func(ctx context.Context, c *Client) error {
+				return c.UpdateDocumentsStatus(ctx, "ds", DocumentActionDisable, []string{"d1", "d2"})
+			},
+			method:   http.MethodPatch,
+			path:     "/datasets/ds/documents/status/disable",
+			body:     `{"document_ids":["d1","d2"]}`,
+			response: `{"result":"success"}`,
+		},
+		{
+			name: "un_archive",
+			call: func(ctx context.Context, c *Client) error {
+				return c.UpdateDocumentsStatus(ctx, "ds", DocumentActionUnarchive, []string{"d1"})
+			},
+			method:   http.MethodPatch,
+			path:     "/datasets/ds/documents/status/un_archive",
+			body:     `{"document_ids":["d1"]}`,
+			response: `{"result":"success"}`,
+		},
+	})
+}
+
+// 测试启用分段时先查询分段，再连同原有内容提交
+func TestSetSegmentsEnabledWire(t *testing.T) {
+	var requests []string
+	var update []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			io.WriteString(w, `{"data":{"id":"seg","content":"q","answer":"a","keywords":["k"],"enabled":false}}`)
+			return
+		}
+		update, _ = io.ReadAll(r.Body)
+		io.WriteString(w, `{"data":{"id":"seg","enabled":true}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("key", WithBaseURL(srv.URL))
+	if err := c.SetSegmentsEnabled(context.Background(), "ds", "doc", true, []string{"seg"}); err != nil {
+		t.Fatal(err)
+	}
+	const path = "/datasets/ds/documents/doc/segments/seg"
+	if len(requests) != 2 || requests[0] != "GET "+path || requests[1] != "POST "+path {
+		t.Fatalf("requests = %v", requests)
+	}
+	var got, want interface{}
+	json.Unmarshal(update, &got)
+	json.Unmarshal([]byte(`{"segment":{"content":"q","answer":"a","keywords":["k"],"enabled":true}}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("body = %s", update)
+	}
+}